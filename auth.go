@@ -0,0 +1,33 @@
+package mockaso
+
+import (
+	"net/http"
+)
+
+// MatchBasicAuth sets a rule to match the http request with the given HTTP Basic authentication credentials.
+func MatchBasicAuth(user, pass string) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		reqUser, reqPass, ok := r.BasicAuth()
+		return ok && reqUser == user && reqPass == pass
+	})
+
+	return describedRule(matcher, "basic auth mismatch")
+}
+
+// MatchBearerToken sets a rule to match the http request with the given token in the Authorization: Bearer header.
+func MatchBearerToken(token string) StubMatcherRule {
+	return MatchBearerTokenFunc(func(reqToken string) bool {
+		return reqToken == token
+	})
+}
+
+// MatchBearerTokenFunc sets a rule to match the http request with a token in the Authorization: Bearer header
+// which satisfies the predicate, e.g. to inspect the claims of a JWT. Requests without a bearer token do not match.
+func MatchBearerTokenFunc(predicate func(string) bool) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		token, ok := bearerToken(r)
+		return ok && predicate(token)
+	})
+
+	return describedRule(matcher, "bearer token mismatch")
+}
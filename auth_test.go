@@ -0,0 +1,114 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestMatchBasicAuth(t *testing.T) {
+	t.Parallel()
+
+	rule := mockaso.MatchBasicAuth("john", "secret")
+
+	testCases := map[string]struct {
+		user, pass string
+		noAuth     bool
+		expected   bool
+	}{
+		"should match the credentials": {
+			user:     "john",
+			pass:     "secret",
+			expected: true,
+		},
+		"should not match another user": {
+			user: "rick",
+			pass: "secret",
+		},
+		"should not match another password": {
+			user: "john",
+			pass: "wrong",
+		},
+		"should not match without credentials": {
+			noAuth: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(http.MethodGet, "/test/basic-auth", http.NoBody)
+			if !tc.noAuth {
+				httpReq.SetBasicAuth(tc.user, tc.pass)
+			}
+
+			assert.Equal(t, tc.expected, stubMatches(httpReq, rule))
+		})
+	}
+}
+
+func TestMatchBearerToken(t *testing.T) {
+	t.Parallel()
+
+	isAdminToken := func(token string) bool { return strings.HasPrefix(token, "admin.") }
+
+	testCases := map[string]struct {
+		rule          mockaso.StubMatcherRule
+		authorization string
+		expected      bool
+	}{
+		"should match the token": {
+			rule:          mockaso.MatchBearerToken("abc123"),
+			authorization: "Bearer abc123",
+			expected:      true,
+		},
+		"should match the scheme case insensitively": {
+			rule:          mockaso.MatchBearerToken("abc123"),
+			authorization: "bearer abc123",
+			expected:      true,
+		},
+		"should not match another token": {
+			rule:          mockaso.MatchBearerToken("abc123"),
+			authorization: "Bearer xyz789",
+		},
+		"should not match another scheme": {
+			rule:          mockaso.MatchBearerToken("abc123"),
+			authorization: "Basic abc123",
+		},
+		"should not match without authorization": {
+			rule: mockaso.MatchBearerToken("abc123"),
+		},
+		"should match a token satisfying the predicate": {
+			rule:          mockaso.MatchBearerTokenFunc(isAdminToken),
+			authorization: "Bearer admin.abc123",
+			expected:      true,
+		},
+		"should not match a token not satisfying the predicate": {
+			rule:          mockaso.MatchBearerTokenFunc(isAdminToken),
+			authorization: "Bearer user.abc123",
+		},
+		"should not match an empty token": {
+			rule:          mockaso.MatchBearerTokenFunc(func(string) bool { return true }),
+			authorization: "Bearer ",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(http.MethodGet, "/test/bearer-token", http.NoBody)
+			if tc.authorization != "" {
+				httpReq.Header.Set("Authorization", tc.authorization)
+			}
+
+			assert.Equal(t, tc.expected, stubMatches(httpReq, tc.rule))
+		})
+	}
+}
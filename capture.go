@@ -0,0 +1,92 @@
+package mockaso
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// captureBufferSize is the capacity of the channels returned by Stub.Capture.
+const captureBufferSize = 100
+
+// CapturedRequest is a request matched by a stub. See Stub.Capture.
+type CapturedRequest struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+	Params map[string]string // the path params, see ParamsFromRequest
+}
+
+// Capture returns a channel that receives each request matched by the stub, with its body.
+// The channel is buffered; when it is full, the response waits until the captured request is received.
+//
+// Example:
+//
+//	captured := server.Stub(http.MethodPost, Path("/events")).Capture()
+//	// ... run the code under test
+//	req := <-captured
+func (s *stub) Capture() <-chan *CapturedRequest {
+	s.capturesMutex.Lock()
+	defer s.capturesMutex.Unlock()
+
+	ch := make(chan *CapturedRequest, captureBufferSize)
+	s.captures = append(s.captures, ch)
+
+	return ch
+}
+
+// LastRequest returns the last request matched by the stub, or nil if it was not called. Its body can be read, except
+// for requests with Expect: 100-continue whose body was not read by the stub.
+func (s *stub) LastRequest() *http.Request {
+	last := s.lastRequest.Load()
+	if last == nil {
+		return nil
+	}
+
+	r := last.request.Clone(last.request.Context())
+	r.Body = io.NopCloser(bytes.NewReader(last.body))
+
+	return r
+}
+
+// matchedRequest is a request matched by a stub, kept when it is matched, before its response is written.
+type matchedRequest struct {
+	request *http.Request
+	body    []byte
+}
+
+func (s *stub) capture(r *http.Request) {
+	s.lastRequest.Store(&matchedRequest{
+		request: r.Clone(context.WithoutCancel(r.Context())),
+		body:    bodySnapshot(r),
+	})
+
+	s.capturesMutex.Lock()
+	captures := s.captures
+	s.capturesMutex.Unlock()
+
+	if len(captures) == 0 {
+		return
+	}
+
+	u := *r.URL
+
+	captured := &CapturedRequest{
+		Method: r.Method,
+		URL:    &u,
+		Header: r.Header.Clone(),
+		Body:   bodySnapshot(r),
+		Params: ParamsFromRequest(r),
+	}
+
+	for _, ch := range captures {
+		select {
+		case ch <- captured:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestStub_Capture(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const path = "/test/capture"
+
+	st := server.Stub(http.MethodPost, mockaso.Path(path))
+	st.Match(mockaso.MatchHeader("X-Capture", "true")).Respond(mockaso.WithStatusCode(http.StatusAccepted))
+
+	captured := st.Capture()
+
+	go func() {
+		for _, body := range []string{`{"id":1}`, `{"id":2}`} {
+			httpReq, _ := http.NewRequest(http.MethodPost, path+"?source=test", strings.NewReader(body))
+			httpReq.Header.Set("X-Capture", "true")
+
+			if httpResp, err := server.Client().Do(httpReq); err == nil {
+				_ = httpResp.Body.Close()
+			}
+		}
+	}()
+
+	for _, expectedBody := range []string{`{"id":1}`, `{"id":2}`} {
+		select {
+		case req := <-captured:
+			assert.Equal(t, http.MethodPost, req.Method)
+			assert.Equal(t, path+"?source=test", req.URL.String())
+			assert.Equal(t, "true", req.Header.Get("X-Capture"))
+			assert.Equal(t, expectedBody, string(req.Body))
+		case <-time.After(time.Second):
+			require.FailNow(t, "request not captured")
+		}
+	}
+
+	t.Run("should not capture requests that do not match the stub", func(t *testing.T) {
+		httpReq, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(`{"id":3}`))
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assertNotMatchedResponse(t, httpReq, httpResp)
+		assert.Empty(t, captured)
+	})
+}
+
+func TestStub_LastRequest(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	st := server.Stub(http.MethodPost, mockaso.PathPattern("/users/{id}/events"))
+	st.Respond(mockaso.WithStatusCode(http.StatusAccepted))
+
+	assert.Nil(t, st.LastRequest())
+
+	for _, body := range []string{`{"n":1}`, `{"n":2}`} {
+		httpReq, _ := http.NewRequest(http.MethodPost, "/users/42/events", strings.NewReader(body))
+		httpReq.Header.Set("X-Request-Id", body)
+
+		_, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+	}
+
+	last := st.LastRequest()
+	require.NotNil(t, last)
+
+	assert.Equal(t, "/users/42/events", last.URL.Path)
+	assert.Equal(t, `{"n":2}`, last.Header.Get("X-Request-Id"))
+	assert.Equal(t, "42", last.PathValue("id"))
+	assert.Equal(t, `{"n":2}`, readString(last.Body))
+	assert.Equal(t, `{"n":2}`, readString(st.LastRequest().Body), "the body can be read again")
+}
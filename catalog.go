@@ -0,0 +1,67 @@
+package mockaso
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Catalog is a reusable bundle of named stub templates, e.g. the mocks of an external provider shipped as a package
+// to be used across repositories. Apply it to a server with Server.Use.
+//
+// Example:
+//
+//	var Stripe = mockaso.NewCatalog("stripe").
+//		Add("create-charge", func(s *mockaso.Server) {
+//			s.Stub(http.MethodPost, mockaso.Path("/v1/charges")).Respond(mockaso.WithJSON(charge))
+//		}).
+//		Add("card-declined", func(s *mockaso.Server) { ... })
+type Catalog struct {
+	name      string
+	names     []string
+	templates map[string]func(*Server)
+}
+
+// NewCatalog returns an empty catalog with the given name.
+func NewCatalog(name string) *Catalog {
+	return &Catalog{name: name, templates: make(map[string]func(*Server))}
+}
+
+// Name returns the catalog name.
+func (c *Catalog) Name() string {
+	return c.name
+}
+
+// Add adds a named stub template, a func that registers stubs in the server the catalog is used.
+// It panics if the name was already added.
+func (c *Catalog) Add(name string, template func(*Server)) *Catalog {
+	if _, ok := c.templates[name]; ok {
+		panic(fmt.Errorf("catalog %s: stub %s already added", c.name, name))
+	}
+
+	c.names = append(c.names, name)
+	c.templates[name] = template
+
+	return c
+}
+
+// Names returns the names of the stub templates, in the order they were added.
+func (c *Catalog) Names() []string {
+	return slices.Clone(c.names)
+}
+
+// Use registers the stubs of the catalog in the server. When names are given, only these stub templates are used.
+// It panics if a name is not in the catalog.
+func (s *Server) Use(catalog *Catalog, names ...string) {
+	if len(names) == 0 {
+		names = catalog.names
+	}
+
+	for _, name := range names {
+		template, ok := catalog.templates[name]
+		if !ok {
+			panic(fmt.Errorf("catalog %s: stub %s not found", catalog.name, name))
+		}
+
+		template(s)
+	}
+}
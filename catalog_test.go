@@ -0,0 +1,85 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestServer_Use(t *testing.T) {
+	t.Parallel()
+
+	catalog := mockaso.NewCatalog("users-api").
+		Add("get-user", func(s *mockaso.Server) {
+			s.Stub(http.MethodGet, mockaso.Path("/users/1")).Respond(mockaso.WithBody("john"))
+		}).
+		Add("delete-user", func(s *mockaso.Server) {
+			s.Stub(http.MethodDelete, mockaso.Path("/users/1")).Respond(mockaso.WithStatusCode(http.StatusNoContent))
+		})
+
+	send := func(t *testing.T, server *mockaso.Server, method string) *http.Response {
+		httpReq, _ := http.NewRequest(method, "/users/1", http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		return httpResp
+	}
+
+	t.Run("should register all the stubs of the catalog", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		server.Use(catalog)
+
+		httpResp := send(t, server, http.MethodGet)
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assertBodyString(t, "john", httpResp)
+
+		httpResp = send(t, server, http.MethodDelete)
+		assert.Equal(t, http.StatusNoContent, httpResp.StatusCode)
+	})
+
+	t.Run("should register only the given stubs of the catalog", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		server.Use(catalog, "delete-user")
+
+		httpResp := send(t, server, http.MethodDelete)
+		assert.Equal(t, http.StatusNoContent, httpResp.StatusCode)
+
+		httpResp = send(t, server, http.MethodGet)
+		assert.Equal(t, 666, httpResp.StatusCode)
+	})
+
+	t.Run("should panic when the stub is not in the catalog", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.NewServer()
+
+		fn := func() { server.Use(catalog, "create-user") }
+		assert.PanicsWithError(t, "catalog users-api: stub create-user not found", fn)
+	})
+
+	t.Run("should panic when the stub was already added", func(t *testing.T) {
+		t.Parallel()
+
+		fn := func() { mockaso.NewCatalog("users-api").Add("get-user", nil).Add("get-user", nil) }
+		assert.PanicsWithError(t, "catalog users-api: stub get-user already added", fn)
+	})
+
+	t.Run("should return the names in the order they were added", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, "users-api", catalog.Name())
+		assert.Equal(t, []string{"get-user", "delete-user"}, catalog.Names())
+	})
+}
@@ -0,0 +1,25 @@
+package mockaso
+
+import "time"
+
+// Clock provides the current time and timers to the server. See WithClock.
+// It is satisfied by most fake time libraries, so tests can advance the time instead of really waiting.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// WithClock sets the Clock used to wait the response delays (see WithDelay) and the long polling timeouts,
+// to expire the OAuth2 tokens (see Server.OAuth2TokenEndpoint), to set the time claims of the OIDC tokens (see
+// OIDCProvider.MintToken) and to timestamp the HMAC signatures (see HMACStripeSigner).
+// By default the real time is used.
+func WithClock(clock Clock) ServerOption {
+	return func(s *Server) {
+		s.clock = clock
+	}
+}
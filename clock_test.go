@@ -0,0 +1,143 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+// fakeClock is a manually advanced Clock.
+type fakeClock struct {
+	now     time.Time
+	waiters []fakeWaiter
+	mutex   sync.Mutex
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, fakeWaiter{deadline: c.now.Add(d), ch: ch})
+
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+
+	pending := c.waiters[:0]
+
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+
+		w.ch <- c.now
+	}
+
+	c.waiters = pending
+}
+
+func (c *fakeClock) Waiting() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.waiters)
+}
+
+func TestWithClock(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should wait the response delay with the given clock", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &fakeClock{now: time.Now()}
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithClock(clock))
+		t.Cleanup(server.MustShutdown)
+
+		server.Stub(http.MethodGet, mockaso.Path("/test/clock/delay")).
+			Respond(mockaso.WithStatusCode(http.StatusOK), mockaso.WithDelay(time.Hour))
+
+		done := make(chan *http.Response, 1)
+
+		go func() {
+			httpReq, _ := http.NewRequest(http.MethodGet, "/test/clock/delay", http.NoBody)
+			httpResp, _ := server.Client().Do(httpReq)
+			done <- httpResp
+		}()
+
+		require.Eventually(t, func() bool { return clock.Waiting() == 1 }, time.Second, 10*time.Millisecond)
+
+		clock.Advance(59 * time.Minute)
+		select {
+		case <-done:
+			t.Fatal("response sent before the delay elapsed")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		clock.Advance(time.Minute)
+		select {
+		case httpResp := <-done:
+			require.NotNil(t, httpResp)
+			assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		case <-time.After(time.Second):
+			t.Fatal("response not sent after the delay elapsed")
+		}
+	})
+
+	t.Run("should expire the long polling timeout with the given clock", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &fakeClock{now: time.Now()}
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithClock(clock))
+		t.Cleanup(server.MustShutdown)
+
+		server.Stub(http.MethodGet, mockaso.Path("/test/clock/poll")).
+			Respond(
+				mockaso.WithStatusCode(http.StatusNoContent),
+				mockaso.WithLongPoll(mockaso.NewLongPoll(), time.Minute),
+			)
+
+		done := make(chan *http.Response, 1)
+
+		go func() {
+			httpReq, _ := http.NewRequest(http.MethodGet, "/test/clock/poll", http.NoBody)
+			httpResp, _ := server.Client().Do(httpReq)
+			done <- httpResp
+		}()
+
+		require.Eventually(t, func() bool { return clock.Waiting() == 1 }, time.Second, 10*time.Millisecond)
+		clock.Advance(time.Minute)
+
+		select {
+		case httpResp := <-done:
+			require.NotNil(t, httpResp)
+			assert.Equal(t, http.StatusNoContent, httpResp.StatusCode)
+		case <-time.After(time.Second):
+			t.Fatal("long polling timeout not expired")
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package mockaso
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const identityEncoding = "identity"
+
+// WithNegotiatedEncoding sets the response body to be compressed depending on the request Accept-Encoding header.
+// Supported encodings are br, gzip and identity (not compressed), in that order of preference.
+// The response will include the Content-Encoding header when the body is compressed.
+// When the request does not accept any encoding, the response will be 406 Not Acceptable.
+func WithNegotiatedEncoding() StubResponseRule {
+	return func(r *stubResponse) {
+		r.negotiateEncoding = true
+		r.compression = ""
+	}
+}
+
+// WithCompression sets the response body to be compressed with the given encoding, gzip, br or zstd, regardless of
+// the request Accept-Encoding header, e.g. to test the decompression of the client.
+// The response will include the Content-Encoding header. It panics if the encoding is not supported.
+// See WithNegotiatedEncoding to compress the body depending on the request.
+func WithCompression(encoding string) StubResponseRule {
+	if !slices.Contains(compressionEncodings, encoding) {
+		panic(fmt.Errorf("WithCompression err: unsupported encoding: %s", encoding))
+	}
+
+	return func(r *stubResponse) {
+		r.compression = encoding
+		r.negotiateEncoding = false
+	}
+}
+
+// WithGzipBody sets the response body to be compressed with gzip. See WithCompression.
+func WithGzipBody() StubResponseRule {
+	return WithCompression("gzip")
+}
+
+// compressionEncodings are the encodings supported by WithCompression.
+var compressionEncodings = []string{"gzip", "br", "zstd"}
+
+// encodeCompressed compresses the body with the given encoding.
+func encodeCompressed(w http.ResponseWriter, encoding string, body []byte) []byte {
+	w.Header().Set("Content-Encoding", encoding)
+	return mustCompress(encoding, body)
+}
+
+// encodeNegotiated compresses the body with the encoding that best satisfies the request Accept-Encoding header.
+func encodeNegotiated(w http.ResponseWriter, r *http.Request, body []byte) ([]byte, bool) {
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	encoding, ok := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+	if !ok {
+		return nil, false
+	}
+
+	if encoding == identityEncoding {
+		return body, true
+	}
+
+	w.Header().Set("Content-Encoding", encoding)
+
+	return mustCompress(encoding, body), true
+}
+
+func negotiateEncoding(header string) (string, bool) {
+	accepted := parseAccept(header)
+	if len(accepted) == 0 {
+		return identityEncoding, true
+	}
+
+	// identity is always acceptable unless it is explicitly refused
+	if !containsAcceptValue(accepted, identityEncoding) && !containsAcceptValue(accepted, "*") {
+		accepted = append(accepted, acceptValue{value: identityEncoding, q: 0.001})
+	}
+
+	return negotiate(accepted, []string{"br", "gzip", identityEncoding}, encodingSpecificity)
+}
+
+func containsAcceptValue(values []acceptValue, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v.value, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// encodingSpecificity matches content codings like * or gzip (case-insensitive).
+func encodingSpecificity(pattern, offer string) int {
+	switch {
+	case strings.EqualFold(pattern, offer):
+		return 1
+	case pattern == "*":
+		return 0
+	default:
+		return -1
+	}
+}
+
+// decodeContent decompresses the data encoded with the content codings of a Content-Encoding header, which are
+// listed in the order they were applied.
+func decodeContent(header string, data []byte) ([]byte, error) {
+	codings := strings.Split(header, ",")
+
+	for i := len(codings) - 1; i >= 0; i-- {
+		var err error
+
+		if data, err = decompress(strings.ToLower(strings.TrimSpace(codings[i])), data); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+func decompress(coding string, data []byte) ([]byte, error) {
+	var reader io.Reader
+
+	switch coding {
+	case identityEncoding:
+		return data, nil
+	case "gzip", "x-gzip":
+		gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		reader = gzipReader
+	case "deflate": // the zlib format (RFC 9110)
+		zlibReader, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		reader = zlibReader
+	case "br":
+		reader = brotli.NewReader(bytes.NewReader(data))
+	case "zstd":
+		decoder, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		defer decoder.Close()
+
+		return decoder.DecodeAll(data, nil)
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %s", coding)
+	}
+
+	return io.ReadAll(reader)
+}
+
+func mustCompress(encoding string, data []byte) []byte {
+	var buff bytes.Buffer
+
+	var writer io.WriteCloser
+
+	switch encoding {
+	case "gzip":
+		writer = gzip.NewWriter(&buff)
+	case "br":
+		writer = brotli.NewWriter(&buff)
+	case "zstd":
+		writer, _ = zstd.NewWriter(&buff) // fails only with invalid options
+	default:
+		panic(fmt.Errorf("unsupported encoding: %s", encoding))
+	}
+
+	if _, err := writer.Write(data); err != nil {
+		panic(fmt.Errorf("compress %s body failed: %w", encoding, err))
+	}
+
+	if err := writer.Close(); err != nil {
+		panic(fmt.Errorf("compress %s body failed: %w", encoding, err))
+	}
+
+	return buff.Bytes()
+}
@@ -0,0 +1,315 @@
+package mockaso_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestWithNegotiatedEncoding(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const (
+		path = "/test/negotiated-encoding"
+		body = "a body long enough to be compressed, compressed, compressed"
+	)
+
+	server.Stub(http.MethodGet, mockaso.Path(path)).
+		Respond(
+			mockaso.WithBody(body),
+			mockaso.WithNegotiatedEncoding(),
+		)
+
+	testCases := map[string]struct {
+		acceptEncoding   string
+		expectedEncoding string
+		decoder          func(io.Reader) io.Reader
+	}{
+		"should respond with brotli when it is preferred": {
+			acceptEncoding:   "gzip, deflate, br",
+			expectedEncoding: "br",
+			decoder:          func(r io.Reader) io.Reader { return brotli.NewReader(r) },
+		},
+		"should respond with gzip when it has higher q-value": {
+			acceptEncoding:   "br;q=0.5, gzip",
+			expectedEncoding: "gzip",
+			decoder:          gzipDecoder(t),
+		},
+		"should respond with gzip when it is the only accepted": {
+			acceptEncoding:   "gzip",
+			expectedEncoding: "gzip",
+			decoder:          gzipDecoder(t),
+		},
+		"should respond with identity when accepted encodings are not supported": {
+			acceptEncoding: "zstd",
+		},
+		"should respond with identity when accept encoding is identity": {
+			acceptEncoding: "identity",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq, _ := http.NewRequest(http.MethodGet, path, http.NoBody)
+			httpReq.Header.Set("Accept-Encoding", tc.acceptEncoding)
+
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+			assert.Equal(t, tc.expectedEncoding, httpResp.Header.Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", httpResp.Header.Get("Vary"))
+
+			var reader io.Reader = httpResp.Body
+			if tc.decoder != nil {
+				reader = tc.decoder(reader)
+			}
+
+			assert.Equal(t, body, readString(reader))
+		})
+	}
+
+	t.Run("should respond with identity when request has no accept encoding", func(t *testing.T) {
+		t.Parallel()
+
+		transport := &http.Transport{DisableCompression: true} // the default transport adds Accept-Encoding: gzip
+		httpResp, err := (&http.Client{Transport: transport}).Get(server.URL() + path)
+		require.NoError(t, err)
+
+		assert.Empty(t, httpResp.Header.Get("Content-Encoding"))
+		assertBodyString(t, body, httpResp)
+	})
+
+	t.Run("should be transparently decompressed by the default client", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, _ := http.NewRequest(http.MethodGet, path, http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.True(t, httpResp.Uncompressed)
+		assertBodyString(t, body, httpResp)
+	})
+
+	t.Run("should respond not acceptable when identity is refused", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, _ := http.NewRequest(http.MethodGet, path, http.NoBody)
+		httpReq.Header.Set("Accept-Encoding", "zstd, identity;q=0")
+
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusNotAcceptable, httpResp.StatusCode)
+	})
+}
+
+func gzipDecoder(t *testing.T) func(io.Reader) io.Reader {
+	t.Helper()
+
+	return func(r io.Reader) io.Reader {
+		reader, err := gzip.NewReader(r)
+		require.NoError(t, err)
+
+		return reader
+	}
+}
+
+func TestWithCompression(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const body = "a body long enough to be compressed, compressed, compressed"
+
+	testCases := map[string]struct {
+		rule     mockaso.StubResponseRule
+		encoding string
+		decoder  func(io.Reader) io.Reader
+	}{
+		"should compress with gzip": {
+			rule:     mockaso.WithGzipBody(),
+			encoding: "gzip",
+			decoder:  gzipDecoder(t),
+		},
+		"should compress with brotli": {
+			rule:     mockaso.WithCompression("br"),
+			encoding: "br",
+			decoder:  func(r io.Reader) io.Reader { return brotli.NewReader(r) },
+		},
+		"should compress with zstd": {
+			rule:     mockaso.WithCompression("zstd"),
+			encoding: "zstd",
+			decoder: func(r io.Reader) io.Reader {
+				decoder, err := zstd.NewReader(r)
+				require.NoError(t, err)
+
+				return decoder
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := "/test/compression/" + tc.encoding
+			server.Stub(http.MethodGet, mockaso.Path(path)).Respond(mockaso.WithBody(body), tc.rule)
+
+			httpReq, _ := http.NewRequest(http.MethodGet, path, http.NoBody)
+			httpReq.Header.Set("Accept-Encoding", "identity") // ignored, the body is always compressed
+
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+			assert.Equal(t, tc.encoding, httpResp.Header.Get("Content-Encoding"))
+			assert.Equal(t, body, readString(tc.decoder(httpResp.Body)))
+		})
+	}
+
+	t.Run("should panic when the encoding is not supported", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Panics(t, func() { mockaso.WithCompression("deflate") })
+	})
+}
+
+func TestMatchJSONBody_CompressedBody(t *testing.T) {
+	t.Parallel()
+
+	const body = `{"name":"john","age":57}`
+
+	compress := func(newWriter func(io.Writer) io.WriteCloser) string {
+		var buff bytes.Buffer
+
+		writer := newWriter(&buff)
+		_, _ = writer.Write([]byte(body))
+		_ = writer.Close()
+
+		return buff.String()
+	}
+
+	gzipBody := compress(func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })
+
+	testCases := map[string]struct {
+		encoding string
+		body     string
+		expected bool
+	}{
+		"should match a gzip body": {
+			encoding: "gzip",
+			body:     gzipBody,
+			expected: true,
+		},
+		"should match a deflate body": {
+			encoding: "deflate",
+			body:     compress(func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }),
+			expected: true,
+		},
+		"should match a brotli body": {
+			encoding: "br",
+			body:     compress(func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) }),
+			expected: true,
+		},
+		"should match a zstd body": {
+			encoding: "zstd",
+			body: compress(func(w io.Writer) io.WriteCloser {
+				encoder, _ := zstd.NewWriter(w)
+				return encoder
+			}),
+			expected: true,
+		},
+		"should match a body encoded several times": {
+			encoding: "gzip, br",
+			body: compress(func(w io.Writer) io.WriteCloser {
+				brotliWriter := brotli.NewWriter(w)
+				return writeCloser{Writer: gzip.NewWriter(brotliWriter), closers: []io.Closer{brotliWriter}}
+			}),
+			expected: true,
+		},
+		"should match a body which is not compressed": {
+			encoding: "identity",
+			body:     body,
+			expected: true,
+		},
+		"should match the body as it was sent when it can not be decompressed": {
+			encoding: "gzip",
+			body:     body,
+			expected: true,
+		},
+		"should not match a body with an unsupported encoding": {
+			encoding: "compress",
+			body:     gzipBody,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tc.body))
+			httpReq.Header.Set("Content-Encoding", tc.encoding)
+
+			assert.Equal(t, tc.expected, stubMatches(httpReq, mockaso.MatchRawJSONBody(body)))
+		})
+	}
+
+	t.Run("should not decompress the recorded body", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		server.Stub(http.MethodPost, mockaso.Path("/users")).
+			Match(mockaso.MatchRawJSONBody(body)).
+			Respond(mockaso.WithStatusCode(http.StatusCreated))
+
+		httpReq, _ := http.NewRequest(http.MethodPost, "/users", strings.NewReader(gzipBody))
+		httpReq.Header.Set("Content-Encoding", "gzip")
+
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, httpResp.StatusCode)
+
+		requests := server.Requests()
+		require.Len(t, requests, 1)
+		assert.Equal(t, gzipBody, string(requests[0].Body))
+	})
+}
+
+// writeCloser closes the writer and then the closers, e.g. to close nested compression writers.
+type writeCloser struct {
+	io.Writer
+	closers []io.Closer
+}
+
+func (w writeCloser) Close() error {
+	if closer, ok := w.Writer.(io.Closer); ok {
+		_ = closer.Close()
+	}
+
+	for _, closer := range w.closers {
+		_ = closer.Close()
+	}
+
+	return nil
+}
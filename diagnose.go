@@ -0,0 +1,73 @@
+package mockaso
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/royhq/mockaso/internal/jsondiff"
+)
+
+// matchDiagnostics collects why the matchers of a stub rejected a request.
+type matchDiagnostics struct {
+	bodyDiffs [][]string // field-level diffs reported by body matchers
+}
+
+type diagnosticsKey struct{}
+
+// diagnosticsFrom returns the diagnostics of the request, or nil if the request is not being diagnosed.
+func diagnosticsFrom(r *http.Request) *matchDiagnostics {
+	diagnostics, _ := r.Context().Value(diagnosticsKey{}).(*matchDiagnostics)
+	return diagnostics
+}
+
+// reportJSONBodyDiff reports the difference between the expected and the actual JSON body, if diagnosed.
+func reportJSONBodyDiff(r *http.Request, expected, actual []byte) {
+	diagnostics := diagnosticsFrom(r)
+	if diagnostics == nil {
+		return
+	}
+
+	diff, _ := jsondiff.DiffBytes(expected, actual)
+	diagnostics.bodyDiffs = append(diagnostics.bodyDiffs, diff)
+}
+
+// WithNearMissDiff enables the near-miss logging of unmatched requests: when a stub would have matched a request
+// except for its JSON body matchers, a field-level diff between the expected and the actual body is logged.
+// Note the matchers of every stub are evaluated again for unmatched requests.
+func WithNearMissDiff() ServerOption {
+	return func(s *Server) {
+		s.nearMissDiff = true
+	}
+}
+
+// logNearMisses logs the stubs which would have matched the request except for their body.
+func (s *Server) logNearMisses(r *http.Request) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for i, st := range s.stubs {
+		diagnostics := new(matchDiagnostics)
+
+		req := r.WithContext(context.WithValue(r.Context(), diagnosticsKey{}, diagnostics))
+
+		failed := 0
+
+		state := new(matchState)
+
+		for _, match := range st.matchers {
+			if !match(state, req) {
+				failed++
+			}
+		}
+
+		if failed == 0 || failed != len(diagnostics.bodyDiffs) {
+			continue
+		}
+
+		for _, diff := range diagnostics.bodyDiffs {
+			s.logger.Logf("%s nearly matched %s %s, body differs:\n\t%s",
+				st.label(i+1), r.Method, r.URL.String(), strings.Join(diff, "\n\t"))
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestWithNearMissDiff(t *testing.T) {
+	t.Parallel()
+
+	const path = "/test/near-miss"
+
+	newServer := func(t *testing.T, opts ...mockaso.ServerOption) (*mockaso.Server, func() string) {
+		logger, buff := newTestLogLogger()
+
+		server := mockaso.MustStartNewServer(append(opts, mockaso.WithLogger(logger))...)
+		t.Cleanup(server.MustShutdown)
+
+		server.Stub(http.MethodPost, mockaso.Path(path)).
+			Match(mockaso.MatchRawJSONBody(`{"user":{"name":"john","age":30},"tags":["a","b"]}`)).
+			Respond(mockaso.WithStatusCode(http.StatusCreated))
+
+		server.Stub(http.MethodPut, mockaso.Path(path)).
+			Match(mockaso.MatchRawJSONBody(`{"user":{"name":"rick"}}`)).
+			Respond(mockaso.WithStatusCode(http.StatusOK))
+
+		return server, buff.String
+	}
+
+	send := func(t *testing.T, server *mockaso.Server, body string) {
+		httpReq, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assertNotMatchedResponse(t, httpReq, httpResp)
+	}
+
+	t.Run("should log the body diff of the stub that nearly matched", func(t *testing.T) {
+		t.Parallel()
+
+		server, logs := newServer(t, mockaso.WithNearMissDiff())
+		send(t, server, `{"user":{"name":"rick","email":"rick@mail.com"},"tags":["a","c","d"]}`)
+
+		expected := "stub #1 nearly matched POST /test/near-miss, body differs:\n" +
+			"\t- $.tags[1]: \"b\"\n" +
+			"\t+ $.tags[1]: \"c\"\n" +
+			"\t+ $.tags[2]: \"d\"\n" +
+			"\t- $.user.age: 30\n" +
+			"\t+ $.user.email: \"rick@mail.com\"\n" +
+			"\t- $.user.name: \"john\"\n" +
+			"\t+ $.user.name: \"rick\"\n"
+
+		assert.Contains(t, logs(), expected)
+		assert.NotContains(t, logs(), "stub #2")
+	})
+
+	t.Run("should not log the body diff when near-miss diff is not enabled", func(t *testing.T) {
+		t.Parallel()
+
+		server, logs := newServer(t)
+		send(t, server, `{"user":{"name":"rick"}}`)
+
+		assert.NotContains(t, logs(), "nearly matched")
+	})
+}
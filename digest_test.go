@@ -0,0 +1,169 @@
+package mockaso_test
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestWithDigestAuth(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const (
+		path = "/test/digest-auth"
+		uri  = path + "?attrs=name,age"
+	)
+
+	server.Stub(http.MethodGet, mockaso.Path(path)).
+		Respond(
+			mockaso.WithBody("authenticated"),
+			mockaso.WithDigestAuth("test realm", "john", "secret"),
+		)
+
+	challenge := func(t *testing.T) map[string]string {
+		t.Helper()
+
+		httpReq, _ := http.NewRequest(http.MethodGet, uri, http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnauthorized, httpResp.StatusCode)
+
+		header := httpResp.Header.Get("WWW-Authenticate")
+		require.True(t, strings.HasPrefix(header, "Digest "))
+
+		params := make(map[string]string)
+		for _, match := range regexp.MustCompile(`(\w+)="?([^",]+)"?`).FindAllStringSubmatch(header, -1) {
+			params[match[1]] = match[2]
+		}
+
+		return params
+	}
+
+	doAuthenticated := func(t *testing.T, authorization string) *http.Response {
+		t.Helper()
+
+		httpReq, _ := http.NewRequest(http.MethodGet, uri, http.NoBody)
+		httpReq.Header.Set("Authorization", authorization)
+
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		return httpResp
+	}
+
+	t.Run("should respond with challenge when request has no authorization", func(t *testing.T) {
+		t.Parallel()
+
+		params := challenge(t)
+
+		assert.Equal(t, "test realm", params["realm"])
+		assert.Equal(t, "auth", params["qop"])
+		assert.Equal(t, "MD5", params["algorithm"])
+		assert.NotEmpty(t, params["nonce"])
+		assert.NotEmpty(t, params["opaque"])
+	})
+
+	t.Run("should respond with a different nonce on each challenge", func(t *testing.T) {
+		t.Parallel()
+
+		assert.NotEqual(t, challenge(t)["nonce"], challenge(t)["nonce"])
+	})
+
+	t.Run("should respond with stub response when digest is valid", func(t *testing.T) {
+		t.Parallel()
+
+		testCases := map[string]struct {
+			newHash   func() hash.Hash
+			algorithm string
+		}{
+			"md5":     {newHash: md5.New, algorithm: "MD5"},
+			"sha-256": {newHash: sha256.New, algorithm: "SHA-256"},
+		}
+
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				params := challenge(t)
+				authorization := digestAuthorization(tc.newHash, tc.algorithm, "john", "secret", params, uri)
+
+				httpResp := doAuthenticated(t, authorization)
+
+				assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+				assertBodyString(t, "authenticated", httpResp)
+			})
+		}
+	})
+
+	t.Run("should respond with challenge when digest is not valid", func(t *testing.T) {
+		t.Parallel()
+
+		params := challenge(t)
+
+		testCases := map[string]string{
+			"wrong password":   digestAuthorization(md5.New, "MD5", "john", "wrong", params, uri),
+			"wrong username":   digestAuthorization(md5.New, "MD5", "rick", "secret", params, uri),
+			"wrong uri":        digestAuthorization(md5.New, "MD5", "john", "secret", params, path),
+			"unknown nonce":    digestAuthorization(md5.New, "MD5", "john", "secret", withNonce(params, "abc"), uri),
+			"not digest":       "Basic am9objpzZWNyZXQ=",
+			"unsupported algo": digestAuthorization(md5.New, "MD4", "john", "secret", params, uri),
+		}
+
+		for name, authorization := range testCases {
+			t.Run(name, func(t *testing.T) {
+				httpResp := doAuthenticated(t, authorization)
+
+				assert.Equal(t, http.StatusUnauthorized, httpResp.StatusCode)
+				assert.Contains(t, httpResp.Header.Get("WWW-Authenticate"), "Digest ")
+			})
+		}
+	})
+}
+
+func digestAuthorization(newHash func() hash.Hash, algorithm, username, password string,
+	challenge map[string]string, uri string,
+) string {
+	h := func(values ...string) string {
+		hasher := newHash()
+		hasher.Write([]byte(strings.Join(values, ":")))
+
+		return hex.EncodeToString(hasher.Sum(nil))
+	}
+
+	const (
+		nc     = "00000001"
+		cnonce = "0a4f113b"
+	)
+
+	ha1 := h(username, challenge["realm"], password)
+	ha2 := h(http.MethodGet, uri)
+	response := h(ha1, challenge["nonce"], nc, cnonce, "auth", ha2)
+
+	return fmt.Sprintf(
+		`Digest username="%s", realm="%s", nonce="%s", uri="%s", algorithm=%s, qop=auth, nc=%s, cnonce="%s", `+
+			`response="%s", opaque="%s"`,
+		username, challenge["realm"], challenge["nonce"], uri, algorithm, nc, cnonce, response, challenge["opaque"])
+}
+
+func withNonce(params map[string]string, nonce string) map[string]string {
+	copied := make(map[string]string, len(params))
+	for k, v := range params {
+		copied[k] = v
+	}
+
+	copied["nonce"] = nonce
+
+	return copied
+}
@@ -0,0 +1,121 @@
+package mockaso
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// FakerFuncs returns a set of template functions that generate fake data.
+// Using the same seed, the functions will return the same sequence of values.
+//
+// Functions:
+//
+//	fakeFirstName, fakeLastName, fakeName, fakeEmail, fakeUUID, fakeWord, fakePhone,
+//	fakeDate, fakeDateTime, fakeInt <min> <max>, fakeBool, fakePick <values...>
+//
+// Example:
+//
+//	{"id":"{{ fakeUUID }}","name":"{{ fakeName }}","email":"{{ fakeEmail }}"}
+func FakerFuncs(seed uint64) template.FuncMap {
+	f := &faker{rand: newRand(seed)}
+
+	return template.FuncMap{
+		"fakeFirstName": f.locked(f.firstName),
+		"fakeLastName":  f.locked(f.lastName),
+		"fakeName":      f.locked(func() string { return f.firstName() + " " + f.lastName() }),
+		"fakeEmail":     f.locked(f.email),
+		"fakeUUID":      f.locked(func() string { return randomUUID(f.rand) }),
+		"fakeWord":      f.locked(func() string { return randomWord(f.rand, 4+f.rand.IntN(6)) }),
+		"fakePhone":     f.locked(f.phone),
+		"fakeDate":      f.locked(func() string { return randomTime(f.rand).Format(time.DateOnly) }),
+		"fakeDateTime":  f.locked(func() string { return randomTime(f.rand).Format(time.RFC3339) }),
+		"fakeInt":       f.integer,
+		"fakeBool":      f.boolean,
+		"fakePick":      f.pick,
+	}
+}
+
+// SeedFromName returns a seed derived from the given name.
+// Intended for use with the test name to get deterministic fake data per test, e.g. SeedFromName(t.Name()).
+func SeedFromName(name string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+
+	return h.Sum64()
+}
+
+var (
+	fakeFirstNames = []string{"John", "Rick", "Carl", "Maggie", "Glenn", "Michonne", "Daryl", "Carol", "Negan", "Judith"}
+	fakeLastNames  = []string{"Doe", "Grimes", "Dixon", "Rhee", "Greene", "Peletier", "Smith", "Walsh", "Monroe", "Ford"}
+)
+
+type faker struct {
+	rand  *rand.Rand
+	mutex sync.Mutex
+}
+
+// locked protects the access to the random source, since templates could be executed concurrently.
+func (f *faker) locked(fn func() string) func() string {
+	return func() string {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+
+		return fn()
+	}
+}
+
+func (f *faker) firstName() string {
+	return fakeFirstNames[f.rand.IntN(len(fakeFirstNames))]
+}
+
+func (f *faker) lastName() string {
+	return fakeLastNames[f.rand.IntN(len(fakeLastNames))]
+}
+
+func (f *faker) email() string {
+	return strings.ToLower(f.firstName()+"."+f.lastName()) + "@example.com"
+}
+
+func (f *faker) phone() string {
+	return fmt.Sprintf("+1-555-%03d-%04d", f.rand.IntN(1000), f.rand.IntN(10000))
+}
+
+func (f *faker) integer(minimum, maximum int) (int, error) {
+	if minimum > maximum {
+		return 0, fmt.Errorf("fakeInt: min %d is greater than max %d", minimum, maximum)
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	span := uint64(maximum) - uint64(minimum) // in uint64, so wide ranges do not overflow
+	if span == math.MaxUint64 {
+		return int(f.rand.Uint64()), nil
+	}
+
+	return minimum + int(f.rand.Uint64N(span+1)), nil
+}
+
+func (f *faker) boolean() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.rand.IntN(2) == 1
+}
+
+func (f *faker) pick(values ...any) (any, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("fakePick: no values to pick")
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return values[f.rand.IntN(len(values))], nil
+}
@@ -0,0 +1,105 @@
+package mockaso_test
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestFakerFuncs(t *testing.T) {
+	t.Parallel()
+
+	const tmpl = `{{ fakeName }}|{{ fakeEmail }}|{{ fakeUUID }}|{{ fakeDate }}|{{ fakeDateTime }}|` +
+		`{{ fakeInt 1 10 }}|{{ fakeBool }}|{{ fakePick "a" "b" }}|{{ fakePhone }}|{{ fakeWord }}`
+
+	t.Run("should generate fake data", func(t *testing.T) {
+		t.Parallel()
+
+		out := executeFakerTemplate(t, tmpl, mockaso.FakerFuncs(1))
+
+		expectedRegex := `^[A-Z][a-z]+ [A-Z][a-z]+\|[a-z]+\.[a-z]+@example\.com\|` +
+			`[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}\|` +
+			`\d{4}-\d{2}-\d{2}\|\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z\|` +
+			`([1-9]|10)\|(true|false)\|[ab]\|\+1-555-\d{3}-\d{4}\|[a-z]{4,9}$`
+		assert.Regexp(t, expectedRegex, out)
+	})
+
+	t.Run("should generate the same data with the same seed", func(t *testing.T) {
+		t.Parallel()
+
+		seed := mockaso.SeedFromName(t.Name())
+
+		out1 := executeFakerTemplate(t, tmpl, mockaso.FakerFuncs(seed))
+		out2 := executeFakerTemplate(t, tmpl, mockaso.FakerFuncs(seed))
+
+		assert.Equal(t, out1, out2)
+	})
+
+	t.Run("should generate different data with different seeds", func(t *testing.T) {
+		t.Parallel()
+
+		out1 := executeFakerTemplate(t, tmpl, mockaso.FakerFuncs(mockaso.SeedFromName("test 1")))
+		out2 := executeFakerTemplate(t, tmpl, mockaso.FakerFuncs(mockaso.SeedFromName("test 2")))
+
+		assert.NotEqual(t, out1, out2)
+	})
+
+	t.Run("should generate fakeInt within wide bounds", func(t *testing.T) {
+		t.Parallel()
+
+		testCases := map[string]struct {
+			minimum int
+			maximum int
+		}{
+			"zero to max int":     {minimum: 0, maximum: math.MaxInt},
+			"negative to max int": {minimum: -1, maximum: math.MaxInt},
+			"min int to max int":  {minimum: math.MinInt, maximum: math.MaxInt},
+			"single value":        {minimum: math.MaxInt, maximum: math.MaxInt},
+		}
+
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				tmpl := fmt.Sprintf(`{{ fakeInt %d %d }}`, tc.minimum, tc.maximum)
+
+				for seed := range uint64(10) {
+					value, err := strconv.Atoi(executeFakerTemplate(t, tmpl, mockaso.FakerFuncs(seed)))
+					require.NoError(t, err)
+
+					assert.GreaterOrEqual(t, value, tc.minimum)
+					assert.LessOrEqual(t, value, tc.maximum)
+				}
+			})
+		}
+	})
+
+	t.Run("should fail when fakeInt bounds are not valid", func(t *testing.T) {
+		t.Parallel()
+
+		parsed := template.Must(template.New("").Funcs(mockaso.FakerFuncs(1)).Parse(`{{ fakeInt 10 1 }}`))
+		err := parsed.Execute(new(bytes.Buffer), nil)
+
+		assert.ErrorContains(t, err, "fakeInt: min 10 is greater than max 1")
+	})
+}
+
+func executeFakerTemplate(t *testing.T, tmpl string, funcs template.FuncMap) string {
+	t.Helper()
+
+	parsed, err := template.New("").Funcs(funcs).Parse(tmpl)
+	require.NoError(t, err)
+
+	var buff bytes.Buffer
+	require.NoError(t, parsed.Execute(&buff, nil))
+
+	return buff.String()
+}
@@ -0,0 +1,126 @@
+package mockaso
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// MatchFormField sets a rule to match the http request with a form-urlencoded body which has the given field value.
+// If the field has several values, any of them can match.
+func MatchFormField(key, value string) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		form, ok := readForm(r)
+		return ok && slices.Contains(form[key], value)
+	})
+
+	return describedRule(matcher, "form field %s mismatch", key)
+}
+
+// MatchFormBody sets a rule to match the http request with a form-urlencoded body which has exactly the given fields,
+// with their values in the same order. The order of the fields does not matter.
+func MatchFormBody(values url.Values) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		form, ok := readForm(r)
+		return ok && maps.EqualFunc(form, values, slices.Equal)
+	})
+
+	return describedRule(matcher, "form body mismatch")
+}
+
+// readForm parses the request body as form-urlencoded, reporting false if it is not valid.
+func readForm(r *http.Request) (url.Values, bool) {
+	form, err := url.ParseQuery(string(mustReadDecodedBody(r)))
+	return form, err == nil
+}
+
+// MatchMultipartField sets a rule to match the http request with a multipart/form-data body which has the given
+// field value. File parts are not fields, see MatchMultipartFile.
+func MatchMultipartField(name, value string) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		form, ok := readMultipart(r)
+		return ok && slices.Contains(form.fields[name], value)
+	})
+
+	return describedRule(matcher, "multipart field %s mismatch", name)
+}
+
+// MatchMultipartFile sets a rule to match the http request with a multipart/form-data body which has a file with
+// the given name in the field.
+func MatchMultipartFile(field, filename string) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		form, ok := readMultipart(r)
+
+		return ok && slices.ContainsFunc(form.files[field], func(f multipartFile) bool {
+			return f.filename == filename
+		})
+	})
+
+	return describedRule(matcher, "multipart file %s mismatch", field)
+}
+
+// MatchMultipartFileContent sets a rule to match the http request with a multipart/form-data body which has a file
+// with the given content in the field.
+func MatchMultipartFileContent(field string, content []byte) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		form, ok := readMultipart(r)
+
+		return ok && slices.ContainsFunc(form.files[field], func(f multipartFile) bool {
+			return bytes.Equal(f.content, content)
+		})
+	})
+
+	return describedRule(matcher, "multipart file %s mismatch", field)
+}
+
+type multipartForm struct {
+	fields map[string][]string
+	files  map[string][]multipartFile
+}
+
+type multipartFile struct {
+	filename string
+	content  []byte
+}
+
+// readMultipart parses the request body as multipart/form-data, reporting false if it is not valid.
+func readMultipart(r *http.Request) (*multipartForm, bool) {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return nil, false
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(mustReadDecodedBody(r)), params["boundary"])
+	form := &multipartForm{fields: make(map[string][]string), files: make(map[string][]multipartFile)}
+
+	for {
+		part, partErr := reader.NextPart()
+		if errors.Is(partErr, io.EOF) {
+			return form, true
+		}
+
+		if partErr != nil {
+			return nil, false
+		}
+
+		content, readErr := io.ReadAll(part)
+		if readErr != nil {
+			return nil, false
+		}
+
+		if part.FileName() != "" {
+			form.files[part.FormName()] = append(form.files[part.FormName()], multipartFile{
+				filename: part.FileName(),
+				content:  content,
+			})
+		} else {
+			form.fields[part.FormName()] = append(form.fields[part.FormName()], string(content))
+		}
+	}
+}
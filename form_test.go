@@ -0,0 +1,179 @@
+package mockaso_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestMatchFormField(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		body     string
+		expected bool
+	}{
+		"should match when the field has the value": {
+			body:     "grant_type=client_credentials&scope=read",
+			expected: true,
+		},
+		"should match when any of the field values is the value": {
+			body:     "grant_type=password&grant_type=client_credentials",
+			expected: true,
+		},
+		"should match an encoded value": {
+			body:     "grant_type=client%5Fcredentials",
+			expected: true,
+		},
+		"should not match when the field has another value": {
+			body: "grant_type=password",
+		},
+		"should not match when the field is missing": {
+			body: "scope=read",
+		},
+		"should not match an invalid form": {
+			body: "grant_type=client_credentials&scope=%zz",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(tc.body))
+			rule := mockaso.MatchFormField("grant_type", "client_credentials")
+
+			assert.Equal(t, tc.expected, stubMatches(httpReq, rule))
+		})
+	}
+}
+
+func TestMatchFormBody(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const path = "/test/match-form-body"
+
+	server.Stub(http.MethodPost, mockaso.Path(path)).
+		Match(mockaso.MatchFormBody(url.Values{"name": {"john"}, "roles": {"admin", "dev"}})).
+		Respond(matchedRequestRules()...)
+
+	testCases := map[string]struct {
+		form    url.Values
+		matched bool
+	}{
+		"should match the same fields in any order": {
+			form:    url.Values{"roles": {"admin", "dev"}, "name": {"john"}},
+			matched: true,
+		},
+		"should not match when a value differs": {
+			form: url.Values{"name": {"rick"}, "roles": {"admin", "dev"}},
+		},
+		"should not match when the values order differs": {
+			form: url.Values{"name": {"john"}, "roles": {"dev", "admin"}},
+		},
+		"should not match when there are more fields": {
+			form: url.Values{"name": {"john"}, "roles": {"admin", "dev"}, "age": {"57"}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpResp, err := server.Client().PostForm(path, tc.form)
+			require.NoError(t, err)
+
+			if tc.matched {
+				assertBodyString(t, "matched request", httpResp)
+			} else {
+				assert.Equal(t, 666, httpResp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestMatchMultipart(t *testing.T) {
+	t.Parallel()
+
+	var body bytes.Buffer
+
+	writer := multipart.NewWriter(&body)
+	require.NoError(t, writer.WriteField("name", "john"))
+
+	file, err := writer.CreateFormFile("avatar", "john.png")
+	require.NoError(t, err)
+
+	_, err = file.Write([]byte("png content"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	testCases := map[string]struct {
+		rule        mockaso.StubMatcherRule
+		contentType string
+		expected    bool
+	}{
+		"should match the field value": {
+			rule:     mockaso.MatchMultipartField("name", "john"),
+			expected: true,
+		},
+		"should not match another field value": {
+			rule: mockaso.MatchMultipartField("name", "rick"),
+		},
+		"should not match a file as a field": {
+			rule: mockaso.MatchMultipartField("avatar", "png content"),
+		},
+		"should match the file name": {
+			rule:     mockaso.MatchMultipartFile("avatar", "john.png"),
+			expected: true,
+		},
+		"should not match another file name": {
+			rule: mockaso.MatchMultipartFile("avatar", "rick.png"),
+		},
+		"should not match a file in another field": {
+			rule: mockaso.MatchMultipartFile("photo", "john.png"),
+		},
+		"should match the file content": {
+			rule:     mockaso.MatchMultipartFileContent("avatar", []byte("png content")),
+			expected: true,
+		},
+		"should not match another file content": {
+			rule: mockaso.MatchMultipartFileContent("avatar", []byte("jpg content")),
+		},
+		"should not match when the body is not multipart": {
+			rule:        mockaso.MatchMultipartField("name", "john"),
+			contentType: "application/x-www-form-urlencoded",
+		},
+		"should not match when the boundary is wrong": {
+			rule:        mockaso.MatchMultipartField("name", "john"),
+			contentType: "multipart/form-data; boundary=wrong",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			contentType := tc.contentType
+			if contentType == "" {
+				contentType = writer.FormDataContentType()
+			}
+
+			httpReq := httptest.NewRequest(http.MethodPost, "/upload", bytes.NewReader(body.Bytes()))
+			httpReq.Header.Set("Content-Type", contentType)
+
+			assert.Equal(t, tc.expected, stubMatches(httpReq, tc.rule))
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package mockaso
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Gate blocks the responses of the stubs using it (see WithGate) until it is released.
+type Gate struct {
+	released chan struct{}
+	once     sync.Once
+}
+
+// NewGate returns a Gate, not released yet, to be used with WithGate.
+func NewGate() *Gate {
+	return &Gate{released: make(chan struct{})}
+}
+
+// Release opens the gate, so the blocked responses are written and the following ones are not blocked.
+func (g *Gate) Release() {
+	g.once.Do(func() { close(g.released) })
+}
+
+// Released returns a channel closed when the gate is released.
+func (g *Gate) Released() <-chan struct{} {
+	return g.released
+}
+
+// WithGate blocks the response until the given gate is released or the request is canceled by the client.
+// Useful to test concurrent in-flight requests and cancellation deterministically.
+func WithGate(gate *Gate) StubResponseRule {
+	return func(r *stubResponse) {
+		r.waits = append(r.waits, func(req *http.Request) {
+			select {
+			case <-gate.released:
+			case <-req.Context().Done():
+			}
+		})
+	}
+}
+
+// WithBarrier holds the responses until n requests have arrived and then releases them together.
+// The barrier is cyclic: the following n requests are held again. A held request is also released if it is
+// canceled by the client, and then it no longer counts as arrived. Useful to test client connection pool limits
+// and request coalescing.
+func WithBarrier(n int) StubResponseRule {
+	if n <= 0 {
+		panic(fmt.Errorf("WithBarrier err: n must be positive, got %d", n))
+	}
+
+	b := &barrier{n: n, release: make(chan struct{})}
+
+	return func(r *stubResponse) {
+		r.waits = append(r.waits, b.wait)
+	}
+}
+
+type barrier struct {
+	n       int
+	arrived int
+	release chan struct{} // closed when n requests have arrived
+	mutex   sync.Mutex
+}
+
+func (b *barrier) wait(r *http.Request) {
+	b.mutex.Lock()
+
+	release := b.release
+	b.arrived++
+
+	if b.arrived >= b.n { // last one: release the current generation and start a new one
+		close(b.release)
+		b.arrived = 0
+		b.release = make(chan struct{})
+	}
+
+	b.mutex.Unlock()
+
+	select {
+	case <-release:
+	case <-r.Context().Done():
+		b.leave(release)
+	}
+}
+
+// leave removes a canceled request from the arrived ones, unless its generation was released meanwhile.
+func (b *barrier) leave(release chan struct{}) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if release == b.release {
+		b.arrived--
+	}
+}
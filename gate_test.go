@@ -0,0 +1,199 @@
+package mockaso_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestWithGate(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	t.Run("should block responses until the gate is released", func(t *testing.T) {
+		t.Parallel()
+
+		const path = "/test/with-gate/release"
+
+		gate := mockaso.NewGate()
+		server.Stub(http.MethodGet, mockaso.Path(path)).
+			Respond(mockaso.WithBody("released"), mockaso.WithGate(gate))
+
+		const requests = 3
+
+		done := make(chan *http.Response, requests)
+
+		for range requests {
+			go func() {
+				httpResp, _ := server.Client().Get(path)
+				done <- httpResp
+			}()
+		}
+
+		select {
+		case <-done:
+			t.Fatal("response was not blocked by the gate")
+		case <-time.After(200 * time.Millisecond):
+		}
+
+		gate.Release()
+
+		for range requests {
+			httpResp := <-done
+			require.NotNil(t, httpResp)
+			assertBodyString(t, "released", httpResp)
+		}
+
+		select {
+		case <-gate.Released():
+		default:
+			t.Fatal("gate should be released")
+		}
+	})
+
+	t.Run("should not block responses after the gate is released", func(t *testing.T) {
+		t.Parallel()
+
+		const path = "/test/with-gate/already-released"
+
+		gate := mockaso.NewGate()
+		gate.Release()
+		gate.Release() // releasing twice does not panic
+
+		server.Stub(http.MethodGet, mockaso.Path(path)).
+			Respond(mockaso.WithBody("not blocked"), mockaso.WithGate(gate))
+
+		httpResp, err := server.Client().Get(path)
+		require.NoError(t, err)
+
+		assertBodyString(t, "not blocked", httpResp)
+	})
+
+	t.Run("should unblock when the client cancels the request", func(t *testing.T) {
+		t.Parallel()
+
+		const path = "/test/with-gate/cancel"
+
+		server.Stub(http.MethodGet, mockaso.Path(path)).
+			Respond(mockaso.WithGate(mockaso.NewGate()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		httpReq, _ := http.NewRequestWithContext(ctx, http.MethodGet, path, http.NoBody)
+		_, err := server.Client().Do(httpReq)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestWithBarrier(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	t.Run("should release responses when the required requests have arrived", func(t *testing.T) {
+		t.Parallel()
+
+		const (
+			path     = "/test/with-barrier"
+			barrierN = 3
+		)
+
+		server.Stub(http.MethodGet, mockaso.Path(path)).
+			Respond(mockaso.WithBody("released"), mockaso.WithBarrier(barrierN))
+
+		done := make(chan *http.Response, barrierN*2)
+		get := func() {
+			httpResp, _ := server.Client().Get(path)
+			done <- httpResp
+		}
+
+		for generation := range 2 {
+			for range barrierN - 1 {
+				go get()
+			}
+
+			select {
+			case <-done:
+				t.Fatalf("generation %d: response was released before the barrier was reached", generation)
+			case <-time.After(200 * time.Millisecond):
+			}
+
+			go get() // the last one reaches the barrier
+
+			for range barrierN {
+				select {
+				case httpResp := <-done:
+					require.NotNil(t, httpResp)
+					assertBodyString(t, "released", httpResp)
+				case <-time.After(5 * time.Second):
+					t.Fatalf("generation %d: responses were not released", generation)
+				}
+			}
+		}
+	})
+	t.Run("should not count canceled requests", func(t *testing.T) {
+		t.Parallel()
+
+		const path = "/test/with-barrier/canceled"
+
+		st := server.Stub(http.MethodGet, mockaso.Path(path))
+		st.Respond(mockaso.WithBody("released"), mockaso.WithBarrier(2))
+
+		ctx, cancel := context.WithCancel(t.Context())
+		httpReq, _ := http.NewRequestWithContext(ctx, http.MethodGet, path, http.NoBody)
+
+		canceled := make(chan error, 1)
+		go func() {
+			_, err := server.Client().Do(httpReq)
+			canceled <- err
+		}()
+
+		require.Eventually(t, func() bool { return st.Calls() == 1 }, time.Second, 10*time.Millisecond)
+		cancel()
+		require.ErrorIs(t, <-canceled, context.Canceled)
+		time.Sleep(100 * time.Millisecond) // the server notices the canceled request
+
+		done := make(chan *http.Response, 2)
+		get := func() {
+			httpResp, _ := server.Client().Get(path)
+			done <- httpResp
+		}
+
+		go get()
+
+		select {
+		case <-done:
+			t.Fatal("response was released by a canceled request")
+		case <-time.After(200 * time.Millisecond):
+		}
+
+		go get()
+
+		for range 2 {
+			select {
+			case httpResp := <-done:
+				require.NotNil(t, httpResp)
+				assertBodyString(t, "released", httpResp)
+			case <-time.After(5 * time.Second):
+				t.Fatal("responses were not released")
+			}
+		}
+	})
+
+	t.Run("should panic when n is not positive", func(t *testing.T) {
+		t.Parallel()
+
+		assert.PanicsWithError(t, "WithBarrier err: n must be positive, got 0", func() { mockaso.WithBarrier(0) })
+	})
+}
@@ -0,0 +1,606 @@
+package mockaso
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type GenerateOption func(*jsonGenerator)
+
+// WithSeed sets the seed used to generate random data.
+// Using the same seed, the same sequence of values will be generated.
+func WithSeed(seed uint64) GenerateOption {
+	return func(g *jsonGenerator) {
+		g.rand = newRand(seed)
+	}
+}
+
+// WithGeneratedJSON sets the response content with random JSON generated on every request.
+// The schema could be a JSON Schema (string, []byte or json.RawMessage) or a Go value whose type is used
+// as schema. Go struct fields are named with the json tag and can be constrained with the jsonschema tag,
+// e.g. `jsonschema:"minimum=1,maximum=10"` or `jsonschema:"format=email"`.
+// The response will include the Content-Type:application/json header.
+//
+// Supported keywords: type, properties, required, additionalProperties, items, enum, const, oneOf, anyOf,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf, minLength, maxLength, minItems, maxItems,
+// uniqueItems and format (email, uuid, date-time, date, time, uri, hostname, ipv4).
+func WithGeneratedJSON(schema any, opts ...GenerateOption) StubResponseRule {
+	parsed, err := parseGeneratorSchema(schema)
+	if err != nil {
+		panic(fmt.Errorf("WithGeneratedJSON err: invalid schema: %w", err))
+	}
+
+	// generate once to detect unsupported schemas when the rule is defined
+	if _, err = newJSONGenerator(parsed, WithSeed(0)).generate(); err != nil {
+		panic(fmt.Errorf("WithGeneratedJSON err: %w", err))
+	}
+
+	generator := newJSONGenerator(parsed, opts...)
+
+	return func(r *stubResponse) {
+		r.setJSON(nil)
+		r.bodyFunc = func(*stub, *http.Request) []byte {
+			data, genErr := generator.generate()
+			if genErr != nil {
+				panic(fmt.Errorf("WithGeneratedJSON err: %w", genErr))
+			}
+
+			return data
+		}
+	}
+}
+
+type jsonGenerator struct {
+	schema map[string]any
+	rand   *rand.Rand
+	mutex  sync.Mutex
+}
+
+func newJSONGenerator(schema map[string]any, opts ...GenerateOption) *jsonGenerator {
+	g := &jsonGenerator{
+		schema: schema,
+		rand:   newRand(rand.Uint64()),
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+func newRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed))
+}
+
+func (g *jsonGenerator) generate() ([]byte, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	value, err := g.value(g.schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(value)
+}
+
+func (g *jsonGenerator) value(schema map[string]any) (any, error) {
+	if v, ok := schema["const"]; ok {
+		return v, nil
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		return enum[g.rand.IntN(len(enum))], nil
+	}
+
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if options, ok := schema[keyword].([]any); ok && len(options) > 0 {
+			option, isObject := options[g.rand.IntN(len(options))].(map[string]any)
+			if !isObject {
+				return nil, fmt.Errorf("%s must be a list of schemas", keyword)
+			}
+
+			return g.value(option)
+		}
+	}
+
+	for _, keyword := range []string{"$ref", "allOf", "pattern", "not"} {
+		if _, ok := schema[keyword]; ok {
+			return nil, fmt.Errorf("unsupported schema keyword: %s", keyword)
+		}
+	}
+
+	switch schemaType := g.schemaType(schema); schemaType {
+	case "null":
+		return nil, nil
+	case "boolean":
+		return g.rand.IntN(2) == 1, nil
+	case "integer":
+		return g.integer(schema)
+	case "number":
+		return g.number(schema)
+	case "string":
+		return g.string(schema)
+	case "array":
+		return g.array(schema)
+	case "object":
+		return g.object(schema)
+	default:
+		return nil, fmt.Errorf("unsupported schema type: %s", schemaType)
+	}
+}
+
+func (g *jsonGenerator) schemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		if len(t) > 0 {
+			return fmt.Sprint(t[g.rand.IntN(len(t))])
+		}
+	}
+
+	// infer the type when it is not specified
+	switch {
+	case schema["properties"] != nil || schema["additionalProperties"] != nil:
+		return "object"
+	case schema["items"] != nil:
+		return "array"
+	default:
+		return "string"
+	}
+}
+
+func (g *jsonGenerator) integer(schema map[string]any) (int64, error) {
+	minimum, maximum := g.bounds(schema, 0, 1000)
+	lower, upper := int64(math.Ceil(minimum)), int64(math.Floor(maximum))
+
+	if exclusive, ok := schemaNumber(schema["exclusiveMinimum"]); ok && float64(lower) <= exclusive {
+		lower = int64(math.Floor(exclusive)) + 1
+	}
+
+	if exclusive, ok := schemaNumber(schema["exclusiveMaximum"]); ok && float64(upper) >= exclusive {
+		upper = int64(math.Ceil(exclusive)) - 1
+	}
+
+	if multipleOf, ok := schemaNumber(schema["multipleOf"]); ok {
+		step, err := integerStep(multipleOf)
+		if err != nil {
+			return 0, err
+		}
+
+		value, err := g.multiple(float64(step), float64(lower), float64(upper))
+
+		return int64(value), err
+	}
+
+	if lower > upper {
+		return 0, fmt.Errorf("invalid integer bounds [%d, %d]", lower, upper)
+	}
+
+	return lower + g.rand.Int64N(upper-lower+1), nil
+}
+
+func (g *jsonGenerator) number(schema map[string]any) (float64, error) {
+	minimum, maximum := g.bounds(schema, 0, 1000)
+
+	if exclusive, ok := schemaNumber(schema["exclusiveMinimum"]); ok {
+		minimum = math.Max(minimum, exclusive+0.01)
+	}
+
+	if exclusive, ok := schemaNumber(schema["exclusiveMaximum"]); ok {
+		maximum = math.Min(maximum, exclusive-0.01)
+	}
+
+	if multipleOf, ok := schemaNumber(schema["multipleOf"]); ok {
+		if multipleOf <= 0 {
+			return 0, fmt.Errorf("multipleOf must be greater than 0, got %v", multipleOf)
+		}
+
+		return g.multiple(multipleOf, minimum, maximum)
+	}
+
+	// round to 2 decimals within the bounds
+	lower, upper := math.Round(minimum*100)/100, math.Round(maximum*100)/100
+	if lower < minimum {
+		lower += 0.01
+	}
+
+	if upper > maximum {
+		upper -= 0.01
+	}
+
+	if lower > upper { // no value of 2 decimals within the bounds
+		return minimum + g.rand.Float64()*(maximum-minimum), nil
+	}
+
+	value := math.Round((lower+g.rand.Float64()*(upper-lower))*100) / 100
+
+	return math.Min(math.Max(value, lower), upper), nil
+}
+
+// multiple returns a random multiple of step within the inclusive bounds.
+func (g *jsonGenerator) multiple(step, minimum, maximum float64) (float64, error) {
+	lower, upper := math.Ceil(minimum/step), math.Floor(maximum/step)
+	if lower > upper {
+		return 0, fmt.Errorf("no multiple of %v within bounds [%v, %v]", step, minimum, maximum)
+	}
+
+	value := (lower + float64(g.rand.Int64N(int64(upper-lower)+1))) * step
+
+	// drop the floating point error of fractional steps, e.g. 3 * 0.1 = 0.30000000000000004
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(value, 'g', 12, 64), 64)
+
+	return rounded, nil
+}
+
+// integerStep returns the smallest integer multiple of multipleOf, e.g. 5 for 2.5, since integers can only be
+// multiples of a fractional step at its integer multiples.
+func integerStep(multipleOf float64) (int64, error) {
+	if multipleOf <= 0 {
+		return 0, fmt.Errorf("multipleOf must be greater than 0, got %v", multipleOf)
+	}
+
+	for n := 1.0; n <= 1000; n++ {
+		if step := n * multipleOf; math.Abs(step-math.Round(step)) < 1e-9 {
+			return int64(math.Round(step)), nil
+		}
+	}
+
+	return 0, fmt.Errorf("no integer multiple of %v", multipleOf)
+}
+
+// bounds returns the minimum and maximum of the schema, using the given defaults when they are not specified.
+func (g *jsonGenerator) bounds(schema map[string]any, defaultMin, defaultMax float64) (float64, float64) {
+	minimum, hasMin := schemaNumber(schema["minimum"])
+	maximum, hasMax := schemaNumber(schema["maximum"])
+
+	switch {
+	case !hasMin && !hasMax:
+		return defaultMin, defaultMax
+	case !hasMin:
+		return maximum - (defaultMax - defaultMin), maximum
+	case !hasMax:
+		return minimum, minimum + (defaultMax - defaultMin)
+	default:
+		return minimum, maximum
+	}
+}
+
+func (g *jsonGenerator) string(schema map[string]any) (string, error) {
+	switch format, _ := schema["format"].(string); format {
+	case "":
+	case "email":
+		return randomWord(g.rand, 5) + "@example.com", nil
+	case "uuid":
+		return randomUUID(g.rand), nil
+	case "date-time":
+		return randomTime(g.rand).Format(time.RFC3339), nil
+	case "date":
+		return randomTime(g.rand).Format(time.DateOnly), nil
+	case "time":
+		return randomTime(g.rand).Format(time.TimeOnly), nil
+	case "uri":
+		return "https://example.com/" + randomWord(g.rand, 8), nil
+	case "hostname":
+		return randomWord(g.rand, 8) + ".example.com", nil
+	case "ipv4":
+		return fmt.Sprintf("10.%d.%d.%d", g.rand.IntN(256), g.rand.IntN(256), g.rand.IntN(256)), nil
+	default:
+		return "", fmt.Errorf("unsupported string format: %s", format)
+	}
+
+	minLength, hasMin := schemaNumber(schema["minLength"])
+	maxLength, hasMax := schemaNumber(schema["maxLength"])
+
+	switch {
+	case !hasMin && !hasMax:
+		minLength, maxLength = 5, 12
+	case !hasMax:
+		maxLength = minLength + 10
+	case !hasMin:
+		minLength = math.Min(1, maxLength)
+	}
+
+	if minLength > maxLength {
+		return "", fmt.Errorf("invalid string length bounds [%v, %v]", minLength, maxLength)
+	}
+
+	return randomWord(g.rand, int(minLength)+g.rand.IntN(int(maxLength-minLength)+1)), nil
+}
+
+func (g *jsonGenerator) array(schema map[string]any) ([]any, error) {
+	minItems, hasMin := schemaNumber(schema["minItems"])
+	maxItems, hasMax := schemaNumber(schema["maxItems"])
+
+	switch {
+	case !hasMin && !hasMax:
+		minItems, maxItems = 1, 5
+	case !hasMax:
+		maxItems = minItems + 4
+	case !hasMin:
+		minItems = math.Min(1, maxItems)
+	}
+
+	if minItems > maxItems {
+		return nil, fmt.Errorf("invalid array length bounds [%v, %v]", minItems, maxItems)
+	}
+
+	itemSchema, _ := schema["items"].(map[string]any)
+	if itemSchema == nil {
+		itemSchema = map[string]any{"type": "string"}
+	}
+
+	unique, _ := schema["uniqueItems"].(bool)
+	length := int(minItems) + g.rand.IntN(int(maxItems-minItems)+1)
+	items := make([]any, 0, length)
+	seen := make(map[string]bool)
+
+	const maxAttempts = 100
+
+	for attempts := 0; len(items) < length; attempts++ {
+		if attempts >= length*maxAttempts {
+			return nil, errors.New("could not generate unique array items")
+		}
+
+		item, err := g.value(itemSchema)
+		if err != nil {
+			return nil, err
+		}
+
+		if unique {
+			key := fmt.Sprintf("%#v", item)
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (g *jsonGenerator) object(schema map[string]any) (map[string]any, error) {
+	obj := make(map[string]any)
+
+	required := make(map[string]bool)
+	if list, ok := schema["required"].([]any); ok {
+		for _, name := range list {
+			required[fmt.Sprint(name)] = true
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+
+	for _, name := range slices.Sorted(maps.Keys(properties)) { // sorted to be deterministic with a seed
+		propertySchema, ok := properties[name].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("property %s must be a schema", name)
+		}
+
+		if !required[name] && g.rand.IntN(2) == 0 { // optional properties are randomly omitted
+			continue
+		}
+
+		value, err := g.value(propertySchema)
+		if err != nil {
+			return nil, fmt.Errorf("property %s: %w", name, err)
+		}
+
+		obj[name] = value
+	}
+
+	if additional, ok := schema["additionalProperties"].(map[string]any); ok && len(properties) == 0 {
+		for range 1 + g.rand.IntN(3) {
+			value, err := g.value(additional)
+			if err != nil {
+				return nil, err
+			}
+
+			obj[randomWord(g.rand, 6)] = value
+		}
+	}
+
+	return obj, nil
+}
+
+func schemaNumber(v any) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+func parseGeneratorSchema(schema any) (map[string]any, error) {
+	var data []byte
+
+	switch v := schema.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	case json.RawMessage:
+		data = v
+	default:
+		return schemaFromType(reflect.TypeOf(schema), "", make(map[reflect.Type]bool)), nil
+	}
+
+	var parsed map[string]any
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+
+	return parsed, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFromType builds a JSON Schema from a Go type. tag is the jsonschema struct tag of the field (if any).
+// path has the struct types being built, so self-referencing types are null instead of recursing forever.
+func schemaFromType(t reflect.Type, tag string, path map[reflect.Type]bool) map[string]any {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	schema := make(map[string]any)
+
+	switch {
+	case t == nil:
+		schema["type"] = "null"
+	case t == timeType:
+		schema["type"] = "string"
+		schema["format"] = "date-time"
+	case t.Kind() == reflect.Bool:
+		schema["type"] = "boolean"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		schema["type"] = "integer"
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+		schema["type"] = "integer"
+		schema["minimum"] = float64(0)
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		schema["type"] = "number"
+	case t.Kind() == reflect.String:
+		schema["type"] = "string"
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		schema["type"] = "array"
+		schema["items"] = schemaFromType(t.Elem(), "", path)
+
+		if items := schema["items"].(map[string]any); items["type"] == "null" { // self-referencing, e.g. []Node
+			schema["maxItems"] = float64(0)
+		}
+	case t.Kind() == reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = schemaFromType(t.Elem(), "", path)
+	case t.Kind() == reflect.Struct && path[t]:
+		schema["type"] = "null"
+	case t.Kind() == reflect.Struct:
+		path[t] = true
+		properties, required := structProperties(t, path)
+		delete(path, t)
+
+		schema["type"] = "object"
+		schema["properties"] = properties
+		schema["required"] = required
+	default:
+		schema["type"] = "string"
+	}
+
+	applySchemaTag(schema, tag)
+
+	return schema
+}
+
+func structProperties(t reflect.Type, path map[reflect.Type]bool) (map[string]any, []any) {
+	properties := make(map[string]any)
+	required := make([]any, 0)
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = schemaFromType(field.Type, field.Tag.Get("jsonschema"), path)
+
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	return properties, required
+}
+
+// applySchemaTag applies the keywords of a jsonschema struct tag, e.g. `jsonschema:"minimum=1,enum=a,enum=b"`.
+func applySchemaTag(schema map[string]any, tag string) {
+	if tag == "" {
+		return
+	}
+
+	for _, item := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(item, "=")
+
+		switch key {
+		case "enum":
+			enum, _ := schema["enum"].([]any)
+			schema["enum"] = append(enum, tagValue(schema, value))
+		case "const":
+			schema["const"] = tagValue(schema, value)
+		case "format":
+			schema["format"] = value
+		case "uniqueItems":
+			schema["uniqueItems"] = true
+		default:
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				schema[key] = f
+			}
+		}
+	}
+}
+
+// tagValue converts a tag value to the schema type.
+func tagValue(schema map[string]any, value string) any {
+	switch schema["type"] {
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+
+	return value
+}
+
+func randomWord(r *rand.Rand, length int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+
+	var sb strings.Builder
+	for range length {
+		sb.WriteByte(letters[r.IntN(len(letters))])
+	}
+
+	return sb.String()
+}
+
+func randomUUID(r *rand.Rand) string {
+	var b [16]byte
+	for i := range b {
+		b[i] = byte(r.IntN(256))
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func randomTime(r *rand.Rand) time.Time {
+	from := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2030, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	return from.Add(time.Duration(r.Int64N(int64(to.Sub(from)/time.Second))) * time.Second)
+}
@@ -0,0 +1,310 @@
+package mockaso_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+const testUserSchema = `{
+  "type": "object",
+  "required": ["id", "name", "email", "age", "tags", "role", "created_at"],
+  "properties": {
+    "id": {"type": "string", "format": "uuid"},
+    "name": {"type": "string", "minLength": 3, "maxLength": 8},
+    "email": {"type": "string", "format": "email"},
+    "age": {"type": "integer", "minimum": 18, "maximum": 99},
+    "score": {"type": "number", "minimum": 0, "maximum": 1},
+    "tags": {"type": "array", "items": {"type": "string"}, "minItems": 1, "maxItems": 3, "uniqueItems": true},
+    "role": {"enum": ["admin", "user"]},
+    "created_at": {"type": "string", "format": "date-time"}
+  }
+}`
+
+type generatedUser struct {
+	ID        string    `json:"id" jsonschema:"format=uuid"`
+	Name      string    `json:"name" jsonschema:"minLength=3,maxLength=8"`
+	Email     string    `json:"email" jsonschema:"format=email"`
+	Age       int       `json:"age" jsonschema:"minimum=18,maximum=99"`
+	Tags      []string  `json:"tags" jsonschema:"minItems=1,maxItems=3,uniqueItems"`
+	Role      string    `json:"role" jsonschema:"enum=admin,enum=user"`
+	CreatedAt time.Time `json:"created_at"`
+	Nickname  string    `json:"nickname,omitempty"`
+	Ignored   string    `json:"-"`
+}
+
+type generatedNode struct {
+	Name     string          `json:"name"`
+	Children []generatedNode `json:"children"`
+	Parent   *generatedNode  `json:"parent"`
+}
+
+func TestWithGeneratedJSON(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	testCases := map[string]struct {
+		schema any
+	}{
+		"json schema":   {schema: testUserSchema},
+		"go struct":     {schema: generatedUser{}},
+		"go struct ptr": {schema: &generatedUser{}},
+	}
+
+	for name, tc := range testCases {
+		t.Run("should return valid generated json from "+name, func(t *testing.T) {
+			t.Parallel()
+
+			url := "/test/generated-json/" + regexp.MustCompile(`\W`).ReplaceAllString(name, "-")
+			server.Stub(http.MethodGet, mockaso.URL(url)).Respond(mockaso.WithGeneratedJSON(tc.schema))
+
+			for range 10 {
+				httpReq, _ := http.NewRequest(http.MethodGet, url, http.NoBody)
+				httpResp, err := server.Client().Do(httpReq)
+				require.NoError(t, err)
+
+				assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+				assert.Equal(t, "application/json", httpResp.Header.Get("Content-Type"))
+
+				var user generatedUser
+				require.NoError(t, json.Unmarshal([]byte(readString(httpResp.Body)), &user))
+				assertValidGeneratedUser(t, user)
+			}
+		})
+	}
+
+	t.Run("should return the same sequence with the same seed", func(t *testing.T) {
+		t.Parallel()
+
+		for i := range 2 {
+			url := fmt.Sprintf("/test/generated-json/seed/%d", i)
+			server.Stub(http.MethodGet, mockaso.URL(url)).
+				Respond(mockaso.WithGeneratedJSON(testUserSchema, mockaso.WithSeed(42)))
+		}
+
+		for range 3 {
+			bodies := make([]string, 0, 2)
+
+			for i := range 2 {
+				httpReq, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/test/generated-json/seed/%d", i), http.NoBody)
+				httpResp, err := server.Client().Do(httpReq)
+				require.NoError(t, err)
+
+				bodies = append(bodies, readString(httpResp.Body))
+			}
+
+			assert.JSONEq(t, bodies[0], bodies[1])
+		}
+	})
+
+	t.Run("should return different values on each request", func(t *testing.T) {
+		t.Parallel()
+
+		const url = "/test/generated-json/different"
+		server.Stub(http.MethodGet, mockaso.URL(url)).
+			Respond(mockaso.WithGeneratedJSON(`{"type":"string","format":"uuid"}`, mockaso.WithSeed(1)))
+
+		bodies := make(map[string]bool)
+
+		for range 5 {
+			httpReq, _ := http.NewRequest(http.MethodGet, url, http.NoBody)
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+
+			bodies[readString(httpResp.Body)] = true
+		}
+
+		assert.Len(t, bodies, 5)
+	})
+
+	t.Run("should return multiples within bounds", func(t *testing.T) {
+		t.Parallel()
+
+		testCases := map[string]struct {
+			schema  string
+			step    float64
+			minimum float64
+			maximum float64
+		}{
+			"integer": {
+				schema:  `{"type":"integer","minimum":1,"maximum":100,"multipleOf":7}`,
+				step:    7,
+				minimum: 1,
+				maximum: 100,
+			},
+			"integer with fractional step": {
+				schema:  `{"type":"integer","minimum":0,"maximum":100,"multipleOf":2.5}`,
+				step:    5,
+				minimum: 0,
+				maximum: 100,
+			},
+			"integer with negative bounds": {
+				schema:  `{"type":"integer","minimum":-20,"maximum":-1,"multipleOf":3}`,
+				step:    3,
+				minimum: -20,
+				maximum: -1,
+			},
+			"integer with exclusive bounds": {
+				schema:  `{"type":"integer","exclusiveMinimum":0,"exclusiveMaximum":10,"multipleOf":5}`,
+				step:    5,
+				minimum: 5,
+				maximum: 5,
+			},
+			"number": {
+				schema:  `{"type":"number","minimum":0,"maximum":10,"multipleOf":0.25}`,
+				step:    0.25,
+				minimum: 0,
+				maximum: 10,
+			},
+			"number with fractional step": {
+				schema:  `{"type":"number","minimum":0.1,"maximum":1,"multipleOf":0.1}`,
+				step:    0.1,
+				minimum: 0.1,
+				maximum: 1,
+			},
+			"number with negative bounds": {
+				schema:  `{"type":"number","minimum":-1.5,"maximum":-0.5,"multipleOf":0.5}`,
+				step:    0.5,
+				minimum: -1.5,
+				maximum: -0.5,
+			},
+		}
+
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				url := "/test/generated-json/multiple/" + regexp.MustCompile(`\W`).ReplaceAllString(name, "-")
+				server.Stub(http.MethodGet, mockaso.URL(url)).Respond(mockaso.WithGeneratedJSON(tc.schema))
+
+				for range 20 {
+					httpReq, _ := http.NewRequest(http.MethodGet, url, http.NoBody)
+					httpResp, err := server.Client().Do(httpReq)
+					require.NoError(t, err)
+
+					var value float64
+					require.NoError(t, json.Unmarshal([]byte(readString(httpResp.Body)), &value))
+
+					assert.GreaterOrEqual(t, value, tc.minimum)
+					assert.LessOrEqual(t, value, tc.maximum)
+					assert.InDelta(t, math.Round(value/tc.step), value/tc.step, 1e-9, "%v is a multiple of %v", value, tc.step)
+				}
+			})
+		}
+	})
+
+	t.Run("should return numbers within tight bounds", func(t *testing.T) {
+		t.Parallel()
+
+		testCases := map[string]struct {
+			minimum float64
+			maximum float64
+		}{
+			"bounds with 2 decimals values":    {minimum: 0.123, maximum: 0.127},
+			"bounds without 2 decimals values": {minimum: 1.001, maximum: 1.009},
+		}
+
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				url := "/test/generated-json/tight/" + regexp.MustCompile(`\W`).ReplaceAllString(name, "-")
+				schema := fmt.Sprintf(`{"type":"number","minimum":%v,"maximum":%v}`, tc.minimum, tc.maximum)
+				server.Stub(http.MethodGet, mockaso.URL(url)).Respond(mockaso.WithGeneratedJSON(schema))
+
+				for range 20 {
+					httpReq, _ := http.NewRequest(http.MethodGet, url, http.NoBody)
+					httpResp, err := server.Client().Do(httpReq)
+					require.NoError(t, err)
+
+					var value float64
+					require.NoError(t, json.Unmarshal([]byte(readString(httpResp.Body)), &value))
+
+					assert.GreaterOrEqual(t, value, tc.minimum)
+					assert.LessOrEqual(t, value, tc.maximum)
+				}
+			})
+		}
+	})
+
+	t.Run("should stop at self-referencing types", func(t *testing.T) {
+		t.Parallel()
+
+		const url = "/test/generated-json/recursive"
+		server.Stub(http.MethodGet, mockaso.URL(url)).Respond(mockaso.WithGeneratedJSON(generatedNode{}))
+
+		httpReq, _ := http.NewRequest(http.MethodGet, url, http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		var node map[string]any
+		require.NoError(t, json.Unmarshal([]byte(readString(httpResp.Body)), &node))
+
+		assert.NotEmpty(t, node["name"])
+		assert.Equal(t, []any{}, node["children"])
+		assert.Nil(t, node["parent"])
+	})
+
+	t.Run("should panic when schema is not valid", func(t *testing.T) {
+		t.Parallel()
+
+		testCases := map[string]string{
+			"invalid json":        `{"type":`,
+			"unsupported type":    `{"type":"date"}`,
+			"unsupported keyword": `{"type":"string","pattern":"^a+$"}`,
+			"unsupported format":  `{"type":"string","format":"iban"}`,
+			"invalid bounds":      `{"type":"integer","minimum":10,"maximum":1}`,
+			"no integer multiple": `{"type":"integer","minimum":1,"maximum":4,"multipleOf":5}`,
+			"no number multiple":  `{"type":"number","minimum":0.1,"maximum":0.2,"multipleOf":0.25}`,
+			"zero multiple":       `{"type":"number","multipleOf":0}`,
+			"negative multiple":   `{"type":"integer","multipleOf":-2}`,
+		}
+
+		for name, schema := range testCases {
+			t.Run(name, func(t *testing.T) {
+				assert.Panics(t, func() { mockaso.WithGeneratedJSON(schema) })
+			})
+		}
+	})
+}
+
+func assertValidGeneratedUser(t *testing.T, user generatedUser) {
+	t.Helper()
+
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, user.ID)
+	assert.GreaterOrEqual(t, len(user.Name), 3)
+	assert.LessOrEqual(t, len(user.Name), 8)
+	assert.Regexp(t, `^[a-z]+@example\.com$`, user.Email)
+	assert.GreaterOrEqual(t, user.Age, 18)
+	assert.LessOrEqual(t, user.Age, 99)
+	assert.NotEmpty(t, user.Tags)
+	assert.LessOrEqual(t, len(user.Tags), 3)
+	assert.ElementsMatch(t, user.Tags, uniqueStrings(user.Tags))
+	assert.Contains(t, []string{"admin", "user"}, user.Role)
+	assert.False(t, user.CreatedAt.IsZero())
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool)
+	unique := make([]string, 0, len(values))
+
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+
+	return unique
+}
@@ -1,11 +1,16 @@
 module github.com/royhq/mockaso
 
-require github.com/stretchr/testify v1.10.0
+require (
+	github.com/andybalholm/brotli v1.2.5
+	github.com/klauspost/compress v1.18.0
+	github.com/stretchr/testify v1.10.0
+	google.golang.org/protobuf v1.36.10
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 go 1.24
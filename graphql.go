@@ -0,0 +1,171 @@
+package mockaso
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"regexp"
+)
+
+// GraphQLError is an error of a GraphQL response, see WithGraphQLErrors.
+type GraphQLError struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`       // e.g. []any{"user", "friends", 0}
+	Extensions map[string]any `json:"extensions,omitempty"` // e.g. map[string]any{"code": "NOT_FOUND"}
+}
+
+// MatchGraphQLOperation sets a rule to match the GraphQL request of the given operation. The operation is the
+// operationName of the request or, if it is not set, the name of the only operation of the query document.
+// GraphQL requests are read from the standard envelope: a JSON body with the query, operationName and variables
+// fields, the query parameters of GET requests, or an application/graphql body.
+//
+// Example:
+//
+//	server.Stub(http.MethodPost, Path("/graphql")).
+//		Match(MatchGraphQLOperation("GetUser"), MatchGraphQLVariables(map[string]any{"id": "100"})).
+//		Respond(WithGraphQLData(map[string]any{"user": map[string]any{"id": "100", "name": "john"}}))
+func MatchGraphQLOperation(name string) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		req, ok := readGraphQLRequest(r)
+		return ok && req.operation() == name
+	})
+
+	return describedRule(matcher, "GraphQL operation %s mismatch", name)
+}
+
+// MatchGraphQLVariables sets a rule to match the GraphQL request with the given variables, compared as JSON.
+// Other variables of the request are ignored. See MatchGraphQLOperation for the supported requests.
+func MatchGraphQLVariables(variables map[string]any) StubMatcherRule {
+	data, err := json.Marshal(variables)
+	if err != nil {
+		panic(fmt.Errorf("MatchGraphQLVariables err: marshal variables failed: %w", err))
+	}
+
+	var expected map[string]any
+	_ = json.Unmarshal(data, &expected)
+
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		req, ok := readGraphQLRequest(r)
+		if !ok {
+			return false
+		}
+
+		for key, value := range expected {
+			if actual, found := req.Variables[key]; !found || !reflect.DeepEqual(value, actual) {
+				return false
+			}
+		}
+
+		return true
+	})
+
+	return describedRule(matcher, "GraphQL variables mismatch")
+}
+
+// WithGraphQLData sets the response content to a GraphQL response with the marshal output of the given data, i.e.
+// {"data":...}. It can be combined with WithGraphQLErrors for partial results.
+// The response will include the Content-Type:application/json header.
+func WithGraphQLData(data any) StubResponseRule {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		panic(fmt.Errorf("WithGraphQLData err: marshal data failed: %w", err))
+	}
+
+	return func(r *stubResponse) {
+		r.setGraphQL(func(resp *graphQLResponse) { resp.Data = raw })
+	}
+}
+
+// WithGraphQLErrors sets the response content to a GraphQL response with the given errors, i.e. {"errors":[...]}.
+// The status code is not changed, since GraphQL servers usually respond errors with 200 OK.
+// The response will include the Content-Type:application/json header.
+//
+// Example:
+//
+//	WithGraphQLErrors(GraphQLError{Message: "user not found", Path: []any{"user"}})
+func WithGraphQLErrors(errors ...GraphQLError) StubResponseRule {
+	if _, err := json.Marshal(errors); err != nil {
+		panic(fmt.Errorf("WithGraphQLErrors err: marshal errors failed: %w", err))
+	}
+
+	return func(r *stubResponse) {
+		r.setGraphQL(func(resp *graphQLResponse) { resp.Errors = append(resp.Errors, errors...) })
+	}
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data,omitempty"`
+	Errors []GraphQLError  `json:"errors,omitempty"`
+}
+
+func (r *stubResponse) setGraphQL(update func(*graphQLResponse)) {
+	if r.graphQL == nil {
+		r.graphQL = &graphQLResponse{}
+	}
+
+	update(r.graphQL)
+
+	data, _ := json.Marshal(r.graphQL) // the data and the errors were marshaled by the rules
+
+	r.setJSON(data)
+}
+
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// graphQLOperationRegex finds the named operations of a query document.
+var graphQLOperationRegex = regexp.MustCompile(`\b(?:query|mutation|subscription)\s+([_A-Za-z][_0-9A-Za-z]*)`)
+
+// operation returns the operationName of the request or, if not set, the name of the only operation of the query.
+func (r *graphQLRequest) operation() string {
+	if r.OperationName != "" {
+		return r.OperationName
+	}
+
+	if operations := graphQLOperationRegex.FindAllStringSubmatch(r.Query, 2); len(operations) == 1 {
+		return operations[0][1]
+	}
+
+	return ""
+}
+
+// valid reports whether the request has a query or, for persisted queries, an operation name.
+func (r *graphQLRequest) valid() bool {
+	return r.Query != "" || r.OperationName != ""
+}
+
+// readGraphQLRequest reads the GraphQL request, reporting false if the request is not a valid GraphQL request.
+func readGraphQLRequest(r *http.Request) (*graphQLRequest, bool) {
+	var req graphQLRequest
+
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req.Query, req.OperationName = query.Get("query"), query.Get("operationName")
+
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return nil, false
+			}
+		}
+
+		return &req, req.valid()
+	}
+
+	body := mustReadDecodedBody(r)
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/graphql" {
+		req.Query = string(body)
+		return &req, req.valid()
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, false
+	}
+
+	return &req, req.valid()
+}
@@ -0,0 +1,162 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestMatchGraphQLOperation(t *testing.T) {
+	t.Parallel()
+
+	const getUser = `query GetUser($id: ID!) { user(id: $id) { name } }`
+
+	newJSONRequest := func(body string) *http.Request {
+		httpReq := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		httpReq.Header.Set("Content-Type", "application/json")
+
+		return httpReq
+	}
+
+	getQuery := url.Values{"query": {getUser}, "variables": {`{"id":"100","verbose":true}`}}.Encode()
+
+	testCases := map[string]struct {
+		rules    []mockaso.StubMatcherRule
+		httpReq  *http.Request
+		expected bool
+	}{
+		"should match the operation name": {
+			rules: []mockaso.StubMatcherRule{mockaso.MatchGraphQLOperation("GetUser")},
+			httpReq: newJSONRequest(`{"query":"query A { a } query GetUser { user { name } }",` +
+				`"operationName":"GetUser"}`),
+			expected: true,
+		},
+		"should match the only operation of the query": {
+			rules:    []mockaso.StubMatcherRule{mockaso.MatchGraphQLOperation("GetUser")},
+			httpReq:  newJSONRequest(`{"query":"query GetUser($id: ID!) { user(id: $id) { name } }"}`),
+			expected: true,
+		},
+		"should match a persisted query without query": {
+			rules:    []mockaso.StubMatcherRule{mockaso.MatchGraphQLOperation("GetUser")},
+			httpReq:  newJSONRequest(`{"operationName":"GetUser","extensions":{"persistedQuery":{"version":1}}}`),
+			expected: true,
+		},
+		"should match an application/graphql body": {
+			rules: []mockaso.StubMatcherRule{mockaso.MatchGraphQLOperation("GetUser")},
+			httpReq: func() *http.Request {
+				httpReq := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(getUser))
+				httpReq.Header.Set("Content-Type", "application/graphql")
+
+				return httpReq
+			}(),
+			expected: true,
+		},
+		"should match a GET request": {
+			rules: []mockaso.StubMatcherRule{
+				mockaso.MatchGraphQLOperation("GetUser"),
+				mockaso.MatchGraphQLVariables(map[string]any{"id": "100"}),
+			},
+			httpReq:  httptest.NewRequest(http.MethodGet, "/graphql?"+getQuery, nil),
+			expected: true,
+		},
+		"should not match another operation": {
+			rules:   []mockaso.StubMatcherRule{mockaso.MatchGraphQLOperation("GetOrder")},
+			httpReq: newJSONRequest(`{"query":"query GetUser { user { name } }"}`),
+		},
+		"should not match an ambiguous query without operation name": {
+			rules:   []mockaso.StubMatcherRule{mockaso.MatchGraphQLOperation("GetUser")},
+			httpReq: newJSONRequest(`{"query":"query GetUser { user { name } } query A { a }"}`),
+		},
+		"should not match a body which is not a GraphQL request": {
+			rules:   []mockaso.StubMatcherRule{mockaso.MatchGraphQLOperation("GetUser")},
+			httpReq: newJSONRequest(`{"name":"GetUser"}`),
+		},
+		"should match the given variables ignoring the others": {
+			rules: []mockaso.StubMatcherRule{
+				mockaso.MatchGraphQLVariables(map[string]any{"id": "100", "filter": map[string]any{"age": 57}}),
+			},
+			httpReq: newJSONRequest(`{"query":"query GetUser { user { name } }",` +
+				`"variables":{"id":"100","filter":{"age":57.0},"verbose":true}}`),
+			expected: true,
+		},
+		"should not match a different variable": {
+			rules:   []mockaso.StubMatcherRule{mockaso.MatchGraphQLVariables(map[string]any{"id": "100"})},
+			httpReq: newJSONRequest(`{"query":"query GetUser { user { name } }","variables":{"id":"200"}}`),
+		},
+		"should not match a missing variable": {
+			rules:   []mockaso.StubMatcherRule{mockaso.MatchGraphQLVariables(map[string]any{"id": "100"})},
+			httpReq: newJSONRequest(`{"query":"query GetUser { user { name } }"}`),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, stubMatches(tc.httpReq, tc.rules...))
+		})
+	}
+}
+
+func TestWithGraphQLData(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodPost, mockaso.Path("/graphql")).
+		Match(mockaso.MatchGraphQLOperation("GetUser")).
+		Respond(mockaso.WithGraphQLData(map[string]any{"user": map[string]any{"name": "john"}}))
+
+	server.Stub(http.MethodPost, mockaso.Path("/graphql")).
+		Match(mockaso.MatchGraphQLOperation("GetUserWithFriends")).
+		Respond(
+			mockaso.WithGraphQLData(map[string]any{"user": map[string]any{"name": "john", "friends": nil}}),
+			mockaso.WithGraphQLErrors(mockaso.GraphQLError{
+				Message:    "friends unavailable",
+				Path:       []any{"user", "friends"},
+				Extensions: map[string]any{"code": "UNAVAILABLE"},
+			}),
+		)
+
+	server.Stub(http.MethodPost, mockaso.Path("/graphql")).
+		Respond(mockaso.WithGraphQLErrors(mockaso.GraphQLError{Message: "unknown operation"}))
+
+	testCases := map[string]struct {
+		body     string
+		expected string
+	}{
+		"should respond the data": {
+			body:     `{"query":"query GetUser { user { name } }"}`,
+			expected: `{"data":{"user":{"name":"john"}}}`,
+		},
+		"should respond the data with errors": {
+			body: `{"query":"query GetUserWithFriends { user { name friends { name } } }"}`,
+			expected: `{"data":{"user":{"name":"john","friends":null}},"errors":[{"message":"friends unavailable",` +
+				`"path":["user","friends"],"extensions":{"code":"UNAVAILABLE"}}]}`,
+		},
+		"should respond the errors": {
+			body:     `{"query":"query GetOrder { order { id } }"}`,
+			expected: `{"errors":[{"message":"unknown operation"}]}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpResp, err := server.Client().Post("/graphql", "application/json", strings.NewReader(tc.body))
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+			assert.Equal(t, "application/json", httpResp.Header.Get("Content-Type"))
+			assert.JSONEq(t, tc.expected, readString(httpResp.Body))
+		})
+	}
+}
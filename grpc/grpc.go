@@ -0,0 +1,321 @@
+// Package grpc provides a gRPC mock server with the same API style as mockaso: stubs are registered by method,
+// matched against the request messages and metadata, and respond with canned or computed messages, errors and
+// server streams. The server speaks gRPC over HTTP/2 without TLS (h2c), so clients must use insecure credentials.
+// It is usually imported with an alias to avoid the name clash with google.golang.org/grpc.
+//
+// Example:
+//
+//	import mockgrpc "github.com/royhq/mockaso/grpc"
+//
+//	server := mockgrpc.MustStartNewServer(mockgrpc.WithLogger(t))
+//	t.Cleanup(server.MustShutdown)
+//
+//	server.Stub("/helloworld.Greeter/SayHello").
+//		Match(mockgrpc.MatchMessage(&pb.HelloRequest{Name: "john"})).
+//		Respond(mockgrpc.WithMessage(&pb.HelloReply{Message: "hello john"}))
+//
+//	conn, err := grpc.NewClient(server.Addr(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+package grpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/royhq/mockaso"
+)
+
+// Code is a gRPC status code, see https://grpc.github.io/grpc/core/md_doc_statuscodes.html.
+type Code uint32
+
+const (
+	OK Code = iota
+	Canceled
+	Unknown
+	InvalidArgument
+	DeadlineExceeded
+	NotFound
+	AlreadyExists
+	PermissionDenied
+	ResourceExhausted
+	FailedPrecondition
+	Aborted
+	OutOfRange
+	Unimplemented
+	Internal
+	Unavailable
+	DataLoss
+	Unauthenticated
+)
+
+type Server struct {
+	server   *http.Server
+	listener net.Listener
+	stubs    []*stub
+	logger   mockaso.Logger
+	mutex    sync.RWMutex
+}
+
+func (s *Server) Start() error {
+	if s.server != nil {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("listen failed: %w", err)
+	}
+
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+
+	s.listener = listener
+	s.server = &http.Server{Handler: s.handler(), Protocols: &protocols}
+
+	go func() { _ = s.server.Serve(listener) }()
+
+	s.logger.Logf("grpc server started at %s", s.Addr())
+
+	return nil
+}
+
+func (s *Server) Shutdown() error {
+	if s.server == nil {
+		return nil
+	}
+
+	if err := s.server.Close(); err != nil {
+		return fmt.Errorf("close failed: %w", err)
+	}
+
+	s.logger.Logf("grpc server stopped at %s", s.Addr())
+
+	return nil
+}
+
+func (s *Server) MustStart() {
+	if err := s.Start(); err != nil {
+		panic(err)
+	}
+}
+
+func (s *Server) MustShutdown() {
+	if err := s.Shutdown(); err != nil {
+		panic(err)
+	}
+}
+
+// Addr returns the address the server listens on, e.g. "127.0.0.1:50051", to be used as the client target.
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return ""
+	}
+
+	return s.listener.Addr().String()
+}
+
+func (s *Server) Clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.stubs = nil
+}
+
+// Stub adds a stub of the given full method name, e.g. "/helloworld.Greeter/SayHello". The leading slash is
+// optional. Stubs are evaluated in the order they were added and the first one that matches is used.
+func (s *Server) Stub(method string) Stub {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	st := &stub{method: "/" + strings.TrimPrefix(method, "/"), response: &response{}}
+	s.stubs = append(s.stubs, st)
+
+	return st
+}
+
+// handler returns the handler which serves the gRPC calls with the stubs.
+func (s *Server) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			http.Error(w, "not a grpc request", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		messages, err := readMessages(r.Body, r.Header.Get("Grpc-Encoding"))
+		if err != nil {
+			writeStatus(w, Internal, fmt.Sprintf("read request failed: %v", err))
+			return
+		}
+
+		req := &Request{Method: r.URL.Path, Metadata: r.Header, Messages: messages}
+
+		st := s.matchStub(req)
+		if st == nil {
+			s.logger.Logf("no stub matched for grpc %s", req.Method)
+			writeStatus(w, Unimplemented, "no stubs for "+req.Method)
+
+			return
+		}
+
+		st.write(w, r, req)
+	})
+}
+
+func (s *Server) matchStub(r *Request) *stub {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, st := range s.stubs {
+		if st.match(r) {
+			return st
+		}
+	}
+
+	return nil
+}
+
+func NewServer(opts ...ServerOption) *Server {
+	server := &Server{logger: noLogger{}}
+
+	for _, opt := range opts {
+		opt(server)
+	}
+
+	return server
+}
+
+func MustStartNewServer(opts ...ServerOption) *Server {
+	server := NewServer(opts...)
+	server.MustStart()
+
+	return server
+}
+
+type ServerOption func(*Server)
+
+// WithLogger sets a Logger. Intended for use with testing.T
+func WithLogger(logger mockaso.Logger) ServerOption {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// noLogger is a Logger that does not log anything.
+type noLogger struct{}
+
+func (n noLogger) Log(...any)          {}
+func (n noLogger) Logf(string, ...any) {}
+
+// Request is a gRPC call received by the server.
+type Request struct {
+	Method   string      // the full method name, e.g. "/helloworld.Greeter/SayHello"
+	Metadata http.Header // the request metadata, keys are case-insensitive
+	Messages [][]byte    // the request messages in wire format, more than one for client streams
+}
+
+// Message unmarshals the first request message into msg.
+func (r *Request) Message(msg proto.Message) error {
+	if len(r.Messages) == 0 {
+		return errors.New("no request messages")
+	}
+
+	return proto.Unmarshal(r.Messages[0], msg)
+}
+
+// readMessages reads the length-prefixed messages of the body, decompressing them with the given encoding.
+func readMessages(body io.Reader, encoding string) ([][]byte, error) {
+	var messages [][]byte
+
+	for {
+		var prefix [5]byte
+
+		if _, err := io.ReadFull(body, prefix[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return messages, nil
+			}
+
+			return nil, err
+		}
+
+		data := make([]byte, binary.BigEndian.Uint32(prefix[1:]))
+		if _, err := io.ReadFull(body, data); err != nil {
+			return nil, err
+		}
+
+		if prefix[0] == 1 {
+			decompressed, err := decompress(encoding, data)
+			if err != nil {
+				return nil, err
+			}
+
+			data = decompressed
+		}
+
+		messages = append(messages, data)
+	}
+}
+
+func decompress(encoding string, data []byte) ([]byte, error) {
+	if encoding != "gzip" {
+		return nil, fmt.Errorf("unsupported message encoding %q", encoding)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(reader)
+}
+
+// writeMessage writes an uncompressed length-prefixed message.
+func writeMessage(w io.Writer, data []byte) error {
+	prefix := binary.BigEndian.AppendUint32([]byte{0}, uint32(len(data)))
+
+	_, err := w.Write(append(prefix, data...))
+
+	return err
+}
+
+// writeStatus writes a response without messages, i.e. trailers-only.
+func writeStatus(w http.ResponseWriter, code Code, message string) {
+	w.Header().Set("Content-Type", "application/grpc")
+	setStatus(w.Header(), "", code, message)
+	w.WriteHeader(http.StatusOK)
+}
+
+// setStatus sets the status of the call in the header, with the given prefix for trailers.
+func setStatus(header http.Header, prefix string, code Code, message string) {
+	header.Set(prefix+"Grpc-Status", strconv.Itoa(int(code)))
+
+	if message != "" {
+		header.Set(prefix+"Grpc-Message", encodeStatusMessage(message))
+	}
+}
+
+// encodeStatusMessage percent-encodes the status message, as required by the gRPC protocol.
+func encodeStatusMessage(message string) string {
+	var b strings.Builder
+
+	for i := range len(message) {
+		c := message[i]
+		if c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,253 @@
+package grpc_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/royhq/mockaso/grpc"
+)
+
+const sayHello = "/helloworld.Greeter/SayHello"
+
+// fakeT records the assertion failures instead of failing the test.
+type fakeT struct {
+	errors []string
+}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestServer_Stub(t *testing.T) {
+	t.Parallel()
+
+	server := grpc.MustStartNewServer(grpc.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	johnStub := server.Stub(sayHello).
+		Match(grpc.MatchMessage(wrapperspb.String("john"))).
+		Match(grpc.MatchMetadata("x-tenant", "acme"))
+	johnStub.Respond(grpc.WithMessage(wrapperspb.String("hello john")), grpc.WithMetadata("x-request-id", "123"))
+
+	server.Stub("helloworld.Greeter/SayHello").
+		Respond(grpc.WithError(grpc.NotFound, "user not found: 100%"), grpc.WithTrailer("x-reason", "unknown"))
+
+	t.Run("should respond the matched stub message", func(t *testing.T) {
+		t.Parallel()
+
+		call := invoke(t, server, sayHello, http.Header{"X-Tenant": {"acme"}}, wrapperspb.String("john"))
+
+		assert.Equal(t, "0", call.status)
+		assert.Equal(t, "123", call.header.Get("X-Request-Id"))
+		require.Len(t, call.messages, 1)
+		assertStringValue(t, "hello john", call.messages[0])
+	})
+
+	t.Run("should respond the error of the matched stub", func(t *testing.T) {
+		t.Parallel()
+
+		call := invoke(t, server, sayHello, nil, wrapperspb.String("jane"))
+
+		assert.Equal(t, "5", call.status)
+		assert.Equal(t, "user not found: 100%25", call.statusMessage)
+		assert.Equal(t, "unknown", call.header.Get("X-Reason"))
+		assert.Empty(t, call.messages)
+	})
+
+	t.Run("should respond unimplemented when no stub matches", func(t *testing.T) {
+		t.Parallel()
+
+		call := invoke(t, server, "/helloworld.Greeter/SayGoodbye", nil, wrapperspb.String("john"))
+
+		assert.Equal(t, "12", call.status)
+		assert.Equal(t, "no stubs for /helloworld.Greeter/SayGoodbye", call.statusMessage)
+	})
+
+	t.Run("should match compressed messages", func(t *testing.T) {
+		t.Parallel()
+
+		header := http.Header{"X-Tenant": {"acme"}, "Grpc-Encoding": {"gzip"}}
+		call := invoke(t, server, sayHello, header, wrapperspb.String("john"))
+
+		assert.Equal(t, "0", call.status)
+		require.Len(t, call.messages, 1)
+		assertStringValue(t, "hello john", call.messages[0])
+	})
+}
+
+func TestWithMessageFunc(t *testing.T) {
+	t.Parallel()
+
+	server := grpc.MustStartNewServer(grpc.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(sayHello).Respond(grpc.WithMessageFunc(func(r *grpc.Request) proto.Message {
+		var name wrapperspb.StringValue
+		_ = r.Message(&name)
+
+		return wrapperspb.String("hello " + name.GetValue())
+	}))
+
+	call := invoke(t, server, sayHello, nil, wrapperspb.String("mockaso"))
+
+	assert.Equal(t, "0", call.status)
+	require.Len(t, call.messages, 1)
+	assertStringValue(t, "hello mockaso", call.messages[0])
+}
+
+func TestWithMessages(t *testing.T) {
+	t.Parallel()
+
+	server := grpc.MustStartNewServer(grpc.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub("/prices.Prices/Watch").Respond(
+		grpc.WithMessages(wrapperspb.Int64(10), wrapperspb.Int64(11)),
+		grpc.WithMessage(wrapperspb.Int64(12)),
+		grpc.WithStreamInterval(10*time.Millisecond),
+		grpc.WithError(grpc.Unavailable, "market closed"),
+	)
+
+	call := invoke(t, server, "/prices.Prices/Watch", nil, wrapperspb.String("ACME"))
+
+	assert.Equal(t, "14", call.status)
+	assert.Equal(t, "market closed", call.statusMessage)
+	require.Len(t, call.messages, 3)
+
+	for i, expected := range []int64{10, 11, 12} {
+		var price wrapperspb.Int64Value
+		require.NoError(t, proto.Unmarshal(call.messages[i], &price))
+		assert.Equal(t, expected, price.GetValue())
+	}
+}
+
+func TestStub_AssertCalled(t *testing.T) {
+	t.Parallel()
+
+	server := grpc.MustStartNewServer(grpc.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	called := server.Stub(sayHello)
+	notCalled := server.Stub("/helloworld.Greeter/SayGoodbye")
+
+	invoke(t, server, sayHello, nil, wrapperspb.String("john"))
+
+	fake := &fakeT{}
+
+	assert.Equal(t, 1, called.Calls())
+	assert.True(t, called.AssertCalled(fake))
+	assert.True(t, called.AssertCalledTimes(fake, 1))
+	assert.True(t, notCalled.AssertNotCalled(fake))
+	assert.Empty(t, fake.errors)
+
+	assert.False(t, notCalled.AssertCalled(fake))
+	assert.False(t, called.AssertNotCalled(fake))
+	assert.Equal(t, []string{
+		"stub /helloworld.Greeter/SayGoodbye was not called",
+		"stub /helloworld.Greeter/SayHello was called 1 times, expected not to be called",
+	}, fake.errors)
+}
+
+type grpcCall struct {
+	header        http.Header
+	status        string
+	statusMessage string
+	messages      [][]byte
+}
+
+// invoke calls the method with the given request messages over h2c and reads the response messages and status.
+// The messages are gzip compressed if the Grpc-Encoding header is set.
+func invoke(t *testing.T, server *grpc.Server, method string, header http.Header, msgs ...proto.Message) grpcCall {
+	t.Helper()
+
+	var body bytes.Buffer
+
+	for _, msg := range msgs {
+		data, err := proto.Marshal(msg)
+		require.NoError(t, err)
+
+		flag := byte(0)
+
+		if header.Get("Grpc-Encoding") == "gzip" {
+			data, flag = gzipData(t, data), 1
+		}
+
+		body.Write(binary.BigEndian.AppendUint32([]byte{flag}, uint32(len(data))))
+		body.Write(data)
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, "http://"+server.Addr()+method, &body)
+	require.NoError(t, err)
+
+	for k, v := range header {
+		httpReq.Header[k] = v
+	}
+
+	httpReq.Header.Set("Content-Type", "application/grpc")
+	httpReq.Header.Set("Te", "trailers")
+
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+
+	client := &http.Client{Transport: &http.Transport{Protocols: &protocols}, Timeout: 5 * time.Second}
+
+	httpResp, err := client.Do(httpReq)
+	require.NoError(t, err)
+
+	defer httpResp.Body.Close()
+
+	require.Equal(t, http.StatusOK, httpResp.StatusCode)
+	require.Equal(t, "application/grpc", httpResp.Header.Get("Content-Type"))
+
+	data, err := io.ReadAll(httpResp.Body)
+	require.NoError(t, err)
+
+	call := grpcCall{header: httpResp.Header.Clone()}
+
+	for len(data) > 0 {
+		length := binary.BigEndian.Uint32(data[1:5])
+		call.messages = append(call.messages, data[5:5+length])
+		data = data[5+length:]
+	}
+
+	for k, v := range httpResp.Trailer { // trailers-only responses have the status in the header
+		call.header[k] = v
+	}
+
+	call.status = call.header.Get("Grpc-Status")
+	call.statusMessage = call.header.Get("Grpc-Message")
+
+	return call
+}
+
+func gzipData(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buff bytes.Buffer
+
+	w := gzip.NewWriter(&buff)
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return buff.Bytes()
+}
+
+func assertStringValue(t *testing.T, expected string, data []byte) {
+	t.Helper()
+
+	var value wrapperspb.StringValue
+	require.NoError(t, proto.Unmarshal(data, &value))
+	assert.Equal(t, expected, value.GetValue())
+}
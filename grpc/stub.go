@@ -0,0 +1,315 @@
+package grpc
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/royhq/mockaso"
+)
+
+type Stub interface {
+	Match(...MatcherRule) Stub
+	Respond(...ResponseRule)
+	Calls() int
+	AssertCalled(mockaso.TestingT) bool
+	AssertCalledTimes(mockaso.TestingT, int) bool
+	AssertNotCalled(mockaso.TestingT) bool
+}
+
+type stub struct {
+	method   string
+	matchers []MatcherRule
+	response *response
+	calls    atomic.Int64
+}
+
+// Match sets additional rules to match the calls of the stub method. All of them must match.
+func (s *stub) Match(rules ...MatcherRule) Stub {
+	s.matchers = append(s.matchers, rules...)
+	return s
+}
+
+// Respond sets the response rules of the stub. Without rules, the call succeeds without response messages.
+func (s *stub) Respond(rules ...ResponseRule) {
+	for _, rule := range rules {
+		rule(s.response)
+	}
+}
+
+// Calls returns the number of calls matched by the stub.
+func (s *stub) Calls() int {
+	return int(s.calls.Load())
+}
+
+// AssertCalled asserts that the stub matched at least one call.
+func (s *stub) AssertCalled(t mockaso.TestingT) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	if s.calls.Load() == 0 {
+		t.Errorf("stub %s was not called", s.method)
+		return false
+	}
+
+	return true
+}
+
+// AssertCalledTimes asserts that the stub matched exactly n calls.
+func (s *stub) AssertCalledTimes(t mockaso.TestingT, n int) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	if calls := s.calls.Load(); calls != int64(n) {
+		t.Errorf("stub %s was called %d times, expected %d times", s.method, calls, n)
+		return false
+	}
+
+	return true
+}
+
+// AssertNotCalled asserts that the stub did not match any call.
+func (s *stub) AssertNotCalled(t mockaso.TestingT) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	if calls := s.calls.Load(); calls != 0 {
+		t.Errorf("stub %s was called %d times, expected not to be called", s.method, calls)
+		return false
+	}
+
+	return true
+}
+
+type tHelper interface {
+	Helper()
+}
+
+func (s *stub) match(r *Request) bool {
+	if r.Method != s.method {
+		return false
+	}
+
+	for _, matcher := range s.matchers {
+		if !matcher(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *stub) write(w http.ResponseWriter, r *http.Request, req *Request) {
+	s.calls.Add(1)
+
+	resp := s.response
+
+	if !wait(r, resp.delay) {
+		return
+	}
+
+	for k, v := range resp.metadata {
+		w.Header().Set(k, v)
+	}
+
+	if len(resp.messages) == 0 {
+		for k, v := range resp.trailer {
+			w.Header().Set(k, v)
+		}
+
+		writeStatus(w, resp.code, resp.message)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/grpc")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+
+	code, message := resp.code, resp.message
+
+	for i, messageFor := range resp.messages {
+		if i > 0 && !wait(r, resp.interval) {
+			return
+		}
+
+		data, err := messageFor(req)
+		if err != nil {
+			code, message = Internal, err.Error()
+			break
+		}
+
+		if err = writeMessage(w, data); err != nil {
+			return
+		}
+
+		_ = rc.Flush()
+	}
+
+	for k, v := range resp.trailer {
+		w.Header().Set(http.TrailerPrefix+k, v)
+	}
+
+	setStatus(w.Header(), http.TrailerPrefix, code, message)
+}
+
+// wait waits the given duration, reporting false if the call is canceled first.
+func wait(r *http.Request, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+
+	select {
+	case <-time.After(d):
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+type MatcherRule func(*Request) bool
+
+// MatchMessage sets a rule to match the calls whose first request message is equal to the expected (proto.Equal).
+func MatchMessage(expected proto.Message) MatcherRule {
+	return func(r *Request) bool {
+		actual := expected.ProtoReflect().New().Interface()
+		if err := r.Message(actual); err != nil {
+			return false
+		}
+
+		return proto.Equal(expected, actual)
+	}
+}
+
+// MatchMetadata sets a rule to match the calls with the given metadata value. The key is case-insensitive.
+func MatchMetadata(key, value string) MatcherRule {
+	return func(r *Request) bool {
+		for _, v := range r.Metadata.Values(key) {
+			if v == value {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// MatchRequest sets a rule to match the calls given a custom matcher.
+func MatchRequest(fn func(*Request) bool) MatcherRule {
+	return fn
+}
+
+type ResponseRule func(*response)
+
+type response struct {
+	messages []func(*Request) ([]byte, error)
+	code     Code
+	message  string
+	metadata map[string]string
+	trailer  map[string]string
+	delay    time.Duration
+	interval time.Duration
+}
+
+// WithMessage adds a response message. Adding several messages makes the response a server stream.
+// It panics if the message can't be marshaled.
+func WithMessage(msg proto.Message) ResponseRule {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		panic(fmt.Errorf("WithMessage err: message marshal failed: %w", err))
+	}
+
+	return func(r *response) {
+		r.messages = append(r.messages, func(*Request) ([]byte, error) { return data, nil })
+	}
+}
+
+// WithMessages adds the response messages of a server stream, see WithStreamInterval.
+func WithMessages(msgs ...proto.Message) ResponseRule {
+	rules := make([]ResponseRule, 0, len(msgs))
+	for _, msg := range msgs {
+		rules = append(rules, WithMessage(msg))
+	}
+
+	return func(r *response) {
+		for _, rule := range rules {
+			rule(r)
+		}
+	}
+}
+
+// WithMessageFunc adds a response message computed from the request on every call, e.g. to echo request fields.
+// If the message can't be marshaled, the call fails with Internal.
+//
+// Example:
+//
+//	WithMessageFunc(func(r *Request) proto.Message {
+//		var req pb.HelloRequest
+//		_ = r.Message(&req)
+//
+//		return &pb.HelloReply{Message: "hello " + req.GetName()}
+//	})
+func WithMessageFunc(fn func(*Request) proto.Message) ResponseRule {
+	return func(r *response) {
+		r.messages = append(r.messages, func(req *Request) ([]byte, error) {
+			data, err := proto.Marshal(fn(req))
+			if err != nil {
+				return nil, fmt.Errorf("message marshal failed: %w", err)
+			}
+
+			return data, nil
+		})
+	}
+}
+
+// WithError sets the status of the call, e.g. WithError(NotFound, "user not found"). The messages added with
+// the other rules are still sent before the status, e.g. to fail a server stream midway.
+func WithError(code Code, message string) ResponseRule {
+	return func(r *response) {
+		r.code = code
+		r.message = message
+	}
+}
+
+// WithMetadata sets a response header metadata value.
+func WithMetadata(key, value string) ResponseRule {
+	return func(r *response) {
+		if r.metadata == nil {
+			r.metadata = make(map[string]string)
+		}
+
+		r.metadata[key] = value
+	}
+}
+
+// WithTrailer sets a response trailer metadata value.
+func WithTrailer(key, value string) ResponseRule {
+	return func(r *response) {
+		if r.trailer == nil {
+			r.trailer = make(map[string]string)
+		}
+
+		r.trailer[key] = value
+	}
+}
+
+// WithDelay sets a delay before the response is sent, e.g. to test client deadlines.
+func WithDelay(d time.Duration) ResponseRule {
+	return func(r *response) {
+		r.delay = d
+	}
+}
+
+// WithStreamInterval sets the time waited between the response messages of a server stream.
+func WithStreamInterval(d time.Duration) ResponseRule {
+	return func(r *response) {
+		r.interval = d
+	}
+}
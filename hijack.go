@@ -0,0 +1,39 @@
+package mockaso
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// WithHijack takes over the connection of the matched request, so the given func can speak any protocol on it,
+// e.g. after an HTTP Upgrade. The func must write the response itself, including the status line if any.
+// The connection is closed when the func returns. Other response rules are ignored, except for waits and delays.
+//
+// Example:
+//
+//	WithHijack(func(conn net.Conn, rw *bufio.ReadWriter) {
+//		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: custom\r\nConnection: Upgrade\r\n\r\n")
+//		_ = rw.Flush()
+//		// ... custom framing over rw
+//	})
+func WithHijack(fn func(net.Conn, *bufio.ReadWriter)) StubResponseRule {
+	return func(r *stubResponse) {
+		r.hijack = fn
+	}
+}
+
+// hijackConn hijacks the connection and hands it to fn. If the connection can not be hijacked,
+// e.g. HTTP/2 requests, the response will be 500 Internal Server Error.
+func hijackConn(w http.ResponseWriter, fn func(net.Conn, *bufio.ReadWriter)) {
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("hijack failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	defer conn.Close()
+
+	fn(conn, rw)
+}
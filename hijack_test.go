@@ -0,0 +1,61 @@
+package mockaso_test
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestWithHijack(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodGet, mockaso.Path("/test/hijack")).
+		Match(mockaso.MatchHeader("Upgrade", "echo")).
+		Respond(mockaso.WithHijack(func(_ net.Conn, rw *bufio.ReadWriter) {
+			_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: echo\r\nConnection: Upgrade\r\n\r\n")
+			_ = rw.Flush()
+
+			line, err := rw.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			_, _ = rw.WriteString(strings.ToUpper(line))
+			_ = rw.Flush()
+		}))
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL(), "http://"))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	_, err = fmt.Fprint(conn, "GET /test/hijack HTTP/1.1\r\nHost: mockaso\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+	require.NoError(t, err)
+
+	reader := bufio.NewReader(conn)
+
+	httpResp, err := http.ReadResponse(reader, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusSwitchingProtocols, httpResp.StatusCode)
+	assert.Equal(t, "echo", httpResp.Header.Get("Upgrade"))
+
+	_, err = fmt.Fprint(conn, "hello mockaso\n")
+	require.NoError(t, err)
+
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+
+	assert.Equal(t, "HELLO MOCKASO\n", line)
+}
@@ -0,0 +1,113 @@
+package mockaso
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HMACCanonicalizer returns the signed message and the hex encoded signature, given the raw request body and
+// the value of the signature header. ok is false when the header value is malformed.
+type HMACCanonicalizer func(body []byte, header string) (message []byte, signature string, ok bool)
+
+// HMACRawBody is the HMACCanonicalizer where the signature is the hex encoded HMAC of the raw body.
+func HMACRawBody(body []byte, header string) ([]byte, string, bool) {
+	return body, header, header != ""
+}
+
+// HMACGitHub is the HMACCanonicalizer for GitHub style signatures, e.g. X-Hub-Signature-256: sha256=<hex>.
+func HMACGitHub(body []byte, header string) ([]byte, string, bool) {
+	signature, found := strings.CutPrefix(header, "sha256=")
+	return body, signature, found
+}
+
+// HMACStripe is the HMACCanonicalizer for Stripe style signatures, e.g. Stripe-Signature: t=<timestamp>,v1=<hex>.
+// The signed message is "<timestamp>.<body>".
+func HMACStripe(body []byte, header string) ([]byte, string, bool) {
+	var timestamp, signature string
+
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+
+	if timestamp == "" || signature == "" {
+		return nil, "", false
+	}
+
+	return append([]byte(timestamp+"."), body...), signature, true
+}
+
+// MatchHMACSignature sets a rule to match the http request with a valid HMAC-SHA256 signature in the given header.
+// The canonicalizer defines how the signed message and the signature are obtained, e.g. HMACRawBody,
+// HMACGitHub or HMACStripe.
+func MatchHMACSignature(header, secret string, canonicalizer HMACCanonicalizer) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		message, signature, ok := canonicalizer(mustReadBody(r), r.Header.Get(header))
+		if !ok {
+			return false
+		}
+
+		received, err := hex.DecodeString(signature)
+		if err != nil {
+			return false
+		}
+
+		return hmac.Equal(received, hmacSHA256(secret, message))
+	})
+
+	return describedRule(matcher, "header %s signature mismatch", header)
+}
+
+func hmacSHA256(secret string, message []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(message)
+
+	return mac.Sum(nil)
+}
+
+// HMACSigner returns the signature header value for the given body, using sign to compute the hex encoded HMAC
+// of the signed message. now is the current time of the server Clock (see WithClock), for timestamped signatures.
+type HMACSigner func(body []byte, now time.Time, sign func(message []byte) string) string
+
+// HMACRawBodySigner is the HMACSigner where the header is the hex encoded HMAC of the raw body.
+func HMACRawBodySigner(body []byte, _ time.Time, sign func([]byte) string) string {
+	return sign(body)
+}
+
+// HMACGitHubSigner is the HMACSigner for GitHub style signatures, e.g. X-Hub-Signature-256: sha256=<hex>.
+func HMACGitHubSigner(body []byte, _ time.Time, sign func([]byte) string) string {
+	return "sha256=" + sign(body)
+}
+
+// HMACStripeSigner is the HMACSigner for Stripe style signatures, e.g. Stripe-Signature: t=<timestamp>,v1=<hex>.
+// The signed message is "<timestamp>.<body>" with the current unix time of the server Clock as timestamp.
+func HMACStripeSigner(body []byte, now time.Time, sign func([]byte) string) string {
+	timestamp := now.Unix()
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, sign(append([]byte(fmt.Sprintf("%d.", timestamp)), body...)))
+}
+
+// WithHMACSignature sets a response header with the HMAC-SHA256 signature of the response body, emulating
+// providers which sign their payloads. The signer defines the header format, e.g. HMACRawBodySigner,
+// HMACGitHubSigner or HMACStripeSigner. The signature is computed over the uncompressed body.
+func WithHMACSignature(header, secret string, signer HMACSigner) StubResponseRule {
+	sign := func(message []byte) string {
+		return hex.EncodeToString(hmacSHA256(secret, message))
+	}
+
+	return func(r *stubResponse) {
+		r.bodyHeaders = append(r.bodyHeaders, func(st *stub, h http.Header, body []byte) {
+			h.Set(header, signer(body, st.clock.Now(), sign))
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package mockaso_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestMatchHMACSignature(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const (
+		secret = "whsec_test"
+		body   = `{"event":"payment.created"}`
+	)
+
+	server.Stub(http.MethodPost, mockaso.Path("/webhooks/raw")).
+		Match(mockaso.MatchHMACSignature("X-Signature", secret, mockaso.HMACRawBody)).
+		Respond(matchedRequestRules()...)
+
+	server.Stub(http.MethodPost, mockaso.Path("/webhooks/github")).
+		Match(mockaso.MatchHMACSignature("X-Hub-Signature-256", secret, mockaso.HMACGitHub)).
+		Respond(matchedRequestRules()...)
+
+	server.Stub(http.MethodPost, mockaso.Path("/webhooks/stripe")).
+		Match(mockaso.MatchHMACSignature("Stripe-Signature", secret, mockaso.HMACStripe)).
+		Respond(matchedRequestRules()...)
+
+	testCases := map[string]struct {
+		path        string
+		header      string
+		validSign   string
+		invalidSign string
+	}{
+		"raw body": {
+			path:        "/webhooks/raw",
+			header:      "X-Signature",
+			validSign:   hmacHex(secret, body),
+			invalidSign: hmacHex("other secret", body),
+		},
+		"github": {
+			path:        "/webhooks/github",
+			header:      "X-Hub-Signature-256",
+			validSign:   "sha256=" + hmacHex(secret, body),
+			invalidSign: hmacHex(secret, body),
+		},
+		"stripe": {
+			path:        "/webhooks/stripe",
+			header:      "Stripe-Signature",
+			validSign:   "t=1700000000,v1=" + hmacHex(secret, "1700000000."+body),
+			invalidSign: "t=1700000001,v1=" + hmacHex(secret, "1700000000."+body),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("should return the specified stub when signature is valid", func(t *testing.T) {
+				httpReq, _ := http.NewRequest(http.MethodPost, tc.path, strings.NewReader(body))
+				httpReq.Header.Set(tc.header, tc.validSign)
+
+				httpResp, err := server.Client().Do(httpReq)
+				require.NoError(t, err)
+
+				assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+				assertBodyString(t, "matched request", httpResp)
+			})
+
+			t.Run("should return no match response when signature is not valid", func(t *testing.T) {
+				httpReq, _ := http.NewRequest(http.MethodPost, tc.path, strings.NewReader(body))
+				httpReq.Header.Set(tc.header, tc.invalidSign)
+
+				httpResp, err := server.Client().Do(httpReq)
+				require.NoError(t, err)
+
+				assertNotMatchedResponse(t, httpReq, httpResp)
+			})
+
+			t.Run("should return no match response when body was tampered", func(t *testing.T) {
+				httpReq, _ := http.NewRequest(http.MethodPost, tc.path, strings.NewReader(body+" "))
+				httpReq.Header.Set(tc.header, tc.validSign)
+
+				httpResp, err := server.Client().Do(httpReq)
+				require.NoError(t, err)
+
+				assertNotMatchedResponse(t, httpReq, httpResp)
+			})
+
+			t.Run("should return no match response when signature is missing", func(t *testing.T) {
+				httpReq, _ := http.NewRequest(http.MethodPost, tc.path, strings.NewReader(body))
+
+				httpResp, err := server.Client().Do(httpReq)
+				require.NoError(t, err)
+
+				assertNotMatchedResponse(t, httpReq, httpResp)
+			})
+		})
+	}
+}
+
+func TestWithHMACSignature(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const (
+		secret = "whsec_test"
+		body   = `{"event":"payment.created"}`
+	)
+
+	testCases := map[string]struct {
+		header        string
+		signer        mockaso.HMACSigner
+		canonicalizer mockaso.HMACCanonicalizer
+	}{
+		"raw body": {header: "X-Signature", signer: mockaso.HMACRawBodySigner, canonicalizer: mockaso.HMACRawBody},
+		"github":   {header: "X-Hub-Signature-256", signer: mockaso.HMACGitHubSigner, canonicalizer: mockaso.HMACGitHub},
+		"stripe":   {header: "Stripe-Signature", signer: mockaso.HMACStripeSigner, canonicalizer: mockaso.HMACStripe},
+	}
+
+	for name, tc := range testCases {
+		t.Run("should sign the response body with "+name+" signer", func(t *testing.T) {
+			t.Parallel()
+
+			path := "/test/with-hmac-signature/" + strings.ReplaceAll(name, " ", "-")
+
+			server.Stub(http.MethodGet, mockaso.Path(path)).
+				Respond(
+					mockaso.WithRawJSON(body),
+					mockaso.WithHMACSignature(tc.header, secret, tc.signer),
+				)
+
+			httpReq, _ := http.NewRequest(http.MethodGet, path, http.NoBody)
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+
+			respBody := readString(httpResp.Body)
+			assert.Equal(t, body, respBody)
+
+			message, signature, ok := tc.canonicalizer([]byte(respBody), httpResp.Header.Get(tc.header))
+			require.True(t, ok)
+			assert.Equal(t, hmacHex(secret, string(message)), signature)
+		})
+	}
+}
+
+func TestHMACStripeSigner(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithClock(clock))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodGet, mockaso.Path("/webhook")).
+		Respond(mockaso.WithBody("{}"), mockaso.WithHMACSignature("Stripe-Signature", "secret", mockaso.HMACStripeSigner))
+
+	httpResp, err := server.Client().Get("/webhook")
+	require.NoError(t, err)
+
+	expected := "t=1700000000,v1=" + hmacHex("secret", "1700000000.{}")
+	assert.Equal(t, expected, httpResp.Header.Get("Stripe-Signature"), "the timestamp is the server clock time")
+}
+
+func hmacHex(secret, message string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
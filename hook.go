@@ -0,0 +1,83 @@
+package mockaso
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ResponseInfo describes the response written by the server for a request, see WithResponseHook.
+type ResponseInfo struct {
+	StatusCode int
+	Header     http.Header
+	BodySize   int64         // the bytes written of the response body
+	Duration   time.Duration // time to write the whole response since the request was received
+	Matched    bool          // whether a stub matched the request, i.e. it was not a no match or fallback response
+	StubName   string        // the name of the stub which matched the request, if any, see Stub.Name
+}
+
+// WithRequestHook adds a hook called for every request received by the server, before a stub is matched,
+// e.g. to collect metrics or for custom logging. Hooks can read the request body, which is restored for the stubs.
+func WithRequestHook(hook func(*http.Request)) ServerOption {
+	return func(s *Server) {
+		s.requestHooks = append(s.requestHooks, hook)
+	}
+}
+
+// WithResponseHook adds a hook called for every request received by the server, once its response was written.
+// Hooks can read the request body, as in WithRequestHook.
+func WithResponseHook(hook func(*http.Request, *ResponseInfo)) ServerOption {
+	return func(s *Server) {
+		s.responseHooks = append(s.responseHooks, hook)
+	}
+}
+
+func (s *Server) runResponseHooks(r *http.Request, tw *timingWriter, st *stub, received time.Time) {
+	info := &ResponseInfo{
+		StatusCode: tw.statusCode,
+		Header:     tw.Header(),
+		BodySize:   tw.size,
+		Duration:   time.Since(received),
+		Matched:    st != nil,
+	}
+
+	if info.StatusCode == 0 { // the status is written with the first write, or when the handler returns
+		info.StatusCode = http.StatusOK
+	}
+
+	if st != nil {
+		info.StubName = st.name
+	}
+
+	for _, hook := range s.responseHooks {
+		hook(hookRequest(r), info)
+	}
+}
+
+// hookRequest returns a copy of the request for the hooks, whose body is read on demand from the body cache, so it
+// is still available for the stubs and the 100 Continue is not sent unless the body is read.
+func hookRequest(r *http.Request) *http.Request {
+	hr := r.WithContext(r.Context())
+	hr.Body = &lazyBody{request: r}
+
+	return hr
+}
+
+// lazyBody reads the body of a request with mustReadBody on the first read.
+type lazyBody struct {
+	request *http.Request
+	reader  io.Reader
+}
+
+func (b *lazyBody) Read(p []byte) (int, error) {
+	if b.reader == nil {
+		b.reader = bytes.NewReader(mustReadBody(b.request))
+	}
+
+	return b.reader.Read(p)
+}
+
+func (b *lazyBody) Close() error {
+	return nil
+}
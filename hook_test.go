@@ -0,0 +1,113 @@
+package mockaso_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestWithRequestHook(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mutex  sync.Mutex
+		bodies []string
+	)
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithRequestHook(func(r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		bodies = append(bodies, r.Method+" "+r.URL.Path+" "+string(body))
+	}))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodPost, mockaso.Path("/api/users")).
+		Match(mockaso.MatchRawJSONBody(`{"name":"john"}`)).
+		Respond(mockaso.WithStatusCode(http.StatusCreated))
+
+	httpResp, err := server.Client().Post("/api/users", "application/json", strings.NewReader(`{"name":"john"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, httpResp.StatusCode)
+
+	httpResp, err = server.Client().Get("/api/unknown")
+	require.NoError(t, err)
+	assert.Equal(t, 666, httpResp.StatusCode)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	assert.Equal(t, []string{`POST /api/users {"name":"john"}`, "GET /api/unknown "}, bodies)
+}
+
+func TestWithResponseHook(t *testing.T) {
+	t.Parallel()
+
+	infos := make(chan *mockaso.ResponseInfo, 2)
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t),
+		mockaso.WithResponseHook(func(_ *http.Request, info *mockaso.ResponseInfo) { infos <- info }))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodGet, mockaso.Path("/api/users")).Name("list-users").
+		Respond(mockaso.WithStatusCode(http.StatusAccepted), mockaso.WithHeader("X-Id", "1"), mockaso.WithBody("users"))
+
+	t.Run("should describe the response of the matched stub", func(t *testing.T) {
+		httpResp, err := server.Client().Get("/api/users")
+		require.NoError(t, err)
+		assertBodyString(t, "users", httpResp)
+
+		info := <-infos
+		assert.Equal(t, http.StatusAccepted, info.StatusCode)
+		assert.Equal(t, "1", info.Header.Get("X-Id"))
+		assert.Equal(t, int64(len("users")), info.BodySize)
+		assert.Positive(t, info.Duration)
+		assert.True(t, info.Matched)
+		assert.Equal(t, "list-users", info.StubName)
+	})
+
+	t.Run("should describe the no match response", func(t *testing.T) {
+		httpResp, err := server.Client().Get("/api/unknown")
+		require.NoError(t, err)
+		httpResp.Body.Close()
+
+		info := <-infos
+		assert.Equal(t, 666, info.StatusCode)
+		assert.False(t, info.Matched)
+		assert.Empty(t, info.StubName)
+	})
+}
+
+func TestStub_OnMatch(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	matched := make(chan string, 1)
+
+	server.Stub(http.MethodPost, mockaso.Path("/api/events")).
+		OnMatch(func(r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			matched <- string(body)
+		}).
+		Respond(mockaso.RespondWith(func(r *http.Request) (mockaso.Response, error) {
+			body, err := io.ReadAll(r.Body)
+			return mockaso.Response{mockaso.WithBody(body)}, err
+		}))
+
+	httpResp, err := server.Client().Post("/api/events", "text/plain", strings.NewReader("event"))
+	require.NoError(t, err)
+
+	assertBodyString(t, "event", httpResp)
+	assert.Equal(t, "event", <-matched)
+}
@@ -0,0 +1,129 @@
+package mockaso
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// stubKey is the method and the static path of a stub, to index it. See stubIndex.
+type stubKey struct {
+	method string
+	path   string
+}
+
+// newStubKey returns the key of a stub with the given method and URL description, or nil if the URL matcher does
+// not match a static path (e.g. patterns and regexes), so the stub can not be indexed.
+func newStubKey(method string, description urlDescription) *stubKey {
+	if !description.static {
+		return nil
+	}
+
+	return &stubKey{method: method, path: description.path}
+}
+
+// urlDescription describes a URL matcher, see matchState.describe.
+type urlDescription struct {
+	text   string // the URL, path or pattern given to the URL matcher
+	static bool   // the URL matcher matches a static path
+	path   string // the static path, compared to the decoded request path
+
+	muxPattern *muxPattern // the pattern of a MuxPattern matcher
+}
+
+// describeURL returns the description of the URL matcher, which is empty if the URL matcher does not describe itself.
+func describeURL(matcher URLMatcher) urlDescription {
+	state := &matchState{describe: new(urlDescription)}
+	matcher(new(url.URL), state)
+
+	return *state.describe
+}
+
+// describedURLMatcher returns the URL matcher, which describes itself with the given text (see describeURL).
+func describedURLMatcher(text string, matcher URLMatcher) URLMatcher {
+	return func(u *url.URL, state *matchState) bool {
+		if state != nil && state.describe != nil {
+			state.describe.text = text
+			return false
+		}
+
+		return matcher(u, state)
+	}
+}
+
+// stubIndex indexes the stubs by method and static path, so the stubs which may match a request are found without
+// evaluating every stub. The stubs which can not be indexed are candidates for every request.
+type stubIndex struct {
+	byKey     map[stubKey][]*stub
+	unindexed []*stub
+	positions map[*stub]int // the registration order of the stubs
+}
+
+func newStubIndex(stubs []*stub) *stubIndex {
+	index := &stubIndex{
+		byKey:     make(map[stubKey][]*stub),
+		positions: make(map[*stub]int, len(stubs)),
+	}
+
+	index.add(stubs...)
+
+	return index
+}
+
+func (idx *stubIndex) add(stubs ...*stub) {
+	for _, st := range stubs {
+		idx.positions[st] = len(idx.positions)
+
+		if st.key == nil {
+			idx.unindexed = append(idx.unindexed, st)
+			continue
+		}
+
+		idx.byKey[*st.key] = append(idx.byKey[*st.key], st)
+	}
+}
+
+// candidates returns the stubs which may match the request, in registration order.
+func (idx *stubIndex) candidates(r *http.Request) []*stub {
+	indexed := idx.byKey[stubKey{method: r.Method, path: r.URL.Path}]
+
+	switch {
+	case len(indexed) == 0:
+		return idx.unindexed
+	case len(idx.unindexed) == 0:
+		return indexed
+	}
+
+	candidates := make([]*stub, 0, len(indexed)+len(idx.unindexed))
+
+	i, j := 0, 0
+	for i < len(indexed) && j < len(idx.unindexed) {
+		if idx.positions[indexed[i]] < idx.positions[idx.unindexed[j]] {
+			candidates = append(candidates, indexed[i])
+			i++
+		} else {
+			candidates = append(candidates, idx.unindexed[j])
+			j++
+		}
+	}
+
+	candidates = append(candidates, indexed[i:]...)
+
+	return append(candidates, idx.unindexed[j:]...)
+}
+
+// appendStubs registers the stubs in the server. The server mutex must be locked.
+func (s *Server) appendStubs(stubs ...*stub) {
+	s.stubs = append(s.stubs, stubs...)
+
+	if s.index == nil {
+		s.index = newStubIndex(nil)
+	}
+
+	s.index.add(stubs...)
+}
+
+// setStubs replaces the stubs of the server, e.g. to remove some of them. The server mutex must be locked.
+func (s *Server) setStubs(stubs []*stub) {
+	s.stubs = stubs
+	s.index = newStubIndex(stubs)
+}
@@ -0,0 +1,105 @@
+// Package jsondiff computes field-level differences between JSON documents.
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// Diff returns the field-level differences between two decoded JSON values in a unified format,
+// where "-" lines are expected values and "+" lines are actual values, e.g. `- $.user.name: "john"`.
+// Paths start with "$" for the root value. No lines are returned when the values are equal.
+func Diff(expected, actual any) []string {
+	return diff("$", expected, actual, nil)
+}
+
+// DiffBytes is like Diff for encoded JSON documents. An empty document is treated as null.
+func DiffBytes(expected, actual []byte) ([]string, error) {
+	var expectedJSON, actualJSON any
+
+	if len(expected) > 0 {
+		if err := json.Unmarshal(expected, &expectedJSON); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal expected JSON: %w", err)
+		}
+	}
+
+	if len(actual) > 0 {
+		if err := json.Unmarshal(actual, &actualJSON); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal actual JSON: %w", err)
+		}
+	}
+
+	return Diff(expectedJSON, actualJSON), nil
+}
+
+// diff appends the differences found under the given path.
+func diff(path string, expected, actual any, lines []string) []string {
+	switch expectedValue := expected.(type) {
+	case map[string]any:
+		actualValue, ok := actual.(map[string]any)
+		if !ok {
+			break
+		}
+
+		keys := slices.Collect(maps.Keys(expectedValue))
+
+		for key := range actualValue {
+			if _, found := expectedValue[key]; !found {
+				keys = append(keys, key)
+			}
+		}
+
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			expectedField, inExpected := expectedValue[key]
+			actualField, inActual := actualValue[key]
+			fieldPath := path + "." + key
+
+			switch {
+			case !inActual:
+				lines = append(lines, "- "+fieldPath+": "+jsonString(expectedField))
+			case !inExpected:
+				lines = append(lines, "+ "+fieldPath+": "+jsonString(actualField))
+			default:
+				lines = diff(fieldPath, expectedField, actualField, lines)
+			}
+		}
+
+		return lines
+	case []any:
+		actualValue, ok := actual.([]any)
+		if !ok {
+			break
+		}
+
+		for i := range max(len(expectedValue), len(actualValue)) {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+
+			switch {
+			case i >= len(actualValue):
+				lines = append(lines, "- "+itemPath+": "+jsonString(expectedValue[i]))
+			case i >= len(expectedValue):
+				lines = append(lines, "+ "+itemPath+": "+jsonString(actualValue[i]))
+			default:
+				lines = diff(itemPath, expectedValue[i], actualValue[i], lines)
+			}
+		}
+
+		return lines
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		lines = append(lines, "- "+path+": "+jsonString(expected), "+ "+path+": "+jsonString(actual))
+	}
+
+	return lines
+}
+
+func jsonString(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,146 @@
+package mockaso
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// RecordedRequest is a request received by the server. See Server.Requests.
+type RecordedRequest struct {
+	Method   string
+	URL      *url.URL
+	Header   http.Header
+	Body     []byte
+	Stub     Stub      // the matched stub, nil if the request did not match any stub
+	Received time.Time // when the request was received
+}
+
+// Requests returns the requests received by the server, in the order they were received.
+func (s *Server) Requests() []*RecordedRequest {
+	return s.RequestsMatching(func(*http.Request) bool { return true })
+}
+
+// RequestsMatching returns the requests received by the server for which matcher returns true,
+// in the order they were received. The request body can be read by the matcher.
+//
+// Example:
+//
+//	posts := server.RequestsMatching(func(r *http.Request) bool { return r.Method == http.MethodPost })
+func (s *Server) RequestsMatching(matcher RequestMatcherFunc) []*RecordedRequest {
+	var requests []*RecordedRequest
+
+	for _, rr := range s.journal.all() {
+		if matcher(rr.httpRequest()) {
+			requests = append(requests, rr.export())
+		}
+	}
+
+	return requests
+}
+
+// journal records the requests received by the server.
+type journal struct {
+	requests []*recordedRequest
+	mutex    sync.RWMutex
+}
+
+// recordedRequest is a snapshot of a request received by the server.
+type recordedRequest struct {
+	request  *http.Request
+	body     []byte
+	received time.Time
+	stub     *stub  // the matched stub, nil if the request did not match any stub
+	late     string // why the request is late (see Server.AssertNoLateRequests), empty if it is not
+	timing   RequestTiming
+}
+
+// record saves a snapshot of the request (see bodySnapshot) received at the given time.
+func (j *journal) record(r *http.Request, received time.Time) *recordedRequest {
+	rr := &recordedRequest{
+		body:     bytes.Clone(bodySnapshot(r)), // not shared with the matchers, since it is exported
+		request:  r.Clone(context.Background()),
+		received: received,
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.requests = append(j.requests, rr)
+
+	return rr
+}
+
+func (j *journal) setStub(rr *recordedRequest, st *stub) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	rr.stub = st
+}
+
+func (j *journal) setLate(rr *recordedRequest, reason string) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	rr.late = reason
+}
+
+func (j *journal) setTiming(rr *recordedRequest, timing RequestTiming) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	rr.timing = timing
+}
+
+// bodySnapshot reads and restores the request body, so it can be read again by matchers, except for requests with
+// Expect: 100-continue whose body was not read yet, to not send the 100 Continue.
+func bodySnapshot(r *http.Request) []byte {
+	if expectsContinue(r) && !requestBodyCache(r).read.Load() {
+		return nil
+	}
+
+	return mustReadBody(r)
+}
+
+// all returns a copy of the recorded requests.
+func (j *journal) all() []recordedRequest {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+
+	requests := make([]recordedRequest, 0, len(j.requests))
+	for _, rr := range j.requests {
+		requests = append(requests, *rr)
+	}
+
+	return requests
+}
+
+// httpRequest returns a copy of the recorded request with its body ready to be read.
+func (rr recordedRequest) httpRequest() *http.Request {
+	r := rr.request.Clone(context.Background())
+	r.Body = io.NopCloser(bytes.NewReader(rr.body))
+
+	return r
+}
+
+func (rr recordedRequest) export() *RecordedRequest {
+	u := *rr.request.URL
+
+	exported := &RecordedRequest{
+		Method:   rr.request.Method,
+		URL:      &u,
+		Header:   rr.request.Header.Clone(),
+		Body:     bytes.Clone(rr.body),
+		Received: rr.received,
+	}
+
+	if rr.stub != nil { // avoid a non-nil Stub holding a nil *stub
+		exported.Stub = rr.stub
+	}
+
+	return exported
+}
@@ -0,0 +1,73 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestServer_Requests(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	st := server.Stub(http.MethodPost, mockaso.Path("/users"))
+	st.Respond(mockaso.WithStatusCode(http.StatusCreated))
+
+	before := time.Now()
+
+	httpReq, _ := http.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"john"}`))
+	httpReq.Header.Set("X-Request-Id", "1")
+
+	_, err := server.Client().Do(httpReq)
+	require.NoError(t, err)
+
+	_, err = server.Client().Get("/orders?page=1")
+	require.NoError(t, err)
+
+	t.Run("should return all the requests in the order they were received", func(t *testing.T) {
+		t.Parallel()
+
+		requests := server.Requests()
+		require.Len(t, requests, 2)
+
+		assert.Equal(t, http.MethodPost, requests[0].Method)
+		assert.Equal(t, "/users", requests[0].URL.String())
+		assert.Equal(t, "1", requests[0].Header.Get("X-Request-Id"))
+		assert.Equal(t, `{"name":"john"}`, string(requests[0].Body))
+		assert.Equal(t, st, requests[0].Stub)
+		assert.False(t, requests[0].Received.Before(before))
+
+		assert.Equal(t, http.MethodGet, requests[1].Method)
+		assert.Equal(t, "/orders?page=1", requests[1].URL.String())
+		assert.Empty(t, requests[1].Body)
+		assert.Nil(t, requests[1].Stub)
+		assert.False(t, requests[1].Received.Before(requests[0].Received))
+	})
+
+	t.Run("should return the requests matching the given matcher", func(t *testing.T) {
+		t.Parallel()
+
+		requests := server.RequestsMatching(func(r *http.Request) bool {
+			body := readString(r.Body)
+			return strings.Contains(body, "john")
+		})
+
+		require.Len(t, requests, 1)
+		assert.Equal(t, "/users", requests[0].URL.String())
+	})
+
+	t.Run("should return nil when no request matches", func(t *testing.T) {
+		t.Parallel()
+
+		requests := server.RequestsMatching(func(r *http.Request) bool { return r.Method == http.MethodDelete })
+		assert.Nil(t, requests)
+	})
+}
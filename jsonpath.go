@@ -0,0 +1,177 @@
+package mockaso
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MatchJSONPath sets a rule to match the http request with a JSON body which has the given value at the path.
+// The path is a dot separated list of object keys and array indexes, e.g. "user.address.city" or "items.0.id".
+// The value is compared as JSON, so MatchJSONPath("age", 57) matches {"age":57.0}.
+func MatchJSONPath(path string, value any) StubMatcherRule {
+	data, err := json.Marshal(value)
+	if err != nil {
+		panic(fmt.Errorf("MatchJSONPath err: marshal value failed: %w", err))
+	}
+
+	var expected any
+	_ = json.Unmarshal(data, &expected)
+
+	return MatchJSONPathFunc(path, func(actual any) bool {
+		return reflect.DeepEqual(expected, actual)
+	})
+}
+
+// MatchJSONPathFunc sets a rule to match the http request with a JSON body whose value at the path satisfies the
+// predicate (see MatchJSONPath for the path syntax). The value is decoded as by encoding/json into an any.
+// The request does not match if the path does not exist.
+//
+// Example:
+//
+//	MatchJSONPathFunc("user.age", func(v any) bool { age, ok := v.(float64); return ok && age >= 18 })
+func MatchJSONPathFunc(path string, predicate func(any) bool) StubMatcherRule {
+	segments := strings.Split(path, ".")
+
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		var body any
+
+		if err := json.Unmarshal(mustReadDecodedBody(r), &body); err != nil {
+			return false
+		}
+
+		value, found := lookupJSONPath(body, segments)
+
+		return found && predicate(value)
+	})
+
+	return describedRule(matcher, "JSON path %s mismatch", path)
+}
+
+// MatchJSONBodyIgnoring sets a rule to match the http request with the given JSON body, as MatchJSONBody does,
+// excluding the given fields from the comparison, e.g. nondeterministic fields like timestamps or request ids.
+// Fields are paths as in MatchJSONPath, and "*" matches any key or index, e.g. "items.*.created_at".
+func MatchJSONBodyIgnoring(body any, fields ...string) StubMatcherRule {
+	data, err := json.Marshal(body)
+	if err != nil {
+		panic(fmt.Errorf("MatchJSONBodyIgnoring err: marshal body failed: %w", err))
+	}
+
+	var expected any
+	_ = json.Unmarshal(data, &expected)
+
+	ignored := make([][]string, 0, len(fields))
+	for _, field := range fields {
+		ignored = append(ignored, strings.Split(field, "."))
+	}
+
+	for _, segments := range ignored {
+		expected = deleteJSONPath(expected, segments)
+	}
+
+	expectedData, _ := json.Marshal(expected)
+
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		reqBody := mustReadDecodedBody(r)
+
+		var actual any
+		if unmarshalErr := json.Unmarshal(reqBody, &actual); unmarshalErr != nil {
+			return false
+		}
+
+		for _, segments := range ignored {
+			actual = deleteJSONPath(actual, segments)
+		}
+
+		if !reflect.DeepEqual(expected, actual) {
+			actualData, _ := json.Marshal(actual)
+			reportJSONBodyDiff(r, expectedData, actualData)
+
+			return false
+		}
+
+		return true
+	})
+
+	return describedRule(matcher, "body mismatch")
+}
+
+// deleteJSONPath deletes the value at the path segments of a decoded JSON document, where "*" matches any key or
+// index, and returns the document. Array items are removed, so the following ones are not compared by position.
+func deleteJSONPath(doc any, segments []string) any {
+	if len(segments) == 0 {
+		return doc
+	}
+
+	segment, rest := segments[0], segments[1:]
+
+	switch v := doc.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if segment != "*" && segment != key {
+				continue
+			}
+
+			if len(rest) == 0 {
+				delete(v, key)
+			} else {
+				v[key] = deleteJSONPath(value, rest)
+			}
+		}
+
+		return v
+	case []any:
+		if len(rest) > 0 {
+			for i, item := range v {
+				if segment == "*" || segment == strconv.Itoa(i) {
+					v[i] = deleteJSONPath(item, rest)
+				}
+			}
+
+			return v
+		}
+
+		if segment == "*" {
+			return []any{}
+		}
+
+		if i, err := strconv.Atoi(segment); err == nil && i >= 0 && i < len(v) {
+			return append(v[:i], v[i+1:]...)
+		}
+
+		return v
+	default:
+		return doc
+	}
+}
+
+// lookupJSONPath returns the value at the path segments of a decoded JSON document.
+func lookupJSONPath(doc any, segments []string) (any, bool) {
+	current := doc
+
+	for _, segment := range segments {
+		switch v := current.(type) {
+		case map[string]any:
+			next, ok := v[segment]
+			if !ok {
+				return nil, false
+			}
+
+			current = next
+		case []any:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+
+			current = v[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
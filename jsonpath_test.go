@@ -0,0 +1,140 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestMatchJSONPath(t *testing.T) {
+	t.Parallel()
+
+	const body = `{"user":{"name":"john","age":57,"address":{"city":"Rome"},"tags":["a","b"]},` +
+		`"items":[{"id":1},{"id":2}],"deleted":null}`
+
+	testCases := map[string]struct {
+		rule     mockaso.StubMatcherRule
+		body     string
+		expected bool
+	}{
+		"should match a nested string": {
+			rule:     mockaso.MatchJSONPath("user.address.city", "Rome"),
+			expected: true,
+		},
+		"should match a number": {
+			rule:     mockaso.MatchJSONPath("user.age", 57),
+			expected: true,
+		},
+		"should match an array item": {
+			rule:     mockaso.MatchJSONPath("items.1.id", 2),
+			expected: true,
+		},
+		"should match an object": {
+			rule:     mockaso.MatchJSONPath("items.0", map[string]int{"id": 1}),
+			expected: true,
+		},
+		"should match an array": {
+			rule:     mockaso.MatchJSONPath("user.tags", []string{"a", "b"}),
+			expected: true,
+		},
+		"should match a null": {
+			rule:     mockaso.MatchJSONPath("deleted", nil),
+			expected: true,
+		},
+		"should not match a different value": {
+			rule: mockaso.MatchJSONPath("user.address.city", "Milan"),
+		},
+		"should not match a different type": {
+			rule: mockaso.MatchJSONPath("user.age", "57"),
+		},
+		"should not match a missing key": {
+			rule: mockaso.MatchJSONPath("user.address.zip", nil),
+		},
+		"should not match an index out of range": {
+			rule: mockaso.MatchJSONPath("items.2.id", 3),
+		},
+		"should not match a path through a scalar": {
+			rule: mockaso.MatchJSONPath("user.name.first", "john"),
+		},
+		"should match a predicate": {
+			rule:     mockaso.MatchJSONPathFunc("user.age", func(v any) bool { age, ok := v.(float64); return ok && age >= 18 }),
+			expected: true,
+		},
+		"should not match a false predicate": {
+			rule: mockaso.MatchJSONPathFunc("user.age", func(v any) bool { return v == nil }),
+		},
+		"should not match an invalid body": {
+			rule: mockaso.MatchJSONPath("user.name", "john"),
+			body: `{"user":`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			reqBody := body
+			if tc.body != "" {
+				reqBody = tc.body
+			}
+
+			httpReq := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(reqBody))
+			assert.Equal(t, tc.expected, stubMatches(httpReq, tc.rule))
+		})
+	}
+}
+
+func TestMatchJSONBodyIgnoring(t *testing.T) {
+	t.Parallel()
+
+	expected := map[string]any{
+		"name":       "john",
+		"created_at": "2024-01-01T00:00:00Z",
+		"items":      []map[string]any{{"id": 1, "created_at": "2024-01-01T00:00:00Z"}},
+		"meta":       map[string]any{"request_id": "abc", "source": "web"},
+	}
+
+	rule := mockaso.MatchJSONBodyIgnoring(expected, "created_at", "items.*.created_at", "meta.request_id")
+
+	testCases := map[string]struct {
+		body     string
+		expected bool
+	}{
+		"should match ignoring the given fields": {
+			body: `{"name":"john","created_at":"2025-05-05T10:00:00Z",` +
+				`"items":[{"id":1,"created_at":"2025-05-05T10:00:00Z"}],"meta":{"request_id":"xyz","source":"web"}}`,
+			expected: true,
+		},
+		"should match when the ignored fields are missing": {
+			body:     `{"name":"john","items":[{"id":1}],"meta":{"source":"web"}}`,
+			expected: true,
+		},
+		"should not match when another field differs": {
+			body: `{"name":"rick","created_at":"2025-05-05T10:00:00Z",` +
+				`"items":[{"id":1,"created_at":"2025-05-05T10:00:00Z"}],"meta":{"request_id":"xyz","source":"web"}}`,
+		},
+		"should not match when a nested field differs": {
+			body: `{"name":"john","items":[{"id":2}],"meta":{"source":"web"}}`,
+		},
+		"should not match when there are more fields": {
+			body: `{"name":"john","age":57,"items":[{"id":1}],"meta":{"source":"web"}}`,
+		},
+		"should not match an invalid body": {
+			body: `{"name":`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tc.body))
+			assert.Equal(t, tc.expected, stubMatches(httpReq, rule))
+		})
+	}
+}
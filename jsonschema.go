@@ -0,0 +1,441 @@
+package mockaso
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MatchJSONSchema sets a rule to match the http request with a JSON body which is valid against the given
+// JSON Schema, to match the shape of the body instead of its exact values. It panics if the schema is not valid.
+//
+// Supported keywords: type, properties, required, additionalProperties, items, enum, const, oneOf, anyOf, allOf,
+// not, minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf, minLength, maxLength, pattern, minItems,
+// maxItems, uniqueItems and format (email, uuid, date-time, date, time, uri, hostname, ipv4). Other formats are
+// not validated.
+//
+// Example:
+//
+//	MatchJSONSchema([]byte(`{"type":"object","required":["id"],"properties":{"id":{"type":"integer"}}}`))
+func MatchJSONSchema(schema []byte) StubMatcherRule {
+	validator, err := newJSONSchemaValidator(schema)
+	if err != nil {
+		panic(fmt.Errorf("MatchJSONSchema err: invalid schema: %w", err))
+	}
+
+	return matchJSONSchema(validator)
+}
+
+// MatchJSONSchemaFile sets a rule to match the http request with a JSON body which is valid against the JSON Schema
+// of the given file. See MatchJSONSchema.
+func MatchJSONSchemaFile(path string) StubMatcherRule {
+	schema, err := os.ReadFile(path)
+	if err != nil {
+		panic(fmt.Errorf("MatchJSONSchemaFile err: read schema file failed: %w", err))
+	}
+
+	validator, err := newJSONSchemaValidator(schema)
+	if err != nil {
+		panic(fmt.Errorf("MatchJSONSchemaFile err: invalid schema %s: %w", path, err))
+	}
+
+	return matchJSONSchema(validator)
+}
+
+func matchJSONSchema(validator *jsonSchemaValidator) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		var body any
+
+		if err := json.Unmarshal(mustReadDecodedBody(r), &body); err != nil {
+			return false
+		}
+
+		return validator.validate(validator.schema, body, "$") == nil
+	})
+
+	return describedRule(matcher, "body does not match the JSON schema")
+}
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// jsonSchemaValidator validates decoded JSON documents against a JSON Schema.
+type jsonSchemaValidator struct {
+	schema   any
+	patterns map[string]*regexp.Regexp // the compiled pattern keywords
+}
+
+func newJSONSchemaValidator(data []byte) (*jsonSchemaValidator, error) {
+	var schema any
+
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+
+	v := &jsonSchemaValidator{schema: schema, patterns: make(map[string]*regexp.Regexp)}
+
+	if err := v.check(schema); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// check verifies that the schema and its subschemas are supported, and compiles their patterns.
+func (v *jsonSchemaValidator) check(schema any) error {
+	if _, ok := schema.(bool); ok {
+		return nil
+	}
+
+	s, ok := schema.(map[string]any)
+	if !ok {
+		return fmt.Errorf("schema must be an object or a boolean, got %v", schema)
+	}
+
+	for _, keyword := range []string{"$ref", "prefixItems", "patternProperties", "if", "dependentSchemas"} {
+		if _, found := s[keyword]; found {
+			return fmt.Errorf("unsupported schema keyword: %s", keyword)
+		}
+	}
+
+	if pattern, found := s["pattern"]; found {
+		str, _ := pattern.(string)
+
+		re, err := regexp.Compile(str)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+
+		v.patterns[str] = re
+	}
+
+	var subschemas []any
+
+	for _, keyword := range []string{"items", "additionalProperties", "not"} {
+		if sub, found := s[keyword]; found {
+			subschemas = append(subschemas, sub)
+		}
+	}
+
+	for _, keyword := range []string{"oneOf", "anyOf", "allOf"} {
+		if sub, found := s[keyword]; found {
+			list, isList := sub.([]any)
+			if !isList {
+				return fmt.Errorf("%s must be a list of schemas", keyword)
+			}
+
+			subschemas = append(subschemas, list...)
+		}
+	}
+
+	if properties, found := s["properties"]; found {
+		props, isObject := properties.(map[string]any)
+		if !isObject {
+			return errors.New("properties must be an object")
+		}
+
+		for _, sub := range props {
+			subschemas = append(subschemas, sub)
+		}
+	}
+
+	for _, sub := range subschemas {
+		if err := v.check(sub); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validate returns an error describing the first violation of the schema by the value at the path.
+func (v *jsonSchemaValidator) validate(schema any, value any, path string) error {
+	if accept, ok := schema.(bool); ok {
+		if !accept {
+			return fmt.Errorf("%s: not allowed", path)
+		}
+
+		return nil
+	}
+
+	s, _ := schema.(map[string]any)
+
+	if err := validateType(s, value, path); err != nil {
+		return err
+	}
+
+	if err := validateValue(s, value, path); err != nil {
+		return err
+	}
+
+	if err := v.validateComposition(s, value, path); err != nil {
+		return err
+	}
+
+	switch val := value.(type) {
+	case float64:
+		return validateNumber(s, val, path)
+	case string:
+		return v.validateString(s, val, path)
+	case []any:
+		return v.validateArray(s, val, path)
+	case map[string]any:
+		return v.validateObject(s, val, path)
+	default:
+		return nil
+	}
+}
+
+func validateType(s map[string]any, value any, path string) error {
+	var types []any
+
+	switch t := s["type"].(type) {
+	case string:
+		types = []any{t}
+	case []any:
+		types = t
+	default:
+		return nil
+	}
+
+	actual := jsonType(value)
+
+	for _, t := range types {
+		if t == actual || t == "number" && actual == "integer" {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s: expected type %v, got %s", path, s["type"], actual)
+}
+
+func validateValue(s map[string]any, value any, path string) error {
+	if expected, ok := s["const"]; ok && !reflect.DeepEqual(expected, value) {
+		return fmt.Errorf("%s: expected %v, got %v", path, expected, value)
+	}
+
+	if enum, ok := s["enum"].([]any); ok {
+		for _, expected := range enum {
+			if reflect.DeepEqual(expected, value) {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+	}
+
+	return nil
+}
+
+func (v *jsonSchemaValidator) validateComposition(s map[string]any, value any, path string) error {
+	if all, ok := s["allOf"].([]any); ok {
+		for _, sub := range all {
+			if err := v.validate(sub, value, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	if anyOf, ok := s["anyOf"].([]any); ok && v.countValid(anyOf, value, path) == 0 {
+		return fmt.Errorf("%s: does not match any schema of anyOf", path)
+	}
+
+	if oneOf, ok := s["oneOf"].([]any); ok {
+		if count := v.countValid(oneOf, value, path); count != 1 {
+			return fmt.Errorf("%s: matches %d schemas of oneOf, expected 1", path, count)
+		}
+	}
+
+	if not, ok := s["not"]; ok && v.validate(not, value, path) == nil {
+		return fmt.Errorf("%s: matches the schema of not", path)
+	}
+
+	return nil
+}
+
+func (v *jsonSchemaValidator) countValid(schemas []any, value any, path string) int {
+	var count int
+
+	for _, sub := range schemas {
+		if v.validate(sub, value, path) == nil {
+			count++
+		}
+	}
+
+	return count
+}
+
+func validateNumber(s map[string]any, value float64, path string) error {
+	if minimum, ok := schemaNumber(s["minimum"]); ok && value < minimum {
+		return fmt.Errorf("%s: %v is less than the minimum %v", path, value, minimum)
+	}
+
+	if maximum, ok := schemaNumber(s["maximum"]); ok && value > maximum {
+		return fmt.Errorf("%s: %v is greater than the maximum %v", path, value, maximum)
+	}
+
+	if minimum, ok := schemaNumber(s["exclusiveMinimum"]); ok && value <= minimum {
+		return fmt.Errorf("%s: %v is not greater than the exclusive minimum %v", path, value, minimum)
+	}
+
+	if maximum, ok := schemaNumber(s["exclusiveMaximum"]); ok && value >= maximum {
+		return fmt.Errorf("%s: %v is not less than the exclusive maximum %v", path, value, maximum)
+	}
+
+	if multiple, ok := schemaNumber(s["multipleOf"]); ok && multiple > 0 {
+		if quotient := value / multiple; math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			return fmt.Errorf("%s: %v is not a multiple of %v", path, value, multiple)
+		}
+	}
+
+	return nil
+}
+
+func (v *jsonSchemaValidator) validateString(s map[string]any, value string, path string) error {
+	length := float64(len([]rune(value)))
+
+	if minLength, ok := schemaNumber(s["minLength"]); ok && length < minLength {
+		return fmt.Errorf("%s: length %v is less than the minimum length %v", path, length, minLength)
+	}
+
+	if maxLength, ok := schemaNumber(s["maxLength"]); ok && length > maxLength {
+		return fmt.Errorf("%s: length %v is greater than the maximum length %v", path, length, maxLength)
+	}
+
+	if pattern, ok := s["pattern"].(string); ok && !v.patterns[pattern].MatchString(value) {
+		return fmt.Errorf("%s: %q does not match the pattern %s", path, value, pattern)
+	}
+
+	if format, ok := s["format"].(string); ok && !validFormat(format, value) {
+		return fmt.Errorf("%s: %q is not a valid %s", path, value, format)
+	}
+
+	return nil
+}
+
+func (v *jsonSchemaValidator) validateArray(s map[string]any, value []any, path string) error {
+	length := float64(len(value))
+
+	if minItems, ok := schemaNumber(s["minItems"]); ok && length < minItems {
+		return fmt.Errorf("%s: %v items are less than the minimum %v", path, length, minItems)
+	}
+
+	if maxItems, ok := schemaNumber(s["maxItems"]); ok && length > maxItems {
+		return fmt.Errorf("%s: %v items are more than the maximum %v", path, length, maxItems)
+	}
+
+	if unique, _ := s["uniqueItems"].(bool); unique {
+		for i := range value {
+			for j := i + 1; j < len(value); j++ {
+				if reflect.DeepEqual(value[i], value[j]) {
+					return fmt.Errorf("%s: items %d and %d are equal", path, i, j)
+				}
+			}
+		}
+	}
+
+	if items, ok := s["items"]; ok {
+		for i, item := range value {
+			if err := v.validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (v *jsonSchemaValidator) validateObject(s map[string]any, value map[string]any, path string) error {
+	if required, ok := s["required"].([]any); ok {
+		for _, name := range required {
+			if _, found := value[fmt.Sprint(name)]; !found {
+				return fmt.Errorf("%s: missing required property %v", path, name)
+			}
+		}
+	}
+
+	properties, _ := s["properties"].(map[string]any)
+	additional, hasAdditional := s["additionalProperties"]
+
+	for name, property := range value {
+		propertyPath := path + "." + name
+
+		if sub, found := properties[name]; found {
+			if err := v.validate(sub, property, propertyPath); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if hasAdditional {
+			if err := v.validate(additional, property, propertyPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// jsonType returns the JSON Schema type of a decoded JSON value, integer for numbers without a fractional part.
+func jsonType(value any) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if val == math.Trunc(val) {
+			return "integer"
+		}
+
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func validFormat(format, value string) bool {
+	switch format {
+	case "email":
+		at := strings.LastIndex(value, "@")
+		return at > 0 && at < len(value)-1 && !strings.ContainsAny(value, " \t\r\n")
+	case "uuid":
+		return uuidRegexp.MatchString(value)
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	case "time":
+		_, err := time.Parse(time.TimeOnly, value)
+		return err == nil
+	case "uri":
+		u, err := url.Parse(value)
+		return err == nil && u.Scheme != ""
+	case "hostname":
+		return value != "" && len(value) <= 253 && !strings.ContainsAny(value, " /:@") &&
+			!strings.HasPrefix(value, ".") && !strings.HasSuffix(value, ".")
+	case "ipv4":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && strings.Contains(value, ".")
+	default: // other formats are not validated
+		return true
+	}
+}
@@ -0,0 +1,240 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/royhq/mockaso"
+)
+
+const testOrderSchema = `{
+	"type": "object",
+	"required": ["id", "email", "items"],
+	"additionalProperties": false,
+	"properties": {
+		"id": {"type": "string", "format": "uuid"},
+		"email": {"type": "string", "format": "email"},
+		"status": {"enum": ["pending", "paid"]},
+		"coupon": {"type": ["string", "null"], "pattern": "^[A-Z]{3}[0-9]{2}$"},
+		"items": {
+			"type": "array",
+			"minItems": 1,
+			"uniqueItems": true,
+			"items": {
+				"type": "object",
+				"required": ["sku", "quantity"],
+				"properties": {
+					"sku": {"type": "string", "minLength": 3},
+					"quantity": {"type": "integer", "minimum": 1, "maximum": 10},
+					"price": {"type": "number", "exclusiveMinimum": 0, "multipleOf": 0.01}
+				}
+			}
+		}
+	}
+}`
+
+func TestMatchJSONSchema(t *testing.T) {
+	t.Parallel()
+
+	const order = `"id":"5f2b9c1e-8a4d-4b7e-9c3f-1a2b3c4d5e6f","email":"john@example.com"`
+
+	rule := mockaso.MatchJSONSchema([]byte(testOrderSchema))
+
+	testCases := map[string]struct {
+		body     string
+		expected bool
+	}{
+		"should match a valid body": {
+			body:     `{` + order + `,"status":"paid","items":[{"sku":"abc","quantity":2,"price":9.99}]}`,
+			expected: true,
+		},
+		"should match a nullable field": {
+			body:     `{` + order + `,"coupon":null,"items":[{"sku":"abc","quantity":1}]}`,
+			expected: true,
+		},
+		"should match a pattern": {
+			body:     `{` + order + `,"coupon":"ABC10","items":[{"sku":"abc","quantity":1}]}`,
+			expected: true,
+		},
+		"should not match a missing required property": {
+			body: `{` + order + `}`,
+		},
+		"should not match an additional property": {
+			body: `{` + order + `,"items":[{"sku":"abc","quantity":1}],"notes":"x"}`,
+		},
+		"should not match a wrong type": {
+			body: `{` + order + `,"items":[{"sku":"abc","quantity":"1"}]}`,
+		},
+		"should not match a number instead of an integer": {
+			body: `{` + order + `,"items":[{"sku":"abc","quantity":1.5}]}`,
+		},
+		"should not match a value out of range": {
+			body: `{` + order + `,"items":[{"sku":"abc","quantity":11}]}`,
+		},
+		"should not match a value not in the enum": {
+			body: `{` + order + `,"status":"lost","items":[{"sku":"abc","quantity":1}]}`,
+		},
+		"should not match a short string": {
+			body: `{` + order + `,"items":[{"sku":"ab","quantity":1}]}`,
+		},
+		"should not match a string not matching the pattern": {
+			body: `{` + order + `,"coupon":"abc10","items":[{"sku":"abc","quantity":1}]}`,
+		},
+		"should not match an invalid format": {
+			body: `{"id":"1","email":"john@example.com","items":[{"sku":"abc","quantity":1}]}`,
+		},
+		"should not match too few items": {
+			body: `{` + order + `,"items":[]}`,
+		},
+		"should not match duplicated items": {
+			body: `{` + order + `,"items":[{"sku":"abc","quantity":1},{"sku":"abc","quantity":1}]}`,
+		},
+		"should not match a number which is not a multiple": {
+			body: `{` + order + `,"items":[{"sku":"abc","quantity":1,"price":9.999}]}`,
+		},
+		"should not match an invalid body": {
+			body: `{"id":`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(tc.body))
+			assert.Equal(t, tc.expected, stubMatches(httpReq, rule))
+		})
+	}
+}
+
+func TestMatchJSONSchemaComposition(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		schema   string
+		body     string
+		expected bool
+	}{
+		"should match anyOf": {
+			schema:   `{"anyOf":[{"type":"string"},{"type":"integer"}]}`,
+			body:     `1`,
+			expected: true,
+		},
+		"should not match anyOf": {
+			schema: `{"anyOf":[{"type":"string"},{"type":"integer"}]}`,
+			body:   `true`,
+		},
+		"should match oneOf": {
+			schema:   `{"oneOf":[{"type":"integer"},{"type":"number","minimum":10}]}`,
+			body:     `1`,
+			expected: true,
+		},
+		"should not match more than one of oneOf": {
+			schema: `{"oneOf":[{"type":"integer"},{"type":"number","minimum":10}]}`,
+			body:   `11`,
+		},
+		"should match allOf": {
+			schema:   `{"allOf":[{"required":["a"]},{"required":["b"]}]}`,
+			body:     `{"a":1,"b":2}`,
+			expected: true,
+		},
+		"should not match allOf": {
+			schema: `{"allOf":[{"required":["a"]},{"required":["b"]}]}`,
+			body:   `{"a":1}`,
+		},
+		"should match not": {
+			schema:   `{"not":{"type":"null"}}`,
+			body:     `{}`,
+			expected: true,
+		},
+		"should not match not": {
+			schema: `{"not":{"type":"null"}}`,
+			body:   `null`,
+		},
+		"should match const": {
+			schema:   `{"properties":{"kind":{"const":"user"}}}`,
+			body:     `{"kind":"user"}`,
+			expected: true,
+		},
+		"should not match const": {
+			schema: `{"properties":{"kind":{"const":"user"}}}`,
+			body:   `{"kind":"admin"}`,
+		},
+		"should match a true schema": {
+			schema:   `true`,
+			body:     `[1,"a"]`,
+			expected: true,
+		},
+		"should not match a false schema": {
+			schema: `false`,
+			body:   `{}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rule := mockaso.MatchJSONSchema([]byte(tc.schema))
+			httpReq := httptest.NewRequest(http.MethodPost, "/values", strings.NewReader(tc.body))
+			assert.Equal(t, tc.expected, stubMatches(httpReq, rule))
+		})
+	}
+}
+
+func TestMatchJSONSchemaInvalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		schema      string
+		expectedErr string
+	}{
+		"invalid json": {
+			schema:      `{"type":`,
+			expectedErr: "MatchJSONSchema err: invalid schema: unexpected end of JSON input",
+		},
+		"invalid pattern": {
+			schema:      `{"pattern":"[a-"}`,
+			expectedErr: "MatchJSONSchema err: invalid schema: invalid pattern: error parsing regexp: missing closing ]: `[a-`",
+		},
+		"unsupported keyword": {
+			schema:      `{"properties":{"user":{"$ref":"#/$defs/user"}}}`,
+			expectedErr: "MatchJSONSchema err: invalid schema: unsupported schema keyword: $ref",
+		},
+		"invalid subschema": {
+			schema:      `{"items":1}`,
+			expectedErr: "MatchJSONSchema err: invalid schema: schema must be an object or a boolean, got 1",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.PanicsWithError(t, tc.expectedErr, func() { mockaso.MatchJSONSchema([]byte(tc.schema)) })
+		})
+	}
+}
+
+func TestMatchJSONSchemaFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "order.schema.json")
+	writeFile(t, path, testOrderSchema)
+
+	rule := mockaso.MatchJSONSchemaFile(path)
+
+	valid := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(
+		`{"id":"5f2b9c1e-8a4d-4b7e-9c3f-1a2b3c4d5e6f","email":"john@example.com","items":[{"sku":"abc","quantity":1}]}`))
+	invalid := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"id":"1"}`))
+
+	assert.True(t, stubMatches(valid, rule))
+	assert.False(t, stubMatches(invalid, rule))
+
+	assert.Panics(t, func() { mockaso.MatchJSONSchemaFile(filepath.Join(t.TempDir(), "missing.json")) })
+}
@@ -0,0 +1,107 @@
+package mockaso
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// LongPoll signals data availability to requests held open by WithLongPoll.
+type LongPoll struct {
+	queue   []*stubResponse      // published responses without a held request
+	waiters []chan *stubResponse // held requests, the oldest first
+	mutex   sync.Mutex
+}
+
+// NewLongPoll returns a LongPoll to be used with WithLongPoll.
+func NewLongPoll() *LongPoll {
+	return &LongPoll{}
+}
+
+// Publish makes the given response available. It is sent to the oldest request held open or, if there is none,
+// to the next request.
+func (p *LongPoll) Publish(rules ...StubResponseRule) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	response := Response(rules).build()
+
+	if len(p.waiters) == 0 {
+		p.queue = append(p.queue, response)
+		return
+	}
+
+	waiter := p.waiters[0]
+	p.waiters = p.waiters[1:]
+	waiter <- response // buffered, each waiter receives only one response
+}
+
+// next returns the next published response, or nil if the timeout elapses or the request is canceled.
+func (p *LongPoll) next(r *http.Request, clock Clock, timeout time.Duration) *stubResponse {
+	p.mutex.Lock()
+
+	if len(p.queue) > 0 {
+		response := p.queue[0]
+		p.queue = p.queue[1:]
+		p.mutex.Unlock()
+
+		return response
+	}
+
+	waiter := make(chan *stubResponse, 1)
+	p.waiters = append(p.waiters, waiter)
+	p.mutex.Unlock()
+
+	select {
+	case response := <-waiter:
+		return response
+	case <-clock.After(timeout):
+		return p.leave(waiter, false)
+	case <-r.Context().Done():
+		return p.leave(waiter, true)
+	}
+}
+
+// leave removes the waiter of a request which is no longer held. If a response was sent to the waiter meanwhile,
+// it is returned or, if the request was canceled, put back at the head of the queue for the next request.
+func (p *LongPoll) leave(waiter chan *stubResponse, canceled bool) *stubResponse {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if i := slices.Index(p.waiters, waiter); i >= 0 {
+		p.waiters = slices.Delete(p.waiters, i, i+1)
+		return nil
+	}
+
+	response := <-waiter
+	if !canceled {
+		return response
+	}
+
+	p.queue = slices.Insert(p.queue, 0, response)
+
+	return nil
+}
+
+// WithLongPoll holds the request open until a response is published in the given LongPoll or the timeout elapses.
+// When the timeout elapses the stub response is used, so it should be the "empty result", e.g. 204 No Content.
+//
+// Example:
+//
+//	poll := NewLongPoll()
+//	server.Stub(http.MethodGet, Path("/events")).
+//		Respond(WithStatusCode(http.StatusNoContent), WithLongPoll(poll, 30*time.Second))
+//
+//	poll.Publish(WithJSON(event)) // releases the held request with the event
+func WithLongPoll(poll *LongPoll, timeout time.Duration) StubResponseRule {
+	return func(r *stubResponse) {
+		r.selector = func(st *stub, req *http.Request) *stubResponse {
+			if response := poll.next(req, st.clock, timeout); response != nil {
+				return response
+			}
+
+			return r
+		}
+	}
+}
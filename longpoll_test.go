@@ -0,0 +1,135 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestWithLongPoll(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	newLongPollStub := func(path string, timeout time.Duration) *mockaso.LongPoll {
+		poll := mockaso.NewLongPoll()
+
+		server.Stub(http.MethodGet, mockaso.Path(path)).
+			Respond(
+				mockaso.WithStatusCode(http.StatusNoContent),
+				mockaso.WithLongPoll(poll, timeout),
+			)
+
+		return poll
+	}
+
+	doGet := func(t *testing.T, path string) *http.Response {
+		t.Helper()
+
+		httpReq, _ := http.NewRequest(http.MethodGet, path, http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		return httpResp
+	}
+
+	t.Run("should respond empty result when timeout elapses", func(t *testing.T) {
+		t.Parallel()
+
+		const path = "/test/long-poll/timeout"
+		newLongPollStub(path, 300*time.Millisecond)
+
+		start := time.Now()
+		httpResp := doGet(t, path)
+
+		assert.Equal(t, http.StatusNoContent, httpResp.StatusCode)
+		assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+	})
+
+	t.Run("should respond published data to the waiting request", func(t *testing.T) {
+		t.Parallel()
+
+		const path = "/test/long-poll/publish"
+		poll := newLongPollStub(path, 10*time.Second)
+
+		time.AfterFunc(200*time.Millisecond, func() {
+			poll.Publish(mockaso.WithStatusCode(http.StatusOK), mockaso.WithBody("new data"))
+		})
+
+		start := time.Now()
+		httpResp := doGet(t, path)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assertBodyString(t, "new data", httpResp)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("should respond published data immediately when it was published before the request", func(t *testing.T) {
+		t.Parallel()
+
+		const path = "/test/long-poll/published-before"
+		poll := newLongPollStub(path, 10*time.Second)
+
+		poll.Publish(mockaso.WithBody("first"))
+		poll.Publish(mockaso.WithBody("second"))
+
+		assertBodyString(t, "first", doGet(t, path))
+		assertBodyString(t, "second", doGet(t, path))
+	})
+
+	t.Run("should not block stub registration while a request is held open", func(t *testing.T) {
+		t.Parallel()
+
+		const path = "/test/long-poll/registration"
+		poll := newLongPollStub(path, 10*time.Second)
+
+		done := make(chan *http.Response)
+		go func() {
+			httpResp, _ := server.Client().Get(path)
+			done <- httpResp
+		}()
+
+		time.Sleep(100 * time.Millisecond) // let the request be held open
+		server.Stub(http.MethodGet, mockaso.Path(path+"/other"))
+		poll.Publish(mockaso.WithBody("released"))
+
+		assertBodyString(t, "released", <-done)
+	})
+	t.Run("should respond published data to the held requests in arrival order", func(t *testing.T) {
+		t.Parallel()
+
+		const path = "/test/long-poll/order"
+
+		poll := mockaso.NewLongPoll()
+		st := server.Stub(http.MethodGet, mockaso.Path(path))
+		st.Respond(mockaso.WithStatusCode(http.StatusNoContent), mockaso.WithLongPoll(poll, 10*time.Second))
+
+		responses := make([]chan *http.Response, 3)
+
+		for i := range responses {
+			responses[i] = make(chan *http.Response, 1)
+
+			go func() {
+				httpResp, _ := server.Client().Get(path)
+				responses[i] <- httpResp
+			}()
+
+			require.Eventually(t, func() bool { return st.Calls() == i+1 }, time.Second, 10*time.Millisecond)
+			time.Sleep(50 * time.Millisecond) // let the request be held open
+		}
+
+		for _, body := range []string{"first", "second", "third"} {
+			poll.Publish(mockaso.WithBody(body))
+		}
+
+		assertBodyString(t, "first", <-responses[0])
+		assertBodyString(t, "second", <-responses[1])
+		assertBodyString(t, "third", <-responses[2])
+	})
+}
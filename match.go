@@ -2,57 +2,204 @@ package mockaso
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"mime"
+	"net"
 	"net/http"
 	"net/url"
 	"reflect"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
-type requestMatcherFunc func(*stub, *http.Request) bool
+type requestMatcherFunc func(*matchState, *http.Request) bool
 
-type URLMatcher func(*url.URL, *stub) bool
+type URLMatcher func(*url.URL, *matchState) bool
+
+// matchState holds the data of the evaluation of a stub for a single request, so concurrent requests matched by the
+// same stub do not share it.
+type matchState struct {
+	params     map[string]string // the path params, see URLPattern
+	muxPattern *muxPattern       // set when the URL was matched by a MuxPattern
+	describe   *urlDescription   // when set, the URL matcher describes itself instead of matching, see describeURL
+	trace      *matchTrace       // when set, the matchers report why they rejected the request, see WithMatchTracing
+}
+
+// URLOption configures how the URL and Path matchers compare percent-encoded URLs.
+type URLOption func(*urlOptions)
+
+type urlOptions struct {
+	encoding urlEncodingMode
+}
+
+type urlEncodingMode int
+
+const (
+	urlEncodingExact urlEncodingMode = iota + 1
+	urlEncodingIgnored
+)
+
+// ExactEncoding compares the URL exactly as it was sent, e.g. "%2F" and "/" or "%41" and "A" are different.
+func ExactEncoding() URLOption {
+	return func(o *urlOptions) {
+		o.encoding = urlEncodingExact
+	}
+}
+
+// IgnoreEncoding compares the decoded URL, e.g. "%2F" and "/" are equal, as "+" and "%20" in the query string.
+func IgnoreEncoding() URLOption {
+	return func(o *urlOptions) {
+		o.encoding = urlEncodingIgnored
+	}
+}
+
+func newURLOptions(opts []URLOption) urlOptions {
+	var options urlOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options
+}
 
 // URL will match http request when the value specified is equals to the full request URL.
-func URL(u string) URLMatcher {
-	return func(url *url.URL, _ *stub) bool {
-		return u == url.String()
+// By default the request URL is re-serialized, which may normalize its encoding. See ExactEncoding and IgnoreEncoding.
+func URL(u string, opts ...URLOption) URLMatcher {
+	switch newURLOptions(opts).encoding {
+	case urlEncodingExact:
+		return describedURLMatcher(u, func(url *url.URL, _ *matchState) bool {
+			return u == sentURL(url)
+		})
+	case urlEncodingIgnored:
+		decoded := decodeURL(u)
+
+		return describedURLMatcher(u, func(url *url.URL, _ *matchState) bool {
+			return decoded == decodeURL(sentURL(url))
+		})
+	default:
+		return describedURLMatcher(u, func(url *url.URL, _ *matchState) bool {
+			return u == url.String()
+		})
 	}
 }
 
 // Path will match http request when the value specified is equals to the request URL path part.
-func Path(path string) URLMatcher {
+// By default the decoded request path is compared, so "%2F" and "/" are equal. See ExactEncoding and IgnoreEncoding.
+func Path(path string, opts ...URLOption) URLMatcher {
 	ensureHasNotQueryStringParams(path)
 
-	return func(url *url.URL, _ *stub) bool {
-		return url.Path == strings.TrimSuffix(path, "/")
+	path = strings.TrimSuffix(path, "/")
+
+	switch newURLOptions(opts).encoding {
+	case urlEncodingExact:
+		return describedURLMatcher(path, func(url *url.URL, _ *matchState) bool {
+			return sentPath(url) == path
+		})
+	case urlEncodingIgnored:
+		return staticPathMatcher(decodePath(path))
+	default:
+		return staticPathMatcher(path)
+	}
+}
+
+// staticPathMatcher returns a URL matcher which compares the decoded request path with the given one, and which
+// allows to index the stubs by path (see stubIndex).
+func staticPathMatcher(path string) URLMatcher {
+	return func(url *url.URL, state *matchState) bool {
+		if state != nil && state.describe != nil {
+			state.describe.text, state.describe.static, state.describe.path = path, true, path
+			return false
+		}
+
+		return url.Path == path
+	}
+}
+
+// sentPath returns the path as it was sent. RawPath is only set by net/url when it is not the default encoding.
+func sentPath(u *url.URL) string {
+	if u.RawPath != "" {
+		return u.RawPath
+	}
+
+	return u.EscapedPath()
+}
+
+// sentURL returns the path and query string as they were sent.
+func sentURL(u *url.URL) string {
+	if u.RawQuery == "" && !u.ForceQuery {
+		return sentPath(u)
+	}
+
+	return sentPath(u) + "?" + u.RawQuery
+}
+
+// decodeURL returns the URL with its path and query string components decoded.
+func decodeURL(u string) string {
+	path, query, hasQuery := strings.Cut(u, "?")
+	if !hasQuery {
+		return decodePath(path)
 	}
+
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		key, value, hasValue := strings.Cut(param, "=")
+		params[i] = decodeQueryComponent(key)
+
+		if hasValue {
+			params[i] += "=" + decodeQueryComponent(value)
+		}
+	}
+
+	return decodePath(path) + "?" + strings.Join(params, "&")
+}
+
+func decodePath(path string) string {
+	if decoded, err := url.PathUnescape(path); err == nil {
+		return decoded
+	}
+
+	return path
+}
+
+func decodeQueryComponent(s string) string {
+	if decoded, err := url.QueryUnescape(s); err == nil {
+		return decoded
+	}
+
+	return s
 }
 
 // URLRegex will match http request when the regex pattern specified match to the request URL.
 func URLRegex(pattern string) URLMatcher {
 	regex := regexp.MustCompile(pattern)
-	return func(url *url.URL, _ *stub) bool { return regex.MatchString(url.String()) }
+	return describedURLMatcher(pattern, func(url *url.URL, _ *matchState) bool { return regex.MatchString(url.String()) })
 }
 
 // PathRegex will match http request when the regex pattern specified match to the request URL path part.
 func PathRegex(pattern string) URLMatcher {
 	regex := regexp.MustCompile(pattern)
-	return func(url *url.URL, _ *stub) bool { return regex.MatchString(url.Path) }
+	return describedURLMatcher(pattern, func(url *url.URL, _ *matchState) bool { return regex.MatchString(url.Path) })
 }
 
 // URLPattern will match http request when the given URL pattern match to the request URL.
 // Can specify path params with {param_name} notation and then use it in matcher.
 // Can use parameters in query string.
+// Params can be constrained with a type: {id:int} for digits, {rest:*} for any tail of the URL (slashes included)
+// or a regex like {slug:[a-z-]+}.
 //
 // Example:
 //
 //	URLPattern("/api/users/{user_id}")
 //	URLPattern("/api/users/{user_id}?attrs={attrs}")
+//	URLPattern("/api/users/{user_id:int}/files/{path:*}")
 func URLPattern(pattern string) URLMatcher {
 	source := func(u *url.URL) string { return u.String() } // use complete url as source
 	return patternMatcher(source, pattern)
@@ -61,10 +208,12 @@ func URLPattern(pattern string) URLMatcher {
 // PathPattern will match http request when the given URL pattern match to the request URL path part.
 // Can specify path params with {param_name} notation and then use it in matcher.
 // Can't use parameters in query string, only path will be evaluated.
+// Params can be constrained with a type as in URLPattern.
 //
 // Example:
 //
 //	PathPattern("/api/users/{user_id}")
+//	PathPattern("/api/posts/{slug:[a-z-]+}")
 func PathPattern(pattern string) URLMatcher {
 	ensureHasNotQueryStringParams(pattern)
 	source := func(u *url.URL) string { return u.Path } // use url path as source
@@ -74,20 +223,20 @@ func PathPattern(pattern string) URLMatcher {
 
 func defaultMatchers(method string, url URLMatcher) []requestMatcherFunc {
 	return []requestMatcherFunc{
-		methodMatcher(method),
-		urlMatcher(url),
+		describedMatcher(methodMatcher(method), "method mismatch"),
+		describedMatcher(urlMatcher(url), "url mismatch"),
 	}
 }
 
 func methodMatcher(method string) requestMatcherFunc {
-	return func(_ *stub, r *http.Request) bool {
+	return func(_ *matchState, r *http.Request) bool {
 		return r.Method == method
 	}
 }
 
 func urlMatcher(matcher URLMatcher) requestMatcherFunc {
-	return func(st *stub, r *http.Request) bool {
-		return matcher(r.URL, st)
+	return func(state *matchState, r *http.Request) bool {
+		return matcher(r.URL, state)
 	}
 }
 
@@ -95,38 +244,94 @@ func patternMatcher(source func(*url.URL) string, pattern string) URLMatcher {
 	expr, paramKeys := convertPatternToRegex(pattern)
 	regex := regexp.MustCompile(expr)
 
-	return func(url *url.URL, s *stub) bool {
+	return describedURLMatcher(pattern, func(url *url.URL, state *matchState) bool {
 		match := regex.FindStringSubmatch(source(url))
 		if match == nil {
 			return false
 		}
 
-		params := make(map[string]string)
-		for i, paramKey := range paramKeys {
-			params[paramKey] = match[i+1]
+		if state != nil {
+			state.params = make(map[string]string, len(paramKeys))
+			for _, paramKey := range paramKeys {
+				state.params[paramKey] = match[regex.SubexpIndex(paramKey)]
+			}
 		}
 
-		s.patternParams = params
-
 		return true
-	}
+	})
 }
 
+// paramNameRegex validates the names of the pattern params, e.g. {user_id}.
+var paramNameRegex = regexp.MustCompile(`^\w+$`)
+
 func convertPatternToRegex(urlPattern string) (string, []string) {
-	urlPattern = escapeURLPattern(urlPattern)
+	var (
+		expr       strings.Builder
+		paramNames []string
+	)
+
+	for {
+		start := strings.IndexByte(urlPattern, '{')
+		if start < 0 {
+			break
+		}
 
-	var paramNames []string
+		end := closingBrace(urlPattern, start)
+		if end < 0 {
+			break
+		}
 
-	re := regexp.MustCompile(`\{(\w+)}`) // to identify parameters like {param_name} within pattern
+		name, constraint, _ := strings.Cut(urlPattern[start+1:end], ":")
+		if !paramNameRegex.MatchString(name) { // not a param, keep the brace
+			expr.WriteString(escapeURLPattern(urlPattern[:start+1]))
+			urlPattern = urlPattern[start+1:]
 
-	urlPattern = re.ReplaceAllStringFunc(urlPattern, func(match string) string {
-		paramName := re.FindStringSubmatch(match)[1]
-		paramNames = append(paramNames, paramName)
+			continue
+		}
 
-		return fmt.Sprintf(`(?P<%s>[^/?&]+)`, paramName)
-	})
+		expr.WriteString(escapeURLPattern(urlPattern[:start]))
+		fmt.Fprintf(&expr, `(?P<%s>%s)`, name, paramRegex(constraint))
+		paramNames = append(paramNames, name)
+		urlPattern = urlPattern[end+1:]
+	}
+
+	expr.WriteString(escapeURLPattern(urlPattern))
 
-	return "^" + urlPattern + "$", paramNames
+	return "^" + expr.String() + "$", paramNames
+}
+
+// paramRegex returns the regex of a pattern param given its type constraint, e.g. int for {id:int}.
+func paramRegex(constraint string) string {
+	switch constraint {
+	case "":
+		return `[^/?&]+`
+	case "int":
+		return `[0-9]+`
+	case "*":
+		return `.*`
+	default:
+		return constraint
+	}
+}
+
+// closingBrace returns the index of the brace which closes the one at start, so params like {code:[0-9]{3}}
+// can have braces in their constraint, or -1 if it is not closed.
+func closingBrace(s string, start int) int {
+	depth := 0
+
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
 }
 
 func escapeURLPattern(urlPattern string) string {
@@ -158,36 +363,280 @@ func MatchHeader(key, value string) StubMatcherRule {
 		return r.Header.Get(key) == value
 	})
 
-	return MatchRequest(matcher)
+	return describedRule(matcher, "header %s mismatch", key)
+}
+
+// MatchHeaderExact sets a rule to match the http request with a header sent with exactly the given name
+// (case-sensitive, not canonicalized) and value. Useful for clients talking to servers sensitive to the header case.
+func MatchHeaderExact(name, value string) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		return slices.Contains(rawHeaderFields(r), rawHeaderField{name: name, value: value})
+	})
+
+	return describedRule(matcher, "header %s mismatch", name)
+}
+
+// MatchHeaderValues sets a rule to match the http request with exactly the given values of a repeated header,
+// in the same order they were sent.
+func MatchHeaderValues(key string, values ...string) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		return slices.Equal(r.Header.Values(key), values)
+	})
+
+	return describedRule(matcher, "header %s mismatch", key)
+}
+
+// MatchHeaderRegex sets a rule to match the http request with a header whose value matches the regex pattern,
+// e.g. MatchHeaderRegex("Authorization", "^Bearer .+$"). If the header is repeated, any of its values may match.
+func MatchHeaderRegex(key, pattern string) StubMatcherRule {
+	regex := regexp.MustCompile(pattern)
+
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		return slices.ContainsFunc(r.Header.Values(key), regex.MatchString)
+	})
+
+	return describedRule(matcher, "header %s mismatch", key)
+}
+
+// MatchHeaderPresent sets a rule to match the http request with the given header, whatever its value (even empty).
+func MatchHeaderPresent(key string) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		return len(r.Header.Values(key)) > 0
+	})
+
+	return describedRule(matcher, "header %s missing", key)
+}
+
+// MatchHeaderAbsent sets a rule to match the http request without the given header.
+func MatchHeaderAbsent(key string) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		return len(r.Header.Values(key)) == 0
+	})
+
+	return describedRule(matcher, "header %s present", key)
+}
+
+// MatchContentType sets a rule to match the http request with the given media type in the Content-Type header,
+// ignoring its parameters and case, e.g. MatchContentType("application/json") matches
+// "application/json; charset=utf-8". It panics if the media type is not valid.
+func MatchContentType(mediaType string) StubMatcherRule {
+	expected, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		panic(fmt.Errorf("MatchContentType err: invalid media type: %w", err))
+	}
+
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		actual, _, parseErr := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		return parseErr == nil && actual == expected
+	})
+
+	return describedRule(matcher, "content type mismatch")
+}
+
+// MatchHost sets a rule to match the http request sent to the given host (case-insensitive), by its Host header.
+// The port is compared only when it is given, e.g. MatchHost("api.example.com") matches "api.example.com:8443".
+// Useful to impersonate several upstreams with a single server, see MultiTransport.
+func MatchHost(host string) StubMatcherRule {
+	_, _, splitErr := net.SplitHostPort(host)
+	withPort := splitErr == nil
+
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		reqHost := r.Host
+		if !withPort {
+			reqHost = hostname(reqHost)
+		}
+
+		return strings.EqualFold(reqHost, host)
+	})
+
+	return describedRule(matcher, "host mismatch")
+}
+
+// MatchScheme sets a rule to match the http request sent with the given scheme, "http" or "https".
+// The scheme is taken from the X-Forwarded-Proto header, which is set by MultiTransport and NewTransport with the
+// scheme of the original request, or else from the server connection.
+func MatchScheme(scheme string) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		return strings.EqualFold(requestScheme(r), scheme)
+	})
+
+	return describedRule(matcher, "scheme mismatch")
+}
+
+// hostname returns the host without the port, if any.
+func hostname(host string) string {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		return name
+	}
+
+	return host
+}
+
+func requestScheme(r *http.Request) string {
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		return proto
+	}
+
+	if r.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+// MatchTrailer sets a rule to match the http request with the given trailer value.
+// Trailers are sent after the body of chunked requests, so the body is read before evaluating the trailer.
+func MatchTrailer(key, value string) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		_ = mustReadBody(r) // trailers are only available once the body was consumed
+		return r.Trailer.Get(key) == value
+	})
+
+	return describedRule(matcher, "trailer %s mismatch", key)
+}
+
+// MatchChunkedRequest sets a rule to match the http request sent with Transfer-Encoding: chunked.
+// Useful to assert the client streamed the body instead of buffering it (unknown Content-Length).
+func MatchChunkedRequest() StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		return slices.Contains(r.TransferEncoding, "chunked")
+	})
+
+	return describedRule(matcher, "request not chunked")
+}
+
+// MatchHTTPProto sets a rule to match the http request with the given protocol version, e.g. "HTTP/1.1" or "HTTP/2.0".
+func MatchHTTPProto(proto string) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		return r.Proto == proto
+	})
+
+	return describedRule(matcher, "protocol mismatch")
 }
 
 // MatchQuery sets a rule to match the http request with the given query string value.
-func MatchQuery(key, value string) StubMatcherRule {
+// The options set how non-standard query strings are parsed, see SemicolonSeparator and DuplicateKeys.
+func MatchQuery(key, value string, opts ...QueryOption) StubMatcherRule {
+	options := newQueryOptions(opts)
+
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		values := options.parse(r.URL.RawQuery)[key]
+		return options.match(values, func(v string) bool { return v == value })
+	})
+
+	return describedRule(matcher, "query param %s mismatch", key)
+}
+
+// MatchQueryRegex sets a rule to match the http request with a query param whose value matches the regex pattern,
+// e.g. MatchQueryRegex("cursor", "^[A-Za-z0-9=]+$"). A missing param is matched as an empty value, as in MatchQuery.
+func MatchQueryRegex(key, pattern string, opts ...QueryOption) StubMatcherRule {
+	regex := regexp.MustCompile(pattern)
+	return MatchQueryFunc(key, regex.MatchString, opts...)
+}
+
+// MatchQueryFunc sets a rule to match the http request with a query param whose value satisfies the predicate.
+// A missing param is matched as an empty value, as in MatchQuery.
+//
+// Example:
+//
+//	MatchQueryFunc("since", func(v string) bool { _, err := time.Parse(time.RFC3339, v); return err == nil })
+func MatchQueryFunc(key string, predicate func(string) bool, opts ...QueryOption) StubMatcherRule {
+	options := newQueryOptions(opts)
+
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		return options.match(options.parse(r.URL.RawQuery)[key], predicate)
+	})
+
+	return describedRule(matcher, "query param %s mismatch", key)
+}
+
+// MatchQueryParams sets a rule to match the http request with all the given query params, as MatchQuery does for
+// each one. Other params of the request are ignored.
+func MatchQueryParams(params map[string]string, opts ...QueryOption) StubMatcherRule {
+	options := newQueryOptions(opts)
+
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		query := options.parse(r.URL.RawQuery)
+
+		for key, value := range params {
+			if !options.match(query[key], func(v string) bool { return v == value }) {
+				return false
+			}
+		}
+
+		return true
+	})
+
+	return describedRule(matcher, "query params mismatch")
+}
+
+// MatchQueryValues sets a rule to match the http request with exactly the given values of a repeated query param,
+// in the same order they were sent, e.g. MatchQueryValues("id", []string{"1", "2"}) matches ?id=1&id=2.
+// The duplicate keys policy is not applied.
+func MatchQueryValues(key string, values []string, opts ...QueryOption) StubMatcherRule {
+	options := newQueryOptions(opts)
+
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		return slices.Equal(options.parse(r.URL.RawQuery)[key], values)
+	})
+
+	return describedRule(matcher, "query param %s mismatch", key)
+}
+
+// MatchQueryPresent sets a rule to match the http request with the given query param, whatever its value
+// (even empty, e.g. ?debug or ?debug=).
+func MatchQueryPresent(key string, opts ...QueryOption) StubMatcherRule {
+	options := newQueryOptions(opts)
+
 	matcher := RequestMatcherFunc(func(r *http.Request) bool {
-		return r.URL.Query().Get(key) == value
+		return options.parse(r.URL.RawQuery).Has(key)
 	})
 
-	return MatchRequest(matcher)
+	return describedRule(matcher, "query param %s missing", key)
+}
+
+// MatchRawQuery sets a rule to match the http request with exactly the given raw query string (without "?").
+// The query is compared byte-for-byte, so the encoding and the order of the params must be the same.
+//
+// Example:
+//
+//	MatchRawQuery("a=1&b=two%20words")
+func MatchRawQuery(rawQuery string) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		return r.URL.RawQuery == rawQuery
+	})
+
+	return describedRule(matcher, "query string mismatch")
 }
 
 // MatchParam sets a rule to match the http request with the given path param value.
 // This needs that the URL must be specified with URLPattern.
 func MatchParam(key, value string) StubMatcherRule {
-	matcher := requestMatcherFunc(func(st *stub, r *http.Request) bool {
-		return st.patternParams[key] == value
-	})
+	matcher := describedMatcher(func(state *matchState, r *http.Request) bool {
+		return state.params[key] == value
+	}, "param %s mismatch", key)
 
 	return func() requestMatcherFunc { return matcher }
 }
 
+type paramsContextKey struct{}
+
+// ParamsFromRequest returns the path params captured by the URLPattern, PathPattern or MuxPattern of the stub
+// which matched the request, e.g. to echo them in a RespondWith or WithBodyReaderFunc response. They are also
+// available with r.PathValue. It returns nil if there are no params.
+func ParamsFromRequest(r *http.Request) map[string]string {
+	params, _ := r.Context().Value(paramsContextKey{}).(map[string]string)
+	return maps.Clone(params)
+}
+
 // MatchNoBody sets a rule to match the http request with empty body.
 func MatchNoBody() StubMatcherRule {
 	matcher := RequestMatcherFunc(func(r *http.Request) bool {
-		realReqBody := mustReadBody(r)
+		realReqBody := mustReadDecodedBody(r)
 		return len(realReqBody) == 0
 	})
 
-	return MatchRequest(matcher)
+	return describedRule(matcher, "body present")
 }
 
 // MatchRawJSONBody sets a rule to match the http request with the given raw JSON body.
@@ -197,6 +646,7 @@ func MatchRawJSONBody[T string | []byte | json.RawMessage](raw T) StubMatcherRul
 
 // MatchJSONBody sets a rule to match the http request with the given JSON body.
 // The specified body will be marshaled and compared with the real body.
+// As for the other body matchers, bodies sent compressed (see the Content-Encoding header) are decompressed.
 func MatchJSONBody(body any) StubMatcherRule {
 	data, err := json.Marshal(body)
 	if err != nil {
@@ -204,26 +654,67 @@ func MatchJSONBody(body any) StubMatcherRule {
 	}
 
 	matcher := RequestMatcherFunc(func(r *http.Request) bool {
-		reqBody := mustReadBody(r)
+		reqBody := mustReadDecodedBody(r)
 
 		equals, equalsErr := equalJSON(reqBody, data)
-		if equalsErr != nil {
-			panic(fmt.Errorf("MatchJSONBody err: equals failed: %w", equalsErr))
+		if equalsErr != nil { // the request body is not valid JSON
+			return false
+		}
+
+		if !equals {
+			reportJSONBodyDiff(r, data, reqBody)
 		}
 
 		return equals
 	})
 
-	return MatchRequest(matcher)
+	return describedRule(matcher, "body mismatch")
+}
+
+// BodyValidator validates the struct tags of a value, e.g. *validator.Validate of github.com/go-playground/validator.
+type BodyValidator interface {
+	Struct(any) error
+}
+
+// MatchValidBody sets a rule to match the http request with a JSON body that can be unmarshaled into T and that
+// passes the validation. The body is validated by its Validate() error method, if T implements it, and by the
+// given validators, which run the validate struct tags.
+//
+// Example:
+//
+//	MatchValidBody[CreateUserRequest](validator.New())
+func MatchValidBody[T any](validators ...BodyValidator) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		var body T
+
+		if err := json.Unmarshal(mustReadDecodedBody(r), &body); err != nil {
+			return false
+		}
+
+		if v, ok := any(&body).(interface{ Validate() error }); ok && v.Validate() != nil {
+			return false
+		}
+
+		for _, validator := range validators {
+			if validator.Struct(body) != nil {
+				return false
+			}
+		}
+
+		return true
+	})
+
+	return describedRule(matcher, "body validation failed")
 }
 
 type BodyMatcherMapFunc func(map[string]any) bool
 
 // MatchBodyMapFunc sets a rule to match the http request with the given matcher based on the body as a map.
 // The matcher is a func that receives the body parameters as a map. If the body is empty the map will be empty.
+// If the body is not a JSON object the request does not match.
 func MatchBodyMapFunc(bodyMatcher BodyMatcherMapFunc) StubMatcherRule {
 	matcher := RequestMatcherFunc(func(r *http.Request) bool {
-		reqBody := mustReadBody(r)
+		reqBody := mustReadDecodedBody(r)
 
 		if len(reqBody) == 0 { // empty body
 			return bodyMatcher(make(map[string]any)) // empty map
@@ -232,13 +723,13 @@ func MatchBodyMapFunc(bodyMatcher BodyMatcherMapFunc) StubMatcherRule {
 		var bodyMap map[string]any
 
 		if err := json.Unmarshal(reqBody, &bodyMap); err != nil {
-			panic(fmt.Errorf("MatchBodyMapFunc err: unmarshal body failed: %w", err))
+			return false
 		}
 
 		return bodyMatcher(bodyMap)
 	})
 
-	return MatchRequest(matcher)
+	return describedRule(matcher, "body mismatch")
 }
 
 type BodyMatcherStringFunc func(string) bool
@@ -247,34 +738,157 @@ type BodyMatcherStringFunc func(string) bool
 // The matcher is a func that receives the body as plain text.
 func MatchBodyStringFunc(bodyMatcher BodyMatcherStringFunc) StubMatcherRule {
 	matcher := RequestMatcherFunc(func(r *http.Request) bool {
-		reqBody := mustReadBody(r)
+		reqBody := mustReadDecodedBody(r)
 		return bodyMatcher(string(reqBody))
 	})
 
-	return MatchRequest(matcher)
+	return describedRule(matcher, "body mismatch")
+}
+
+// describedRule returns a rule as MatchRequest, which describes why the matcher rejected a request when the match
+// is traced (see WithMatchTracing).
+func describedRule(requestMatcher RequestMatcherFunc, format string, args ...any) StubMatcherRule {
+	matcher := describedMatcher(func(_ *matchState, r *http.Request) bool {
+		return requestMatcher(r)
+	}, format, args...)
+
+	return func() requestMatcherFunc { return matcher }
 }
 
 // MatchRequest sets a rule to match the http request given a custom matcher.
 func MatchRequest(requestMatcher RequestMatcherFunc) StubMatcherRule {
-	matcher := requestMatcherFunc(func(_ *stub, r *http.Request) bool {
+	matcher := requestMatcherFunc(func(_ *matchState, r *http.Request) bool {
 		return requestMatcher(r)
 	})
 
 	return func() requestMatcherFunc { return matcher }
 }
 
+// Not sets a rule to match the http request when the given rule does not match.
+//
+// Example:
+//
+//	Match(AnyOf(AllOf(MatchJSONPath("type", "X"), Not(MatchHeaderPresent("Y"))), MatchQueryPresent("Z")))
+func Not(rule StubMatcherRule) StubMatcherRule {
+	ruleMatcher := rule()
+
+	matcher := requestMatcherFunc(func(state *matchState, r *http.Request) bool {
+		return !ruleMatcher(state, r)
+	})
+
+	return func() requestMatcherFunc { return matcher }
+}
+
+// AnyOf sets a rule to match the http request when at least one of the given rules matches.
+// The rules are evaluated in order until one matches.
+func AnyOf(rules ...StubMatcherRule) StubMatcherRule {
+	if len(rules) == 0 {
+		panic(fmt.Errorf("AnyOf err: at least one rule is required"))
+	}
+
+	matchers := ruleMatchers(rules)
+
+	matcher := requestMatcherFunc(func(state *matchState, r *http.Request) bool {
+		return slices.ContainsFunc(matchers, func(m requestMatcherFunc) bool { return m(state, r) })
+	})
+
+	return func() requestMatcherFunc { return matcher }
+}
+
+// AllOf sets a rule to match the http request when all the given rules match, e.g. to group rules inside AnyOf.
+// The rules are evaluated in order until one does not match.
+func AllOf(rules ...StubMatcherRule) StubMatcherRule {
+	if len(rules) == 0 {
+		panic(fmt.Errorf("AllOf err: at least one rule is required"))
+	}
+
+	matchers := ruleMatchers(rules)
+
+	matcher := requestMatcherFunc(func(state *matchState, r *http.Request) bool {
+		return !slices.ContainsFunc(matchers, func(m requestMatcherFunc) bool { return !m(state, r) })
+	})
+
+	return func() requestMatcherFunc { return matcher }
+}
+
+func ruleMatchers(rules []StubMatcherRule) []requestMatcherFunc {
+	matchers := make([]requestMatcherFunc, 0, len(rules))
+	for _, rule := range rules {
+		matchers = append(matchers, rule())
+	}
+
+	return matchers
+}
+
+type bodyCacheKey struct{}
+
+// bodyCache holds the body of a request received by the server, so it is read once and shared by the matchers of
+// all the evaluated stubs. The body is read on demand, e.g. to not send the 100 Continue until it is needed.
+type bodyCache struct {
+	once sync.Once
+	read atomic.Bool // set once the body was read, e.g. to know whether the 100 Continue was sent
+	data []byte
+	err  error
+
+	decodeOnce sync.Once
+	decoded    []byte // the data decompressed, see mustReadDecodedBody
+}
+
+// withBodyCache returns a copy of the request whose body is read once by mustReadBody.
+func withBodyCache(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), bodyCacheKey{}, new(bodyCache)))
+}
+
+// mustReadBody reads the request body and restores it, so it can be read again. The returned data must not be
+// modified, since it is shared when the request has a body cache (see withBodyCache).
 func mustReadBody(r *http.Request) []byte {
-	buff := new(bytes.Buffer)
-	tee := io.TeeReader(r.Body, buff)
+	cache := requestBodyCache(r)
 
-	data, err := io.ReadAll(tee)
-	if err != nil {
-		panic(fmt.Errorf("read request body failed: %w", err))
+	cache.once.Do(func() {
+		cache.data, cache.err = io.ReadAll(r.Body)
+		cache.read.Store(true)
+	})
+
+	if cache.err != nil {
+		panic(fmt.Errorf("read request body failed: %w", cache.err))
 	}
 
-	r.Body = io.NopCloser(buff)
+	r.Body = io.NopCloser(bytes.NewReader(cache.data))
+
+	return cache.data
+}
+
+// mustReadDecodedBody reads the request body as mustReadBody does, decompressed according to the Content-Encoding
+// header (gzip, deflate, br or zstd), so the body matchers can be used with compressed uploads. The body is returned
+// as it was sent if it can not be decompressed. The request body itself is not decompressed.
+func mustReadDecodedBody(r *http.Request) []byte {
+	data := mustReadBody(r)
+
+	encoding := r.Header.Get("Content-Encoding")
+	if encoding == "" {
+		return data
+	}
+
+	cache := requestBodyCache(r)
+
+	cache.decodeOnce.Do(func() {
+		cache.decoded = data
+
+		if decoded, err := decodeContent(encoding, data); err == nil {
+			cache.decoded = decoded
+		}
+	})
 
-	return data
+	return cache.decoded
+}
+
+// requestBodyCache returns the body cache of the request, or a new one if it has none.
+func requestBodyCache(r *http.Request) *bodyCache {
+	if cache, ok := r.Context().Value(bodyCacheKey{}).(*bodyCache); ok {
+		return cache
+	}
+
+	return new(bodyCache)
 }
 
 func equalJSON(v1, v2 []byte) (bool, error) {
@@ -294,3 +908,31 @@ func equalJSON(v1, v2 []byte) (bool, error) {
 
 	return reflect.DeepEqual(json1, json2), nil
 }
+
+// NewStub returns a stub which is not registered in any server, to be evaluated with Match.
+func NewStub(method string, url URLMatcher) Stub {
+	return newStub(realClock{}, defaultMatchers(method, url))
+}
+
+// Match returns the first of the given stubs which matches the request, or nil if none of them matches.
+// As in the server, stubs with a more specific MuxPattern take precedence.
+// Only the stub matchers are evaluated, without a server nor network, so it is suitable for fuzz tests.
+// Stubs can be created with NewStub or Server.Stub.
+func Match(stubs []Stub, r *http.Request) Stub {
+	internal := make([]*stub, 0, len(stubs))
+
+	for _, st := range stubs {
+		if s, ok := st.(*stub); ok {
+			internal = append(internal, s)
+		}
+	}
+
+	for i, st := range internal {
+		if state, ok := st.evaluate(r); ok {
+			preferred, _ := preferredStub(st, state, internal[i+1:], r)
+			return preferred
+		}
+	}
+
+	return nil
+}
@@ -1,8 +1,13 @@
 package mockaso_test
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -90,6 +95,104 @@ func TestPath(t *testing.T) {
 	})
 }
 
+func TestURL_Encoding(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		reqURL        string
+		matchURL      string
+		opts          []mockaso.URLOption
+		expectedMatch bool
+	}{
+		"should return true when url is sent with the same encoding (exact encoding)": {
+			reqURL:        "/api/files/a|b?name=two%20words",
+			matchURL:      "/api/files/a|b?name=two%20words",
+			opts:          []mockaso.URLOption{mockaso.ExactEncoding()},
+			expectedMatch: true,
+		},
+		"should return false when url is re-serialized (exact encoding)": {
+			reqURL:        "/api/files/a|b",
+			matchURL:      "/api/files/a%7Cb",
+			opts:          []mockaso.URLOption{mockaso.ExactEncoding()},
+			expectedMatch: false,
+		},
+		"should return false when slash is encoded (exact encoding)": {
+			reqURL:        "/api/files/a%2Fb",
+			matchURL:      "/api/files/a/b",
+			opts:          []mockaso.URLOption{mockaso.ExactEncoding()},
+			expectedMatch: false,
+		},
+		"should return true when slash is encoded (ignore encoding)": {
+			reqURL:        "/api/files/a%2Fb",
+			matchURL:      "/api/files/a/b",
+			opts:          []mockaso.URLOption{mockaso.IgnoreEncoding()},
+			expectedMatch: true,
+		},
+		"should return true when space is encoded as plus (ignore encoding)": {
+			reqURL:        "/api/files?name=two+words",
+			matchURL:      "/api/files?name=two%20words",
+			opts:          []mockaso.URLOption{mockaso.IgnoreEncoding()},
+			expectedMatch: true,
+		},
+		"should return false when space is encoded as plus (default)": {
+			reqURL:        "/api/files?name=two+words",
+			matchURL:      "/api/files?name=two%20words",
+			expectedMatch: false,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(http.MethodGet, tc.reqURL, http.NoBody)
+			matcher := mockaso.URL(tc.matchURL, tc.opts...)
+			assert.Equal(t, tc.expectedMatch, matcher(httpReq.URL, nil))
+		})
+	}
+}
+
+func TestPath_Encoding(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		matchPath     string
+		opts          []mockaso.URLOption
+		expectedMatch bool
+	}{
+		"should return true when slash is encoded (default)": {
+			matchPath:     "/api/files/a/b",
+			expectedMatch: true,
+		},
+		"should return false when slash is encoded (exact encoding)": {
+			matchPath:     "/api/files/a/b",
+			opts:          []mockaso.URLOption{mockaso.ExactEncoding()},
+			expectedMatch: false,
+		},
+		"should return true when path is sent with the same encoding (exact encoding)": {
+			matchPath:     "/api/files/a%2Fb",
+			opts:          []mockaso.URLOption{mockaso.ExactEncoding()},
+			expectedMatch: true,
+		},
+		"should return true when expected path is encoded (ignore encoding)": {
+			matchPath:     "/api/files/a%2fb",
+			opts:          []mockaso.URLOption{mockaso.IgnoreEncoding()},
+			expectedMatch: true,
+		},
+	}
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/api/files/a%2Fb", http.NoBody)
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			matcher := mockaso.Path(tc.matchPath, tc.opts...)
+			assert.Equal(t, tc.expectedMatch, matcher(httpReq.URL, nil))
+		})
+	}
+}
+
 func TestURLRegex(t *testing.T) {
 	t.Parallel()
 
@@ -142,6 +245,74 @@ func TestPathPattern(t *testing.T) {
 	})
 }
 
+func TestPathPattern_TypedParams(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		pattern        string
+		path           string
+		expectedParams map[string]string
+		expectedMatch  bool
+	}{
+		"int param should match digits": {
+			pattern:        "/api/users/{id:int}",
+			path:           "/api/users/123",
+			expectedParams: map[string]string{"id": "123"},
+			expectedMatch:  true,
+		},
+		"int param should not match letters": {
+			pattern: "/api/users/{id:int}",
+			path:    "/api/users/john",
+		},
+		"regex param should match the regex": {
+			pattern:        "/api/posts/{slug:[a-z-]+}",
+			path:           "/api/posts/hello-world",
+			expectedParams: map[string]string{"slug": "hello-world"},
+			expectedMatch:  true,
+		},
+		"regex param should not match other values": {
+			pattern: "/api/posts/{slug:[a-z-]+}",
+			path:    "/api/posts/Hello_World",
+		},
+		"regex param can have braces": {
+			pattern:        "/api/codes/{code:[A-Z]{3}}",
+			path:           "/api/codes/ABC",
+			expectedParams: map[string]string{"code": "ABC"},
+			expectedMatch:  true,
+		},
+		"regex param can have groups": {
+			pattern:        "/api/{kind:(users|orders)}/{id}",
+			path:           "/api/orders/7",
+			expectedParams: map[string]string{"kind": "orders", "id": "7"},
+			expectedMatch:  true,
+		},
+		"wildcard param should match the tail": {
+			pattern:        "/api/users/{id:int}/files/{path:*}",
+			path:           "/api/users/1/files/docs/2024/report.pdf",
+			expectedParams: map[string]string{"id": "1", "path": "docs/2024/report.pdf"},
+			expectedMatch:  true,
+		},
+		"untyped param should not match a slash": {
+			pattern: "/api/files/{path}",
+			path:    "/api/files/docs/report.pdf",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			st := mockaso.NewStub(http.MethodGet, mockaso.PathPattern(tc.pattern))
+			for key, value := range tc.expectedParams {
+				st.Match(mockaso.MatchParam(key, value))
+			}
+
+			httpReq := httptest.NewRequest(http.MethodGet, tc.path, http.NoBody)
+			assert.Equal(t, tc.expectedMatch, mockaso.Match([]mockaso.Stub{st}, httpReq) != nil)
+		})
+	}
+}
+
 func TestMatchRequest(t *testing.T) {
 	t.Parallel()
 
@@ -186,80 +357,705 @@ func TestMatchRequest(t *testing.T) {
 		httpResp, err := server.Client().Do(httpReq)
 		require.NoError(t, err)
 
-		assertNotMatchedResponse(t, httpReq, httpResp)
-	})
+		assertNotMatchedResponse(t, httpReq, httpResp)
+	})
+}
+
+func TestMatchHeader(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const path = "/test/match-header"
+
+	server.Stub(http.MethodGet, mockaso.Path(path)).
+		Match(mockaso.MatchHeader("X-Test-Header", "test value")).
+		Respond(matchedRequestRules()...)
+
+	t.Run("should return the specified stub when header match", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, _ := http.NewRequest(http.MethodGet, path, http.NoBody)
+		httpReq.Header.Set("X-Test-Header", "test value")
+
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assertBodyString(t, "matched request", httpResp)
+	})
+
+	t.Run("should return no match response when header does not match", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, _ := http.NewRequest(http.MethodGet, path, http.NoBody)
+		httpReq.Header.Set("X-Test-Header", "another test value")
+
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assertNotMatchedResponse(t, httpReq, httpResp)
+	})
+}
+
+func TestMatchHeaderExact(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const path = "/test/match-header-exact"
+
+	server.Stub(http.MethodPost, mockaso.Path(path)).
+		Match(mockaso.MatchHeaderExact("x-api-KEY", "secret")).
+		Respond(matchedRequestRules()...)
+
+	testCases := map[string]struct {
+		name          string
+		expectedMatch bool
+	}{
+		"should return the specified stub when header name has the same case": {
+			name:          "x-api-KEY",
+			expectedMatch: true,
+		},
+		"should return no match response when header name is canonical": {
+			name: "X-Api-Key",
+		},
+		"should return no match response when header name is lowercase": {
+			name: "x-api-key",
+		},
+	}
+
+	client := server.Client() // the requests share the connection
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			httpReq, _ := http.NewRequest(http.MethodPost, path, strings.NewReader("body with x-api-KEY: secret"))
+			httpReq.Header[tc.name] = []string{"secret"}
+
+			httpResp, err := client.Do(httpReq)
+			require.NoError(t, err)
+
+			if tc.expectedMatch {
+				assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+				assertBodyString(t, "matched request", httpResp)
+			} else {
+				assertNotMatchedResponse(t, httpReq, httpResp)
+			}
+		})
+	}
+}
+
+func TestMatchHeaderValues(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const path = "/test/match-header-values"
+
+	server.Stub(http.MethodGet, mockaso.Path(path)).
+		Match(mockaso.MatchHeaderValues("X-Forwarded-For", "10.0.0.1", "10.0.0.2")).
+		Respond(matchedRequestRules()...)
+
+	testCases := map[string]struct {
+		values        []string
+		expectedMatch bool
+	}{
+		"should return the specified stub when values are in the same order": {
+			values:        []string{"10.0.0.1", "10.0.0.2"},
+			expectedMatch: true,
+		},
+		"should return no match response when values are in another order": {
+			values: []string{"10.0.0.2", "10.0.0.1"},
+		},
+		"should return no match response when there are more values": {
+			values: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq, _ := http.NewRequest(http.MethodGet, path, http.NoBody)
+			for _, value := range tc.values {
+				httpReq.Header.Add("X-Forwarded-For", value)
+			}
+
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+
+			if tc.expectedMatch {
+				assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+				assertBodyString(t, "matched request", httpResp)
+			} else {
+				assertNotMatchedResponse(t, httpReq, httpResp)
+			}
+		})
+	}
+}
+
+func TestMatchHeaderRegexAndPresence(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		rule     mockaso.StubMatcherRule
+		headers  map[string][]string
+		expected bool
+	}{
+		"regex should match the header value": {
+			rule:     mockaso.MatchHeaderRegex("Authorization", "^Bearer .+$"),
+			headers:  map[string][]string{"Authorization": {"Bearer abc123"}},
+			expected: true,
+		},
+		"regex should match any value of a repeated header": {
+			rule:     mockaso.MatchHeaderRegex("Accept", "json"),
+			headers:  map[string][]string{"Accept": {"text/plain", "application/json"}},
+			expected: true,
+		},
+		"regex should not match another value": {
+			rule:    mockaso.MatchHeaderRegex("Authorization", "^Bearer .+$"),
+			headers: map[string][]string{"Authorization": {"Basic am9objpzZWNyZXQ="}},
+		},
+		"regex should not match a missing header": {
+			rule: mockaso.MatchHeaderRegex("Authorization", ".*"),
+		},
+		"present should match a header": {
+			rule:     mockaso.MatchHeaderPresent("traceparent"),
+			headers:  map[string][]string{"Traceparent": {"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}},
+			expected: true,
+		},
+		"present should match an empty header": {
+			rule:     mockaso.MatchHeaderPresent("X-Empty"),
+			headers:  map[string][]string{"X-Empty": {""}},
+			expected: true,
+		},
+		"present should not match a missing header": {
+			rule: mockaso.MatchHeaderPresent("Traceparent"),
+		},
+		"absent should match a missing header": {
+			rule:     mockaso.MatchHeaderAbsent("Authorization"),
+			headers:  map[string][]string{"X-Other": {"value"}},
+			expected: true,
+		},
+		"absent should not match a header": {
+			rule:    mockaso.MatchHeaderAbsent("Authorization"),
+			headers: map[string][]string{"Authorization": {"Bearer abc123"}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(http.MethodGet, "/test/match-header-regex", http.NoBody)
+			for key, values := range tc.headers {
+				for _, value := range values {
+					httpReq.Header.Add(key, value)
+				}
+			}
+
+			assert.Equal(t, tc.expected, stubMatches(httpReq, tc.rule))
+		})
+	}
+
+	assert.Panics(t, func() { mockaso.MatchHeaderRegex("Authorization", "[a-") })
+}
+
+func TestMatchContentType(t *testing.T) {
+	t.Parallel()
+
+	rule := mockaso.MatchContentType("application/json")
+
+	testCases := map[string]struct {
+		contentType string
+		expected    bool
+	}{
+		"should match the media type": {
+			contentType: "application/json",
+			expected:    true,
+		},
+		"should match the media type with parameters": {
+			contentType: "application/json; charset=utf-8",
+			expected:    true,
+		},
+		"should match the media type in another case": {
+			contentType: "Application/JSON;charset=UTF-8",
+			expected:    true,
+		},
+		"should not match another media type": {
+			contentType: "application/problem+json",
+		},
+		"should not match an invalid content type": {
+			contentType: "application/json;;",
+		},
+		"should not match a missing content type": {},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(http.MethodPost, "/test/match-content-type", strings.NewReader(`{}`))
+			if tc.contentType != "" {
+				httpReq.Header.Set("Content-Type", tc.contentType)
+			}
+
+			assert.Equal(t, tc.expected, stubMatches(httpReq, rule))
+		})
+	}
+
+	assert.PanicsWithError(t, "MatchContentType err: invalid media type: mime: no media type",
+		func() { mockaso.MatchContentType("") })
+}
+
+func TestMatchHostAndScheme(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		rule     mockaso.StubMatcherRule
+		url      string
+		header   map[string]string
+		expected bool
+	}{
+		"host should match the host": {
+			rule:     mockaso.MatchHost("api.example.com"),
+			url:      "http://api.example.com/test",
+			expected: true,
+		},
+		"host should match the host ignoring the port and case": {
+			rule:     mockaso.MatchHost("api.example.com"),
+			url:      "http://API.example.com:8080/test",
+			expected: true,
+		},
+		"host should match the host and port": {
+			rule:     mockaso.MatchHost("api.example.com:8080"),
+			url:      "http://api.example.com:8080/test",
+			expected: true,
+		},
+		"host should not match another port": {
+			rule: mockaso.MatchHost("api.example.com:8080"),
+			url:  "http://api.example.com:9090/test",
+		},
+		"host should not match another host": {
+			rule: mockaso.MatchHost("api.example.com"),
+			url:  "http://auth.example.com/test",
+		},
+		"scheme should match a tls request": {
+			rule:     mockaso.MatchScheme("https"),
+			url:      "https://api.example.com/test",
+			expected: true,
+		},
+		"scheme should match a plain request": {
+			rule:     mockaso.MatchScheme("http"),
+			url:      "http://api.example.com/test",
+			expected: true,
+		},
+		"scheme should match the forwarded proto": {
+			rule:     mockaso.MatchScheme("https"),
+			url:      "http://api.example.com/test",
+			header:   map[string]string{"X-Forwarded-Proto": "https"},
+			expected: true,
+		},
+		"scheme should not match another scheme": {
+			rule: mockaso.MatchScheme("https"),
+			url:  "http://api.example.com/test",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(http.MethodGet, tc.url, http.NoBody)
+			for key, value := range tc.header {
+				httpReq.Header.Set(key, value)
+			}
+
+			assert.Equal(t, tc.expected, stubMatches(httpReq, tc.rule))
+		})
+	}
+}
+
+func TestMatchTrailer(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const path = "/test/match-trailer"
+
+	server.Stub(http.MethodPost, mockaso.Path(path)).
+		Match(
+			mockaso.MatchTrailer("X-Checksum", "abc123"),
+			mockaso.MatchBodyStringFunc(func(body string) bool { return body == "chunked body" }),
+		).
+		Respond(matchedRequestRules()...)
+
+	newChunkedRequest := func(checksum string) *http.Request {
+		body := io.MultiReader(strings.NewReader("chunked "), strings.NewReader("body"))
+		httpReq, _ := http.NewRequest(http.MethodPost, path, body)
+		httpReq.ContentLength = -1 // unknown length, so it is sent chunked with trailers
+		httpReq.Trailer = http.Header{"X-Checksum": []string{checksum}}
+
+		return httpReq
+	}
+
+	t.Run("should return the specified stub when trailer match", func(t *testing.T) {
+		t.Parallel()
+
+		httpResp, err := server.Client().Do(newChunkedRequest("abc123"))
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assertBodyString(t, "matched request", httpResp)
+	})
+
+	t.Run("should return no match response when trailer does not match", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq := newChunkedRequest("xyz789")
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assertNotMatchedResponse(t, httpReq, httpResp)
+	})
+}
+
+func TestMatchChunkedRequest(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const path = "/test/match-chunked"
+
+	server.Stub(http.MethodPost, mockaso.Path(path)).
+		Match(
+			mockaso.MatchChunkedRequest(),
+			mockaso.MatchRawJSONBody(`{"name":"john","age":57}`),
+		).
+		Respond(matchedRequestRules()...)
+
+	t.Run("should return the specified stub when request is chunked", func(t *testing.T) {
+		t.Parallel()
+
+		body := io.MultiReader(strings.NewReader(`{"name":"john",`), strings.NewReader(`"age":57}`))
+		httpReq, _ := http.NewRequest(http.MethodPost, path, body)
+		require.EqualValues(t, 0, httpReq.ContentLength) // unknown length
+
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assertBodyString(t, "matched request", httpResp)
+	})
+
+	t.Run("should return no match response when request is not chunked", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(`{"name":"john","age":57}`))
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assertNotMatchedResponse(t, httpReq, httpResp)
+	})
+}
+
+func TestMatchHTTPProto(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const path = "/test/match-http-proto"
+
+	server.Stub(http.MethodGet, mockaso.Path(path+"/1.1")).
+		Match(mockaso.MatchHTTPProto("HTTP/1.1")).
+		Respond(matchedRequestRules()...)
+
+	server.Stub(http.MethodGet, mockaso.Path(path+"/2.0")).
+		Match(mockaso.MatchHTTPProto("HTTP/2.0")).
+		Respond(matchedRequestRules()...)
+
+	t.Run("should return the specified stub when protocol match", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, _ := http.NewRequest(http.MethodGet, path+"/1.1", http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assertBodyString(t, "matched request", httpResp)
+	})
+
+	t.Run("should return no match response when protocol does not match", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, _ := http.NewRequest(http.MethodGet, path+"/2.0", http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assertNotMatchedResponse(t, httpReq, httpResp)
+	})
+}
+
+func TestMatchQuery(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const path = "/test/match-query"
+
+	server.Stub(http.MethodGet, mockaso.Path(path)).
+		Match(mockaso.MatchQuery("name", "john")).
+		Respond(matchedRequestRules()...)
+
+	t.Run("should return the specified stub when query match", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, _ := http.NewRequest(http.MethodGet, path+"?name=john", http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assertBodyString(t, "matched request", httpResp)
+	})
+
+	t.Run("should return no match response when query does not match", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, _ := http.NewRequest(http.MethodGet, path+"?name=rick", http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assertNotMatchedResponse(t, httpReq, httpResp)
+	})
+}
+
+func TestMatchQuery_Options(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		query         string
+		opts          []mockaso.QueryOption
+		expectedMatch bool
+	}{
+		"should return false when params are separated by semicolon (default)": {
+			query:         "page=1;name=john",
+			expectedMatch: false,
+		},
+		"should return true when params are separated by semicolon (semicolon separator)": {
+			query:         "page=1;name=john",
+			opts:          []mockaso.QueryOption{mockaso.SemicolonSeparator()},
+			expectedMatch: true,
+		},
+		"should return true when first value match (default)": {
+			query:         "name=john&name=rick",
+			expectedMatch: true,
+		},
+		"should return false when only last value match (default)": {
+			query:         "name=rick&name=john",
+			expectedMatch: false,
+		},
+		"should return true when last value match (duplicate last)": {
+			query:         "name=rick&name=john",
+			opts:          []mockaso.QueryOption{mockaso.DuplicateKeys(mockaso.DuplicateLast)},
+			expectedMatch: true,
+		},
+		"should return true when any value match (duplicate any)": {
+			query:         "name=rick&name=john&name=morty",
+			opts:          []mockaso.QueryOption{mockaso.DuplicateKeys(mockaso.DuplicateAny)},
+			expectedMatch: true,
+		},
+		"should return false when key is duplicated (duplicate reject)": {
+			query:         "name=john&name=john",
+			opts:          []mockaso.QueryOption{mockaso.DuplicateKeys(mockaso.DuplicateReject)},
+			expectedMatch: false,
+		},
+		"should return true when key is not duplicated (duplicate reject)": {
+			query:         "name=john",
+			opts:          []mockaso.QueryOption{mockaso.DuplicateKeys(mockaso.DuplicateReject)},
+			expectedMatch: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(http.MethodGet, "/api/users?"+tc.query, http.NoBody)
+			matcher := mockaso.MatchQuery("name", "john", tc.opts...)()
+			assert.Equal(t, tc.expectedMatch, matcher(nil, httpReq))
+		})
+	}
 }
 
-func TestMatchHeader(t *testing.T) {
+func TestMatchQueryParamsValuesAndPresence(t *testing.T) {
 	t.Parallel()
 
-	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
-	t.Cleanup(server.MustShutdown)
-
-	const path = "/test/match-header"
-
-	server.Stub(http.MethodGet, mockaso.Path(path)).
-		Match(mockaso.MatchHeader("X-Test-Header", "test value")).
-		Respond(matchedRequestRules()...)
+	testCases := map[string]struct {
+		rule     mockaso.StubMatcherRule
+		query    string
+		expected bool
+	}{
+		"params should match all the params": {
+			rule:     mockaso.MatchQueryParams(map[string]string{"name": "john", "page": "1"}),
+			query:    "page=1&name=john&sort=asc",
+			expected: true,
+		},
+		"params should not match when a param differs": {
+			rule:  mockaso.MatchQueryParams(map[string]string{"name": "john", "page": "1"}),
+			query: "page=2&name=john",
+		},
+		"params should not match when a param is missing": {
+			rule:  mockaso.MatchQueryParams(map[string]string{"name": "john", "page": "1"}),
+			query: "name=john",
+		},
+		"params should apply the options": {
+			rule: mockaso.MatchQueryParams(map[string]string{"name": "john"},
+				mockaso.DuplicateKeys(mockaso.DuplicateLast)),
+			query:    "name=rick&name=john",
+			expected: true,
+		},
+		"values should match the repeated values": {
+			rule:     mockaso.MatchQueryValues("id", []string{"1", "2"}),
+			query:    "id=1&page=1&id=2",
+			expected: true,
+		},
+		"values should not match another order": {
+			rule:  mockaso.MatchQueryValues("id", []string{"1", "2"}),
+			query: "id=2&id=1",
+		},
+		"values should not match fewer values": {
+			rule:  mockaso.MatchQueryValues("id", []string{"1", "2"}),
+			query: "id=1",
+		},
+		"present should match a param": {
+			rule:     mockaso.MatchQueryPresent("debug"),
+			query:    "debug=true",
+			expected: true,
+		},
+		"present should match a param without value": {
+			rule:     mockaso.MatchQueryPresent("debug"),
+			query:    "page=1&debug",
+			expected: true,
+		},
+		"present should not match a missing param": {
+			rule:  mockaso.MatchQueryPresent("debug"),
+			query: "page=1",
+		},
+	}
 
-	t.Run("should return the specified stub when header match", func(t *testing.T) {
-		t.Parallel()
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
 
-		httpReq, _ := http.NewRequest(http.MethodGet, path, http.NoBody)
-		httpReq.Header.Set("X-Test-Header", "test value")
+			httpReq := httptest.NewRequest(http.MethodGet, "/test/match-query-params?"+tc.query, http.NoBody)
+			assert.Equal(t, tc.expected, stubMatches(httpReq, tc.rule))
+		})
+	}
+}
 
-		httpResp, err := server.Client().Do(httpReq)
-		require.NoError(t, err)
+func TestMatchQueryRegexAndFunc(t *testing.T) {
+	t.Parallel()
 
-		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
-		assertBodyString(t, "matched request", httpResp)
-	})
+	isNumber := func(v string) bool { _, err := strconv.Atoi(v); return err == nil }
 
-	t.Run("should return no match response when header does not match", func(t *testing.T) {
-		t.Parallel()
+	testCases := map[string]struct {
+		rule     mockaso.StubMatcherRule
+		query    string
+		expected bool
+	}{
+		"regex should match the value": {
+			rule:     mockaso.MatchQueryRegex("cursor", "^[a-f0-9]{8}$"),
+			query:    "cursor=0af76519",
+			expected: true,
+		},
+		"regex should not match another value": {
+			rule:  mockaso.MatchQueryRegex("cursor", "^[a-f0-9]{8}$"),
+			query: "cursor=next",
+		},
+		"regex should not match a missing param": {
+			rule:  mockaso.MatchQueryRegex("cursor", "^[a-f0-9]{8}$"),
+			query: "page=1",
+		},
+		"regex should apply the options": {
+			rule:     mockaso.MatchQueryRegex("cursor", "^[a-f0-9]{8}$", mockaso.DuplicateKeys(mockaso.DuplicateAny)),
+			query:    "cursor=next&cursor=0af76519",
+			expected: true,
+		},
+		"func should match a value satisfying the predicate": {
+			rule:     mockaso.MatchQueryFunc("since", isNumber),
+			query:    "since=1700000000",
+			expected: true,
+		},
+		"func should not match a value not satisfying the predicate": {
+			rule:  mockaso.MatchQueryFunc("since", isNumber),
+			query: "since=yesterday",
+		},
+	}
 
-		httpReq, _ := http.NewRequest(http.MethodGet, path, http.NoBody)
-		httpReq.Header.Set("X-Test-Header", "another test value")
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
 
-		httpResp, err := server.Client().Do(httpReq)
-		require.NoError(t, err)
+			httpReq := httptest.NewRequest(http.MethodGet, "/test/match-query-regex?"+tc.query, http.NoBody)
+			assert.Equal(t, tc.expected, stubMatches(httpReq, tc.rule))
+		})
+	}
 
-		assertNotMatchedResponse(t, httpReq, httpResp)
-	})
+	assert.Panics(t, func() { mockaso.MatchQueryRegex("cursor", "[a-") })
 }
 
-func TestMatchQuery(t *testing.T) {
+func TestMatchRawQuery(t *testing.T) {
 	t.Parallel()
 
 	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
 	t.Cleanup(server.MustShutdown)
 
-	const path = "/test/match-query"
+	const path = "/test/match-raw-query"
 
 	server.Stub(http.MethodGet, mockaso.Path(path)).
-		Match(mockaso.MatchQuery("name", "john")).
+		Match(mockaso.MatchRawQuery("a=1&b=two%20words")).
 		Respond(matchedRequestRules()...)
 
-	t.Run("should return the specified stub when query match", func(t *testing.T) {
-		t.Parallel()
-
-		httpReq, _ := http.NewRequest(http.MethodGet, path+"?name=john", http.NoBody)
-		httpResp, err := server.Client().Do(httpReq)
-		require.NoError(t, err)
+	testCases := map[string]struct {
+		query         string
+		expectedMatch bool
+	}{
+		"should return the specified stub when raw query is the same": {
+			query:         "a=1&b=two%20words",
+			expectedMatch: true,
+		},
+		"should return no match response when params are in another order": {
+			query: "b=two%20words&a=1",
+		},
+		"should return no match response when params are encoded in another way": {
+			query: "a=1&b=two+words",
+		},
+	}
 
-		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
-		assertBodyString(t, "matched request", httpResp)
-	})
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
 
-	t.Run("should return no match response when query does not match", func(t *testing.T) {
-		t.Parallel()
+			httpReq, _ := http.NewRequest(http.MethodGet, path+"?"+tc.query, http.NoBody)
 
-		httpReq, _ := http.NewRequest(http.MethodGet, path+"?name=rick", http.NoBody)
-		httpResp, err := server.Client().Do(httpReq)
-		require.NoError(t, err)
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
 
-		assertNotMatchedResponse(t, httpReq, httpResp)
-	})
+			if tc.expectedMatch {
+				assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+				assertBodyString(t, "matched request", httpResp)
+			} else {
+				assertNotMatchedResponse(t, httpReq, httpResp)
+			}
+		})
+	}
 }
 
 func TestMatchParam_URLPattern(t *testing.T) {
@@ -351,6 +1147,71 @@ func TestMatchParam_PathPattern(t *testing.T) {
 	})
 }
 
+func TestMatchParam_ConcurrentRequests(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodGet, mockaso.PathPattern("/api/users/{user_id}")).
+		Respond(mockaso.RespondWith(func(r *http.Request) (mockaso.Response, error) {
+			return mockaso.Response{mockaso.WithBody(r.PathValue("user_id"))}, nil
+		}))
+
+	for i := range 20 {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			userID := strconv.Itoa(i)
+
+			httpResp, err := server.Client().Get("/api/users/" + userID)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+			assertBodyString(t, userID, httpResp)
+		})
+	}
+}
+
+func TestParamsFromRequest(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	st := server.Stub(http.MethodGet, mockaso.URLPattern("/api/users/{user_id:int}/orders/{order_id}"))
+	captured := st.Capture()
+
+	st.Respond(mockaso.RespondWith(func(r *http.Request) (mockaso.Response, error) {
+		return mockaso.Response{mockaso.WithJSON(mockaso.ParamsFromRequest(r))}, nil
+	}))
+
+	server.Stub(http.MethodGet, mockaso.Path("/api/users")).
+		Respond(mockaso.RespondWith(func(r *http.Request) (mockaso.Response, error) {
+			return mockaso.Response{mockaso.WithBody(fmt.Sprint(mockaso.ParamsFromRequest(r) == nil))}, nil
+		}))
+
+	t.Run("should return the params of the matched pattern", func(t *testing.T) {
+		t.Parallel()
+
+		httpResp, err := server.Client().Get("/api/users/1/orders/abc")
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assert.JSONEq(t, `{"user_id":"1","order_id":"abc"}`, readString(httpResp.Body))
+		assert.Equal(t, map[string]string{"user_id": "1", "order_id": "abc"}, (<-captured).Params)
+	})
+
+	t.Run("should return nil without params", func(t *testing.T) {
+		t.Parallel()
+
+		httpResp, err := server.Client().Get("/api/users")
+		require.NoError(t, err)
+
+		assertBodyString(t, "true", httpResp)
+	})
+}
+
 func TestMatchNoBody(t *testing.T) {
 	t.Parallel()
 
@@ -449,6 +1310,85 @@ func TestMatchJSONBody(t *testing.T) {
 	})
 }
 
+type validUser struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"required"`
+	Age   int    `json:"age"`
+}
+
+func (u validUser) Validate() error {
+	if u.Age < 0 {
+		return errors.New("age must not be negative")
+	}
+
+	return nil
+}
+
+// requiredValidator validates the validate:"required" struct tags.
+type requiredValidator struct{}
+
+func (requiredValidator) Struct(v any) error {
+	value := reflect.ValueOf(v)
+
+	for i := range value.NumField() {
+		if value.Type().Field(i).Tag.Get("validate") == "required" && value.Field(i).IsZero() {
+			return fmt.Errorf("%s is required", value.Type().Field(i).Name)
+		}
+	}
+
+	return nil
+}
+
+func TestMatchValidBody(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const path = "/test/match-valid-body"
+
+	server.Stub(http.MethodPost, mockaso.Path(path)).
+		Match(mockaso.MatchValidBody[validUser](requiredValidator{})).
+		Respond(matchedRequestRules()...)
+
+	testCases := map[string]struct {
+		body          string
+		expectedMatch bool
+	}{
+		"should return the specified stub when body is valid": {
+			body:          `{"name":"john","email":"john@mail.com","age":30}`,
+			expectedMatch: true,
+		},
+		"should return no match response when body is not valid json": {
+			body: `{"name":"john"`,
+		},
+		"should return no match response when body does not pass the validator": {
+			body: `{"name":"john","age":30}`,
+		},
+		"should return no match response when body does not pass the Validate method": {
+			body: `{"name":"john","email":"john@mail.com","age":-1}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(tc.body))
+
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+
+			if tc.expectedMatch {
+				assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+				assertBodyString(t, "matched request", httpResp)
+			} else {
+				assertNotMatchedResponse(t, httpReq, httpResp)
+			}
+		})
+	}
+}
+
 func TestMatchBodyMapFunc(t *testing.T) {
 	t.Parallel()
 
@@ -597,3 +1537,202 @@ func matchedRequestRules() []mockaso.StubResponseRule {
 		mockaso.WithBody("matched request"),
 	}
 }
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	users := mockaso.NewStub(http.MethodGet, mockaso.Path("/api/users"))
+	createUser := mockaso.NewStub(http.MethodPost, mockaso.Path("/api/users"))
+	createUser.Match(mockaso.MatchJSONBody(map[string]string{"name": "john"}))
+
+	stubs := []mockaso.Stub{users, createUser}
+
+	testCases := map[string]struct {
+		method       string
+		body         string
+		expectedStub mockaso.Stub
+	}{
+		"should return the first stub that matches": {
+			method:       http.MethodGet,
+			expectedStub: users,
+		},
+		"should return the stub that matches the body": {
+			method:       http.MethodPost,
+			body:         `{"name":"john"}`,
+			expectedStub: createUser,
+		},
+		"should return nil when the body is not valid json": {
+			method: http.MethodPost,
+			body:   `{"name":`,
+		},
+		"should return nil when no stub matches": {
+			method: http.MethodDelete,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(tc.method, "/api/users", strings.NewReader(tc.body))
+			assert.Equal(t, tc.expectedStub, mockaso.Match(stubs, httpReq))
+		})
+	}
+}
+
+func FuzzMatch(f *testing.F) {
+	stubs := []mockaso.Stub{
+		mockaso.NewStub(http.MethodGet, mockaso.URLPattern("/api/users/{id}?attrs={attrs}")),
+		mockaso.NewStub(http.MethodGet, mockaso.PathPattern("/api/users/{id}/orders/{order}")),
+		mockaso.NewStub(http.MethodGet, mockaso.URL("/api/files?name=a b", mockaso.IgnoreEncoding())),
+		mockaso.NewStub(http.MethodGet, mockaso.Path("/api/files/a%2Fb", mockaso.ExactEncoding())),
+	}
+
+	withMatchers := func(method string, rules ...mockaso.StubMatcherRule) mockaso.Stub {
+		st := mockaso.NewStub(method, mockaso.PathRegex(".*"))
+		st.Match(rules...)
+
+		return st
+	}
+
+	stubs = append(stubs,
+		withMatchers(http.MethodPost, mockaso.MatchJSONBody(map[string]any{"name": "john", "tags": []string{"a"}})),
+		withMatchers(http.MethodPost, mockaso.MatchBodyMapFunc(func(m map[string]any) bool { return m["id"] == 1.0 })),
+		withMatchers(http.MethodPost, mockaso.MatchValidBody[validUser](requiredValidator{})),
+		withMatchers(http.MethodPut, mockaso.MatchQuery("id", "1", mockaso.SemicolonSeparator())),
+		withMatchers(http.MethodPut, mockaso.MatchRawQuery("a=1&b=two%20words")),
+		withMatchers(http.MethodGet, mockaso.MatchUpgrade("websocket")),
+		withMatchers(http.MethodGet, mockaso.MatchHeaderExact("x-api-KEY", "secret")),
+	)
+
+	f.Add(http.MethodGet, "/api/users/1?attrs=name", "", "")
+	f.Add(http.MethodPost, "/api/users", `{"name":"john","tags":["a"]}`, "")
+	f.Add(http.MethodPost, "/api/users", `{"id":1,"name":`, "application/json")
+	f.Add(http.MethodPut, "/api/users?id=1;b=%zz", "", "")
+	f.Add(http.MethodGet, "/api/files/a%2Fb?name=a+b", "", "websocket")
+
+	f.Fuzz(func(t *testing.T, method, target, body, header string) {
+		httpReq, err := http.NewRequest(method, target, strings.NewReader(body))
+		if err != nil {
+			t.Skip()
+		}
+
+		httpReq.Header.Set("Upgrade", header)
+		httpReq.Header.Set("Sec-WebSocket-Key", header)
+
+		_ = mockaso.Match(stubs, httpReq)
+	})
+}
+
+func TestMatcherCombinators(t *testing.T) {
+	t.Parallel()
+
+	// body contains X but header Y is absent, or query Z is set
+	complexRule := mockaso.AnyOf(
+		mockaso.AllOf(
+			mockaso.MatchBodyStringFunc(func(body string) bool { return strings.Contains(body, "X") }),
+			mockaso.Not(mockaso.MatchHeaderPresent("Y")),
+		),
+		mockaso.MatchQueryPresent("Z"),
+	)
+
+	testCases := map[string]struct {
+		rule     mockaso.StubMatcherRule
+		query    string
+		header   string
+		body     string
+		expected bool
+	}{
+		"not should match when the rule does not match": {
+			rule:     mockaso.Not(mockaso.MatchHeaderPresent("Y")),
+			expected: true,
+		},
+		"not should not match when the rule matches": {
+			rule:   mockaso.Not(mockaso.MatchHeaderPresent("Y")),
+			header: "Y",
+		},
+		"any of should match when one rule matches": {
+			rule:     mockaso.AnyOf(mockaso.MatchQuery("a", "1"), mockaso.MatchQuery("b", "2")),
+			query:    "b=2",
+			expected: true,
+		},
+		"any of should not match when no rule matches": {
+			rule:  mockaso.AnyOf(mockaso.MatchQuery("a", "1"), mockaso.MatchQuery("b", "2")),
+			query: "a=2&b=1",
+		},
+		"all of should match when all the rules match": {
+			rule:     mockaso.AllOf(mockaso.MatchQuery("a", "1"), mockaso.MatchQuery("b", "2")),
+			query:    "a=1&b=2",
+			expected: true,
+		},
+		"all of should not match when one rule does not match": {
+			rule:  mockaso.AllOf(mockaso.MatchQuery("a", "1"), mockaso.MatchQuery("b", "2")),
+			query: "a=1",
+		},
+		"complex rule should match the body without the header": {
+			rule:     complexRule,
+			body:     "contains X",
+			expected: true,
+		},
+		"complex rule should not match the body with the header": {
+			rule:   complexRule,
+			body:   "contains X",
+			header: "Y",
+		},
+		"complex rule should match the query": {
+			rule:     complexRule,
+			body:     "contains X",
+			header:   "Y",
+			query:    "Z=1",
+			expected: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(http.MethodPost, "/test/combinators?"+tc.query, strings.NewReader(tc.body))
+			if tc.header != "" {
+				httpReq.Header.Set(tc.header, "value")
+			}
+
+			assert.Equal(t, tc.expected, stubMatches(httpReq, tc.rule))
+		})
+	}
+
+	t.Run("should combine param rules", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		server.Stub(http.MethodGet, mockaso.PathPattern("/api/users/{username}")).
+			Match(mockaso.Not(mockaso.AnyOf(mockaso.MatchParam("username", "john"), mockaso.MatchParam("username", "rick")))).
+			Respond(matchedRequestRules()...)
+
+		httpResp, err := server.Client().Get("/api/users/morty")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+
+		httpReq, _ := http.NewRequest(http.MethodGet, "/api/users/rick", http.NoBody)
+		httpResp, err = server.Client().Do(httpReq)
+		require.NoError(t, err)
+		assertNotMatchedResponse(t, httpReq, httpResp)
+	})
+
+	t.Run("should panic without rules", func(t *testing.T) {
+		t.Parallel()
+
+		assert.PanicsWithError(t, "AnyOf err: at least one rule is required", func() { mockaso.AnyOf() })
+		assert.PanicsWithError(t, "AllOf err: at least one rule is required", func() { mockaso.AllOf() })
+	})
+}
+
+// stubMatches reports whether a stub with the given rules matches the request, evaluated without a server.
+func stubMatches(r *http.Request, rules ...mockaso.StubMatcherRule) bool {
+	st := mockaso.NewStub(r.Method, mockaso.Path(r.URL.Path))
+	st.Match(rules...)
+
+	return mockaso.Match([]mockaso.Stub{st}, r) != nil
+}
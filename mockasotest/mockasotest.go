@@ -0,0 +1,75 @@
+// Package mockasotest provides assertion helpers for the requests captured by mockaso stubs,
+// reporting field-level differences instead of whole bodies.
+package mockasotest
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/royhq/mockaso"
+	"github.com/royhq/mockaso/internal/jsondiff"
+)
+
+type tHelper interface {
+	Helper()
+}
+
+// AssertJSONRequest asserts that the body of the captured request is equal to the JSON of expected.
+// expected can be raw JSON (string, []byte or json.RawMessage) or any value, which is marshaled.
+//
+// Example:
+//
+//	req := <-st.Capture()
+//	mockasotest.AssertJSONRequest(t, req, CreateUserRequest{Name: "john"})
+func AssertJSONRequest(t mockaso.TestingT, captured *mockaso.CapturedRequest, expected any) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	if captured == nil {
+		t.Errorf("no captured request")
+		return false
+	}
+
+	return AssertJSON(t, captured.Body, expected)
+}
+
+// AssertJSON asserts that the actual JSON document is equal to the JSON of expected.
+// expected can be raw JSON (string, []byte or json.RawMessage) or any value, which is marshaled.
+func AssertJSON(t mockaso.TestingT, actual []byte, expected any) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	expectedJSON, err := marshal(expected)
+	if err != nil {
+		t.Errorf("failed to marshal expected JSON: %v", err)
+		return false
+	}
+
+	diff, err := jsondiff.DiffBytes(expectedJSON, actual)
+	if err != nil {
+		t.Errorf("failed to compare JSON: %v\nactual: %s", err, actual)
+		return false
+	}
+
+	if len(diff) > 0 {
+		t.Errorf("JSON is not equal:\n\t%s", strings.Join(diff, "\n\t"))
+		return false
+	}
+
+	return true
+}
+
+func marshal(v any) ([]byte, error) {
+	switch raw := v.(type) {
+	case string:
+		return []byte(raw), nil
+	case []byte:
+		return raw, nil
+	case json.RawMessage:
+		return raw, nil
+	default:
+		return json.Marshal(v)
+	}
+}
@@ -0,0 +1,81 @@
+package mockasotest_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+	"github.com/royhq/mockaso/mockasotest"
+)
+
+// fakeT records the assertion failures instead of failing the test.
+type fakeT struct {
+	errors []string
+}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+type user struct {
+	Name string   `json:"name"`
+	Age  int      `json:"age"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+func TestAssertJSONRequest(t *testing.T) {
+	t.Parallel()
+
+	captured := &mockaso.CapturedRequest{Body: []byte(`{"age":30,"name":"john","tags":["a"]}`)}
+
+	testCases := map[string]struct {
+		expected       any
+		expectedErrors []string
+	}{
+		"should pass when body is equal to the expected struct": {
+			expected: user{Name: "john", Age: 30, Tags: []string{"a"}},
+		},
+		"should pass when body is equal to the expected raw JSON": {
+			expected: `{"name":"john","age":30,"tags":["a"]}`,
+		},
+		"should report the field-level differences": {
+			expected: user{Name: "rick", Age: 30},
+			expectedErrors: []string{
+				"JSON is not equal:\n" +
+					"\t- $.name: \"rick\"\n" +
+					"\t+ $.name: \"john\"\n" +
+					"\t+ $.tags: [\"a\"]",
+			},
+		},
+		"should report when expected raw JSON is not valid": {
+			expected: `{"name":`,
+			expectedErrors: []string{
+				"failed to compare JSON: failed to unmarshal expected JSON: unexpected end of JSON input\n" +
+					`actual: {"age":30,"name":"john","tags":["a"]}`,
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := new(fakeT)
+			ok := mockasotest.AssertJSONRequest(fake, captured, tc.expected)
+
+			assert.Equal(t, len(tc.expectedErrors) == 0, ok)
+			assert.Equal(t, tc.expectedErrors, fake.errors)
+		})
+	}
+
+	t.Run("should fail when there is no captured request", func(t *testing.T) {
+		t.Parallel()
+
+		fake := new(fakeT)
+		require.False(t, mockasotest.AssertJSONRequest(fake, nil, user{}))
+		assert.Equal(t, []string{"no captured request"}, fake.errors)
+	})
+}
@@ -0,0 +1,493 @@
+package mockaso
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LoadMountebankImposter reads a Mountebank imposter definition (JSON) and registers its stubs in the server.
+// Supported predicates are equals, deepEquals, contains, startsWith, endsWith, matches, exists, not, or & and
+// over the method, path, query, headers and body request fields. Only "is" responses are supported and, since
+// stubs have a single response, only the first response of each imposter stub is used.
+// Repeated query params and headers are arrays, as in Mountebank, and a predicate matches if any of their values
+// matches, except deepEquals which compares the whole array.
+func (s *Server) LoadMountebankImposter(r io.Reader) error {
+	var imposter mbImposter
+
+	if err := json.NewDecoder(r).Decode(&imposter); err != nil {
+		return fmt.Errorf("decode mountebank imposter failed: %w", err)
+	}
+
+	if imposter.Protocol != "" && imposter.Protocol != "http" && imposter.Protocol != "https" {
+		return fmt.Errorf("unsupported mountebank protocol: %s", imposter.Protocol)
+	}
+
+	stubs := make([]*stub, 0, len(imposter.Stubs))
+
+	for i, mbStub := range imposter.Stubs {
+		st, err := mbStub.toStub(s)
+		if err != nil {
+			return fmt.Errorf("mountebank stub #%d: %w", i, err)
+		}
+
+		stubs = append(stubs, st)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.appendStubs(stubs...)
+
+	return nil
+}
+
+// LoadMountebankImposterFile reads a Mountebank imposter definition from the given file.
+// See LoadMountebankImposter.
+func (s *Server) LoadMountebankImposterFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open mountebank imposter failed: %w", err)
+	}
+	defer f.Close()
+
+	return s.LoadMountebankImposter(f)
+}
+
+type mbImposter struct {
+	Protocol string   `json:"protocol"`
+	Stubs    []mbStub `json:"stubs"`
+}
+
+type mbStub struct {
+	Predicates []mbPredicate `json:"predicates"`
+	Responses  []mbResponse  `json:"responses"`
+}
+
+type mbPredicate map[string]json.RawMessage
+
+type mbResponse struct {
+	Is *mbIsResponse `json:"is"`
+}
+
+type mbIsResponse struct {
+	StatusCode json.RawMessage   `json:"statusCode"`
+	Headers    map[string]string `json:"headers"`
+	Body       json.RawMessage   `json:"body"`
+	Mode       string            `json:"_mode"`
+}
+
+func (m mbStub) toStub(s *Server) (*stub, error) {
+	st := newStub(s.clock, nil)
+
+	for _, predicate := range m.Predicates {
+		matcher, err := predicate.toMatcher()
+		if err != nil {
+			return nil, err
+		}
+
+		st.matchers = append(st.matchers, func(_ *matchState, r *http.Request) bool {
+			return matcher(newMBRequest(r))
+		})
+	}
+
+	if len(m.Responses) == 0 {
+		return st, nil
+	}
+
+	if m.Responses[0].Is == nil {
+		return nil, errors.New(`only "is" responses are supported`)
+	}
+
+	if err := m.Responses[0].Is.apply(st.response); err != nil {
+		return nil, err
+	}
+
+	return st, nil
+}
+
+func (m *mbIsResponse) apply(r *stubResponse) error {
+	if len(m.StatusCode) > 0 {
+		statusCode, err := strconv.Atoi(strings.Trim(string(m.StatusCode), `"`))
+		if err != nil {
+			return fmt.Errorf("invalid status code %s: %w", m.StatusCode, err)
+		}
+
+		r.statusCode = statusCode
+	}
+
+	r.setHeaders(m.Headers)
+
+	if len(m.Body) == 0 {
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(m.Body, &text); err != nil { // body is not a string, so it is served as JSON
+		r.setJSON(m.Body)
+		return nil
+	}
+
+	if m.Mode != "binary" {
+		r.setBody([]byte(text))
+		return nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return fmt.Errorf("invalid binary body: %w", err)
+	}
+
+	r.setBody(data)
+
+	return nil
+}
+
+// mbRequest is the request representation used by Mountebank predicates.
+type mbRequest map[string]any
+
+func newMBRequest(r *http.Request) mbRequest {
+	return mbRequest{
+		"method":  r.Method,
+		"path":    r.URL.Path,
+		"query":   mbValues(r.URL.Query()),
+		"headers": mbValues(r.Header),
+		"body":    string(mustReadDecodedBody(r)),
+	}
+}
+
+// mbValues returns the values as Mountebank does: a string for single values and an array for repeated ones,
+// e.g. ?id=1&id=2 is {"id":["1","2"]}. A predicate over a repeated value matches if any of the values matches.
+func mbValues[M ~map[string][]string](values M) map[string]any {
+	result := make(map[string]any, len(values))
+
+	for k, v := range values {
+		if len(v) == 1 {
+			result[k] = v[0]
+			continue
+		}
+
+		array := make([]any, len(v))
+		for i := range v {
+			array[i] = v[i]
+		}
+
+		result[k] = array
+	}
+
+	return result
+}
+
+type mbMatcher func(mbRequest) bool
+
+var mbFields = map[string]bool{"method": true, "path": true, "query": true, "headers": true, "body": true}
+
+func (p mbPredicate) toMatcher() (mbMatcher, error) {
+	caseSensitive := false
+
+	if raw, ok := p["caseSensitive"]; ok {
+		if err := json.Unmarshal(raw, &caseSensitive); err != nil {
+			return nil, fmt.Errorf("invalid caseSensitive: %w", err)
+		}
+	}
+
+	var matchers []mbMatcher
+
+	for operator, raw := range p {
+		if operator == "caseSensitive" {
+			continue
+		}
+
+		matcher, err := mbOperatorMatcher(operator, raw, caseSensitive)
+		if err != nil {
+			return nil, err
+		}
+
+		matchers = append(matchers, matcher)
+	}
+
+	return mbAll(matchers), nil
+}
+
+func mbOperatorMatcher(operator string, raw json.RawMessage, caseSensitive bool) (mbMatcher, error) {
+	switch operator {
+	case "not":
+		var predicate mbPredicate
+		if err := json.Unmarshal(raw, &predicate); err != nil {
+			return nil, fmt.Errorf("invalid not predicate: %w", err)
+		}
+
+		matcher, err := predicate.toMatcher()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(r mbRequest) bool { return !matcher(r) }, nil
+	case "or", "and":
+		var predicates []mbPredicate
+		if err := json.Unmarshal(raw, &predicates); err != nil {
+			return nil, fmt.Errorf("invalid %s predicate: %w", operator, err)
+		}
+
+		matchers := make([]mbMatcher, 0, len(predicates))
+
+		for _, predicate := range predicates {
+			matcher, err := predicate.toMatcher()
+			if err != nil {
+				return nil, err
+			}
+
+			matchers = append(matchers, matcher)
+		}
+
+		if operator == "and" {
+			return mbAll(matchers), nil
+		}
+
+		return mbAny(matchers), nil
+	case "equals", "deepEquals", "contains", "startsWith", "endsWith", "matches", "exists":
+		var fields map[string]any
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, fmt.Errorf("invalid %s predicate: %w", operator, err)
+		}
+
+		for field := range fields {
+			if !mbFields[field] {
+				return nil, fmt.Errorf("unsupported predicate field: %s", field)
+			}
+		}
+
+		compare, err := mbComparator(operator, fields, caseSensitive)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(r mbRequest) bool {
+			for field, expected := range fields {
+				if !mbMatchValue(operator, expected, r[field], compare, caseSensitive) {
+					return false
+				}
+			}
+
+			return true
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported predicate operator: %s", operator)
+	}
+}
+
+// mbCompileRegexes compiles the expected values of a matches predicate, keyed by their text.
+func mbCompileRegexes(v any, caseSensitive bool, regexes map[string]*regexp.Regexp) error {
+	if value, ok := v.(map[string]any); ok {
+		for _, nested := range value {
+			if err := mbCompileRegexes(nested, caseSensitive, regexes); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	expr := mbString(v)
+	if !caseSensitive {
+		expr = "(?i)" + expr
+	}
+
+	regex, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid matches regex %q: %w", mbString(v), err)
+	}
+
+	regexes[mbString(v)] = regex
+
+	return nil
+}
+
+func mbAll(matchers []mbMatcher) mbMatcher {
+	return func(r mbRequest) bool {
+		for _, matcher := range matchers {
+			if !matcher(r) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+func mbAny(matchers []mbMatcher) mbMatcher {
+	return func(r mbRequest) bool {
+		for _, matcher := range matchers {
+			if matcher(r) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// mbComparator returns the func used to compare an expected scalar of the given fields against the actual value.
+func mbComparator(operator string, fields map[string]any, caseSensitive bool) (func(e, a string) bool, error) {
+	normalize := strings.ToLower
+	if caseSensitive {
+		normalize = func(s string) string { return s }
+	}
+
+	switch operator {
+	case "equals", "deepEquals":
+		return func(e, a string) bool { return normalize(e) == normalize(a) }, nil
+	case "contains":
+		return func(e, a string) bool { return strings.Contains(normalize(a), normalize(e)) }, nil
+	case "startsWith":
+		return func(e, a string) bool { return strings.HasPrefix(normalize(a), normalize(e)) }, nil
+	case "endsWith":
+		return func(e, a string) bool { return strings.HasSuffix(normalize(a), normalize(e)) }, nil
+	case "matches":
+		regexes := make(map[string]*regexp.Regexp)
+		if err := mbCompileRegexes(fields, caseSensitive, regexes); err != nil {
+			return nil, err
+		}
+
+		return func(e, a string) bool { return regexes[e].MatchString(a) }, nil
+	case "exists":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported predicate operator: %s", operator)
+	}
+}
+
+func mbMatchValue(operator string, expected, actual any, compare func(string, string) bool, caseSensitive bool) bool {
+	if operator == "exists" {
+		if expectedMap, ok := expected.(map[string]any); ok {
+			return mbExistsObject(expectedMap, actual, caseSensitive)
+		}
+
+		exists, _ := expected.(bool)
+
+		return mbExists(actual) == exists
+	}
+
+	expectedMap, isMap := expected.(map[string]any)
+	if !isMap {
+		if actualArray, ok := actual.([]any); ok && operator != "deepEquals" {
+			for _, actualValue := range actualArray {
+				if mbMatchValue(operator, expected, actualValue, compare, caseSensitive) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		actualText, ok := actual.(string)
+		if !ok {
+			actualText = mbString(actual)
+		}
+
+		return compare(mbString(expected), actualText)
+	}
+
+	actualMap, ok := mbObject(actual)
+	if !ok {
+		return false
+	}
+
+	if operator == "deepEquals" && len(expectedMap) != len(actualMap) {
+		return false
+	}
+
+	for key, expectedValue := range expectedMap {
+		actualValue, found := mbLookup(actualMap, key, caseSensitive)
+		if !found || !mbMatchValue(operator, expectedValue, actualValue, compare, caseSensitive) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mbExists reports whether the actual value is present and not empty.
+func mbExists(actual any) bool {
+	switch v := actual.(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	case map[string]any:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
+
+// mbExistsObject handles exists predicates over objects, e.g. {"exists":{"query":{"page":true}}}.
+func mbExistsObject(expectedMap map[string]any, actual any, caseSensitive bool) bool {
+	actualMap, ok := mbObject(actual)
+	if !ok {
+		actualMap = make(map[string]any)
+	}
+
+	for key, expectedValue := range expectedMap {
+		actualValue, _ := mbLookup(actualMap, key, caseSensitive)
+		if !mbMatchValue("exists", expectedValue, actualValue, nil, caseSensitive) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mbObject returns the actual value as an object, decoding it when it is a JSON string (e.g. request body).
+func mbObject(actual any) (map[string]any, bool) {
+	switch v := actual.(type) {
+	case map[string]any:
+		return v, true
+	case string:
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(v), &obj); err != nil {
+			return nil, false
+		}
+
+		return obj, true
+	default:
+		return nil, false
+	}
+}
+
+func mbLookup(m map[string]any, key string, caseSensitive bool) (any, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+
+	if caseSensitive {
+		return nil, false
+	}
+
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+
+	return nil, false
+}
+
+func mbString(v any) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case nil:
+		return ""
+	default:
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+}
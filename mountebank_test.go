@@ -0,0 +1,259 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+const testImposter = `{
+  "port": 4545,
+  "protocol": "http",
+  "stubs": [
+    {
+      "predicates": [
+        {"equals": {"method": "GET", "path": "/api/users", "query": {"page": "2"}}}
+      ],
+      "responses": [
+        {"is": {"statusCode": 200, "headers": {"X-Page": "2"}, "body": {"users": ["john", "rick"]}}}
+      ]
+    },
+    {
+      "predicates": [
+        {"equals": {"method": "POST"}},
+        {"startsWith": {"path": "/api/users"}},
+        {"equals": {"body": {"name": "john"}}},
+        {"exists": {"headers": {"Authorization": true}}}
+      ],
+      "responses": [
+        {"is": {"statusCode": 201, "body": "created"}}
+      ]
+    },
+    {
+      "predicates": [
+        {"or": [
+          {"matches": {"path": "^/api/items/\\d+$"}},
+          {"contains": {"path": "legacy"}}
+        ]},
+        {"not": {"equals": {"method": "DELETE"}}}
+      ],
+      "responses": [
+        {"is": {"statusCode": "202", "body": "aGVsbG8=", "_mode": "binary"}}
+      ]
+    },
+    {
+      "predicates": [
+        {"equals": {"path": "/API/CASE"}, "caseSensitive": true}
+      ],
+      "responses": [
+        {"is": {"body": "case sensitive"}}
+      ]
+    },
+    {
+      "predicates": [
+        {"matches": {"path": "^/api/tags$", "query": {"tag": "^go"}}}
+      ],
+      "responses": [
+        {"is": {"body": "tagged"}}
+      ]
+    }
+  ]
+}`
+
+func TestServer_LoadMountebankImposter(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	require.NoError(t, server.LoadMountebankImposter(strings.NewReader(testImposter)))
+
+	testCases := map[string]struct {
+		method         string
+		url            string
+		body           string
+		headers        map[string]string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"equals predicate with query": {
+			method:         http.MethodGet,
+			url:            "/api/users?page=2",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"users": ["john", "rick"]}`,
+		},
+		"equals predicate is case insensitive by default": {
+			method:         http.MethodGet,
+			url:            "/API/Users?page=2",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"users": ["john", "rick"]}`,
+		},
+		"json body and header exists predicates": {
+			method:         http.MethodPost,
+			url:            "/api/users/new",
+			body:           `{"name":"john","age":57}`,
+			headers:        map[string]string{"Authorization": "Bearer token"},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "created",
+		},
+		"or and not predicates with binary body": {
+			method:         http.MethodPut,
+			url:            "/api/items/123",
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   "hello",
+		},
+		"case sensitive predicate": {
+			method:         http.MethodGet,
+			url:            "/API/CASE",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "case sensitive",
+		},
+		"matches predicate with any value of a repeated query param": {
+			method:         http.MethodGet,
+			url:            "/api/tags?tag=rust&tag=GoLang",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "tagged",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run("should match "+name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq, _ := http.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+			for k, v := range tc.headers {
+				httpReq.Header.Set(k, v)
+			}
+
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedStatus, httpResp.StatusCode)
+			assertBodyString(t, tc.expectedBody, httpResp)
+		})
+	}
+
+	notMatchingCases := map[string]struct {
+		method  string
+		url     string
+		body    string
+		headers map[string]string
+	}{
+		"when query does not match": {
+			method: http.MethodGet,
+			url:    "/api/users?page=3",
+		},
+		"when header does not exist": {
+			method: http.MethodPost,
+			url:    "/api/users/new",
+			body:   `{"name":"john"}`,
+		},
+		"when not predicate is false": {
+			method: http.MethodDelete,
+			url:    "/api/items/123",
+		},
+		"when case does not match in case sensitive predicate": {
+			method: http.MethodGet,
+			url:    "/api/case",
+		},
+		"when no value of a repeated query param matches": {
+			method: http.MethodGet,
+			url:    "/api/tags?tag=rust&tag=java",
+		},
+	}
+
+	for name, tc := range notMatchingCases {
+		t.Run("should return no match response "+name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq, _ := http.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+			for k, v := range tc.headers {
+				httpReq.Header.Set(k, v)
+			}
+
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+
+			assertNotMatchedResponse(t, httpReq, httpResp)
+		})
+	}
+}
+
+func TestServer_LoadMountebankImposter_Errors(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.NewServer(mockaso.WithLogger(t))
+
+	testCases := map[string]struct {
+		imposter      string
+		expectedError string
+	}{
+		"invalid json": {
+			imposter:      `{"stubs":`,
+			expectedError: "decode mountebank imposter failed",
+		},
+		"unsupported protocol": {
+			imposter:      `{"protocol":"tcp"}`,
+			expectedError: "unsupported mountebank protocol: tcp",
+		},
+		"unsupported operator": {
+			imposter:      `{"stubs":[{"predicates":[{"inject":"function(){}"}]}]}`,
+			expectedError: "unsupported predicate operator: inject",
+		},
+		"unsupported field": {
+			imposter:      `{"stubs":[{"predicates":[{"equals":{"requestFrom":"127.0.0.1"}}]}]}`,
+			expectedError: "unsupported predicate field: requestFrom",
+		},
+		"invalid regex": {
+			imposter:      `{"stubs":[{"predicates":[{"matches":{"path":"("}}]}]}`,
+			expectedError: "invalid matches regex",
+		},
+		"unsupported response": {
+			imposter:      `{"stubs":[{"responses":[{"proxy":{"to":"http://localhost"}}]}]}`,
+			expectedError: `only "is" responses are supported`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run("should return error when "+name, func(t *testing.T) {
+			t.Parallel()
+
+			err := server.LoadMountebankImposter(strings.NewReader(tc.imposter))
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestServer_LoadMountebankImposterFile(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	t.Run("should load imposter from file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "imposter.json")
+		require.NoError(t, os.WriteFile(path, []byte(testImposter), 0o600))
+
+		require.NoError(t, server.LoadMountebankImposterFile(path))
+
+		httpReq, _ := http.NewRequest(http.MethodGet, "/api/users?page=2", http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assert.Equal(t, "2", httpResp.Header.Get("X-Page"))
+		assert.Equal(t, "application/json", httpResp.Header.Get("Content-Type"))
+	})
+
+	t.Run("should return error when file does not exist", func(t *testing.T) {
+		err := server.LoadMountebankImposterFile(filepath.Join(t.TempDir(), "missing.json"))
+		assert.ErrorContains(t, err, "open mountebank imposter failed")
+	})
+}
@@ -0,0 +1,199 @@
+package mockaso
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// muxWildcardRegex identifies the wildcards of a ServeMux pattern, e.g. {id} or {path...}.
+var muxWildcardRegex = regexp.MustCompile(`\{(\w+)(?:\.\.\.)?}`)
+
+// MuxPattern will match http request when the given pattern match to the request as in http.ServeMux (Go 1.22
+// patterns), so the patterns of the handlers can be used in the stubs. Wildcards like {id} and {path...} are
+// available as params, see MatchParam. Requests which ServeMux would redirect, e.g. non-canonical paths, do not
+// match. The method of the pattern, if any, must be the stub method, and host patterns are not supported.
+//
+// When several stubs with MuxPattern match a request, the most specific pattern wins following the ServeMux
+// precedence rules, regardless of the registration order.
+//
+// Example:
+//
+//	server.Stub(http.MethodGet, MuxPattern("GET /api/users/{id}"))
+//	server.Stub(http.MethodGet, MuxPattern("GET /api/files/{path...}"))
+func MuxPattern(pattern string) URLMatcher {
+	mp, err := newMuxPattern(pattern)
+	if err != nil {
+		panic(fmt.Errorf("MuxPattern err: %w", err))
+	}
+
+	matcher := describedURLMatcher(pattern, func(u *url.URL, state *matchState) bool {
+		params, ok := mp.match(u)
+		if !ok {
+			return false
+		}
+
+		if state != nil {
+			state.params, state.muxPattern = params, mp
+		}
+
+		return true
+	})
+
+	return func(u *url.URL, state *matchState) bool {
+		if state != nil && state.describe != nil {
+			state.describe.muxPattern = mp
+		}
+
+		return matcher(u, state)
+	}
+}
+
+// muxPattern is a http.ServeMux pattern.
+type muxPattern struct {
+	pattern    string
+	method     string
+	wildcards  []string
+	mux        *http.ServeMux // with the pattern only, built once to match the requests
+	precedence sync.Map       // the other pattern -> whether this pattern precedes it, see precedes
+}
+
+func newMuxPattern(pattern string) (mp *muxPattern, err error) {
+	method, path, found := strings.Cut(pattern, " ")
+	if !found {
+		method, path = "", pattern
+	}
+
+	if !strings.HasPrefix(strings.TrimSpace(path), "/") {
+		return nil, fmt.Errorf("host patterns are not supported: %s", pattern)
+	}
+
+	mp = &muxPattern{pattern: pattern, method: method, mux: http.NewServeMux()}
+
+	for _, match := range muxWildcardRegex.FindAllStringSubmatch(path, -1) {
+		mp.wildcards = append(mp.wildcards, match[1])
+	}
+
+	defer func() { // ServeMux panics with invalid patterns
+		if r := recover(); r != nil {
+			mp, err = nil, fmt.Errorf("%v", r)
+		}
+	}()
+
+	mp.mux.Handle(pattern, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	return mp, nil
+}
+
+// match reports whether the pattern matches the url, and returns the values of the wildcards.
+func (mp *muxPattern) match(u *url.URL) (map[string]string, bool) {
+	r := mp.request(u, mp.method)
+
+	w := &muxResponseWriter{code: http.StatusOK}
+	mp.mux.ServeHTTP(w, r)
+
+	if r.Pattern != mp.pattern || w.code != http.StatusOK { // not found or redirected
+		return nil, false
+	}
+
+	params := make(map[string]string, len(mp.wildcards))
+	for _, name := range mp.wildcards {
+		params[name] = r.PathValue(name)
+	}
+
+	return params, true
+}
+
+func (mp *muxPattern) request(u *url.URL, method string) *http.Request {
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	return &http.Request{Method: method, URL: u, Host: u.Host, Header: make(http.Header)}
+}
+
+// muxResponseWriter discards the response of the ServeMux, keeping the status code only.
+type muxResponseWriter struct {
+	header http.Header
+	code   int
+}
+
+func (w *muxResponseWriter) Header() http.Header {
+	if w.header == nil {
+		w.header = make(http.Header)
+	}
+
+	return w.header
+}
+
+func (w *muxResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+
+func (w *muxResponseWriter) WriteHeader(code int) { w.code = code }
+
+// precedes reports whether the pattern takes precedence over the other one for a request which both match,
+// following the ServeMux rules. Patterns which ServeMux considers in conflict do not take precedence.
+// The result does not depend on the request, since a pattern that matches all the requests of another one
+// is more specific or in conflict, so it is computed once per pair of patterns.
+func (mp *muxPattern) precedes(other *muxPattern, r *http.Request) bool {
+	if mp.pattern == other.pattern {
+		return false
+	}
+
+	if precedes, ok := mp.precedence.Load(other.pattern); ok {
+		return precedes.(bool)
+	}
+
+	precedes := mp.resolvePrecedence(other, r)
+	mp.precedence.Store(other.pattern, precedes)
+
+	return precedes
+}
+
+func (mp *muxPattern) resolvePrecedence(other *muxPattern, r *http.Request) (precedes bool) {
+	defer func() {
+		if recover() != nil { // conflicting patterns
+			precedes = false
+		}
+	}()
+
+	mux := http.NewServeMux()
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	mux.Handle(other.pattern, noop)
+	mux.Handle(mp.pattern, noop)
+
+	_, pattern := mux.Handler(mp.request(r.URL, r.Method))
+
+	return pattern == mp.pattern
+}
+
+// preferredStub returns the stub, of the ones registered after the matched stub, whose MuxPattern takes precedence
+// over the one of the matched stub for the request, or the matched stub if there is none. The state of the
+// evaluation of the returned stub is returned too.
+func preferredStub(matched *stub, state *matchState, next []*stub, r *http.Request) (*stub, *matchState) {
+	if state.muxPattern == nil {
+		return matched, state
+	}
+
+	preferred, preferredState := matched, state
+
+	for _, st := range next {
+		if st.muxPattern == nil { // it would not take precedence, so it is not evaluated
+			continue
+		}
+
+		nextState, ok := st.evaluate(r)
+		if !ok || nextState.muxPattern == nil {
+			continue
+		}
+
+		if nextState.muxPattern.precedes(preferredState.muxPattern, r) {
+			preferred, preferredState = st, nextState
+		}
+	}
+
+	return preferred, preferredState
+}
@@ -0,0 +1,126 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestMuxPattern(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		pattern        string
+		path           string
+		expectedParams map[string]string
+		expectedMatch  bool
+	}{
+		"should match a wildcard": {
+			pattern:        "GET /api/users/{id}",
+			path:           "/api/users/1",
+			expectedParams: map[string]string{"id": "1"},
+			expectedMatch:  true,
+		},
+		"should not match a wildcard with more segments": {
+			pattern: "GET /api/users/{id}",
+			path:    "/api/users/1/orders",
+		},
+		"should match a remaining segments wildcard": {
+			pattern:        "/api/files/{path...}",
+			path:           "/api/files/docs/2024/report.pdf",
+			expectedParams: map[string]string{"path": "docs/2024/report.pdf"},
+			expectedMatch:  true,
+		},
+		"should match a trailing slash pattern as a prefix": {
+			pattern:       "/static/",
+			path:          "/static/css/site.css",
+			expectedMatch: true,
+		},
+		"should match the exact path with an end anchor": {
+			pattern:       "/static/{$}",
+			path:          "/static/",
+			expectedMatch: true,
+		},
+		"should not match a longer path with an end anchor": {
+			pattern: "/static/{$}",
+			path:    "/static/css/site.css",
+		},
+		"should not match a path which would be redirected": {
+			pattern: "/static/",
+			path:    "/static",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			st := mockaso.NewStub(http.MethodGet, mockaso.MuxPattern(tc.pattern))
+			for key, value := range tc.expectedParams {
+				st.Match(mockaso.MatchParam(key, value))
+			}
+
+			httpReq := httptest.NewRequest(http.MethodGet, tc.path, http.NoBody)
+			assert.Equal(t, tc.expectedMatch, mockaso.Match([]mockaso.Stub{st}, httpReq) != nil)
+		})
+	}
+
+	t.Run("should panic when pattern is not valid", func(t *testing.T) {
+		t.Parallel()
+
+		assert.PanicsWithError(t, `MuxPattern err: parsing "/api/{id": at offset 5: bad wildcard segment (must end with '}')`,
+			func() { mockaso.MuxPattern("/api/{id") })
+		assert.PanicsWithError(t, "MuxPattern err: host patterns are not supported: api.example.com/users",
+			func() { mockaso.MuxPattern("api.example.com/users") })
+	})
+}
+
+func TestMuxPattern_Precedence(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	// registered from the least to the most specific, so the order does not decide
+	server.Stub(http.MethodGet, mockaso.MuxPattern("GET /api/")).Respond(mockaso.WithBody("api"))
+	server.Stub(http.MethodGet, mockaso.MuxPattern("GET /api/users/{id}")).Respond(mockaso.WithBody("user"))
+	server.Stub(http.MethodGet, mockaso.PathRegex("^/api/orders/")).Respond(mockaso.WithBody("orders"))
+	server.Stub(http.MethodGet, mockaso.MuxPattern("GET /api/users/me")).Respond(mockaso.WithBody("me"))
+
+	testCases := map[string]struct {
+		path         string
+		expectedBody string
+	}{
+		"should return the most specific stub": {
+			path:         "/api/users/me",
+			expectedBody: "me",
+		},
+		"should return the wildcard stub": {
+			path:         "/api/users/1",
+			expectedBody: "user",
+		},
+		"should return the prefix stub": {
+			path:         "/api/orders/1",
+			expectedBody: "api",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for range 2 { // the precedence is resolved once per pair of patterns
+				httpResp, err := server.Client().Get(tc.path)
+				require.NoError(t, err)
+
+				assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+				assertBodyString(t, tc.expectedBody, httpResp)
+			}
+		})
+	}
+}
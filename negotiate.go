@@ -0,0 +1,178 @@
+package mockaso
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RespondNegotiated sets the response variants selected by the request Accept header (q-values are supported).
+// The keys are media types and each variant includes its media type as Content-Type unless it is set by its rules.
+// Ties are resolved by the most specific match and then by media type in alphabetical order.
+// When the request does not accept any variant, the response will be 406 Not Acceptable.
+//
+// Example:
+//
+//	RespondNegotiated(map[string]Response{
+//		"application/json": {WithBody(`{"name":"john"}`)},
+//		"application/xml":  {WithBody(`<user><name>john</name></user>`)},
+//	})
+func (s *stub) RespondNegotiated(variants map[string]Response) {
+	setContentType := func(r *stubResponse, offer string) {
+		if _, ok := r.headers["Content-Type"]; !ok {
+			r.setHeader("Content-Type", offer)
+		}
+	}
+
+	s.response.selector = negotiatedSelector("Accept", variants, mediaTypeSpecificity, setContentType)
+}
+
+// RespondByLanguage sets the response variants selected by the request Accept-Language header.
+// The keys are language tags and each variant includes its language as Content-Language header.
+// A requested language falls back to its base language (en-US to en) and vice versa (en to en-GB).
+// When the request does not accept any variant, the response will be 406 Not Acceptable.
+//
+// Example:
+//
+//	RespondByLanguage(map[string]Response{
+//		"en": {WithBody("hello")},
+//		"es": {WithBody("hola")},
+//	})
+func (s *stub) RespondByLanguage(variants map[string]Response) {
+	setLanguage := func(r *stubResponse, offer string) {
+		r.setHeader("Content-Language", offer)
+	}
+
+	s.response.selector = negotiatedSelector("Accept-Language", variants, languageSpecificity, setLanguage)
+}
+
+// negotiatedSelector returns a response selector that picks the variant which best satisfies the given header.
+func negotiatedSelector(
+	header string,
+	variants map[string]Response,
+	specificity func(pattern, offer string) int,
+	decorate func(*stubResponse, string),
+) func(*stub, *http.Request) *stubResponse {
+	offers := slices.Sorted(maps.Keys(variants))
+	responses := make(map[string]*stubResponse, len(variants))
+
+	for _, offer := range offers {
+		response := variants[offer].build()
+		response.setHeader("Vary", header)
+		decorate(response, offer)
+
+		responses[offer] = response
+	}
+
+	notAcceptable := Response{WithStatusCode(http.StatusNotAcceptable), WithHeader("Vary", header)}.build()
+
+	return func(_ *stub, r *http.Request) *stubResponse {
+		offer, ok := negotiate(parseAccept(r.Header.Get(header)), offers, specificity)
+		if !ok {
+			return notAcceptable
+		}
+
+		return responses[offer]
+	}
+}
+
+type acceptValue struct {
+	value string
+	q     float64
+}
+
+// parseAccept parses an Accept-like header, e.g. "text/html, application/json;q=0.9".
+func parseAccept(header string) []acceptValue {
+	var values []acceptValue
+
+	for _, part := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+
+		q := 1.0
+
+		for _, param := range strings.Split(params, ";") {
+			key, paramValue, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if parsed, err := strconv.ParseFloat(paramValue, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		values = append(values, acceptValue{value: value, q: q})
+	}
+
+	return values
+}
+
+// negotiate returns the offer that best satisfies the accepted values. Offers are in preference order.
+// specificity returns how specific an accepted value matches an offer, or -1 if it does not match.
+func negotiate(accepted []acceptValue, offers []string, specificity func(pattern, offer string) int) (string, bool) {
+	if len(offers) == 0 {
+		return "", false
+	}
+
+	if len(accepted) == 0 {
+		return offers[0], true
+	}
+
+	best, bestQ, bestSpecificity := "", 0.0, -1
+
+	for _, offer := range offers {
+		q, offerSpecificity := 0.0, -1
+
+		for _, value := range accepted {
+			if spec := specificity(value.value, offer); spec > offerSpecificity {
+				q, offerSpecificity = value.q, spec
+			}
+		}
+
+		if q > bestQ || (q == bestQ && q > 0 && offerSpecificity > bestSpecificity) {
+			best, bestQ, bestSpecificity = offer, q, offerSpecificity
+		}
+	}
+
+	return best, bestQ > 0
+}
+
+// mediaTypeSpecificity matches media ranges like */*, text/* or text/html (parameters are ignored).
+func mediaTypeSpecificity(pattern, offer string) int {
+	patternType, patternSubtype, _ := strings.Cut(strings.ToLower(pattern), "/")
+	offerType, offerSubtype, _ := strings.Cut(strings.ToLower(offer), "/")
+
+	switch {
+	case patternType == "*" && patternSubtype == "*":
+		return 0
+	case patternType != offerType:
+		return -1
+	case patternSubtype == "*":
+		return 1
+	case patternSubtype == offerSubtype:
+		return 2
+	default:
+		return -1
+	}
+}
+
+// languageSpecificity matches language ranges like *, en, en-US (case-insensitive).
+func languageSpecificity(pattern, offer string) int {
+	pattern, offer = strings.ToLower(pattern), strings.ToLower(offer)
+
+	switch {
+	case pattern == offer:
+		return 3
+	case strings.HasPrefix(pattern, offer+"-"): // en-US requested, en offered
+		return 2
+	case strings.HasPrefix(offer, pattern+"-"): // en requested, en-GB offered
+		return 1
+	case pattern == "*":
+		return 0
+	default:
+		return -1
+	}
+}
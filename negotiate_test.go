@@ -0,0 +1,176 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestStub_RespondNegotiated(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const path = "/test/respond-negotiated"
+
+	server.Stub(http.MethodGet, mockaso.Path(path)).
+		RespondNegotiated(map[string]mockaso.Response{
+			"application/json": {mockaso.WithBody(`{"name":"john"}`)},
+			"application/xml":  {mockaso.WithBody(`<user><name>john</name></user>`)},
+			"text/plain": {
+				mockaso.WithHeader("Content-Type", "text/plain; charset=utf-8"),
+				mockaso.WithBody("john"),
+			},
+		})
+
+	testCases := map[string]struct {
+		accept              string
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		"should respond with exact media type": {
+			accept:              "application/xml",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/xml",
+			expectedBody:        `<user><name>john</name></user>`,
+		},
+		"should respond with the highest q-value": {
+			accept:              "application/xml;q=0.5, application/json;q=0.9",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        `{"name":"john"}`,
+		},
+		"should respond with the most specific range": {
+			accept:              "text/*;q=0.8, */*;q=0.1",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/plain; charset=utf-8",
+			expectedBody:        "john",
+		},
+		"should respond with the first variant when accept is any": {
+			accept:              "*/*",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        `{"name":"john"}`,
+		},
+		"should respond with the first variant when accept is missing": {
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        `{"name":"john"}`,
+		},
+		"should not respond with rejected variants": {
+			accept:              "application/json;q=0, */*;q=0.5",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/xml",
+			expectedBody:        `<user><name>john</name></user>`,
+		},
+		"should respond not acceptable when no variant is accepted": {
+			accept:         "image/png",
+			expectedStatus: http.StatusNotAcceptable,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq, _ := http.NewRequest(http.MethodGet, path, http.NoBody)
+			if tc.accept != "" {
+				httpReq.Header.Set("Accept", tc.accept)
+			}
+
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedStatus, httpResp.StatusCode)
+			assert.Equal(t, "Accept", httpResp.Header.Get("Vary"))
+
+			if tc.expectedContentType != "" {
+				assert.Equal(t, tc.expectedContentType, httpResp.Header.Get("Content-Type"))
+			}
+
+			assertBodyString(t, tc.expectedBody, httpResp)
+		})
+	}
+}
+
+func TestStub_RespondByLanguage(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const path = "/test/respond-by-language"
+
+	server.Stub(http.MethodGet, mockaso.Path(path)).
+		RespondByLanguage(map[string]mockaso.Response{
+			"en":    {mockaso.WithBody("hello")},
+			"es":    {mockaso.WithBody("hola")},
+			"pt-BR": {mockaso.WithBody("olá")},
+		})
+
+	testCases := map[string]struct {
+		acceptLanguage   string
+		expectedStatus   int
+		expectedLanguage string
+		expectedBody     string
+	}{
+		"should respond with exact language": {
+			acceptLanguage:   "es",
+			expectedStatus:   http.StatusOK,
+			expectedLanguage: "es",
+			expectedBody:     "hola",
+		},
+		"should respond with the highest q-value": {
+			acceptLanguage:   "fr, es;q=0.8, en;q=0.5",
+			expectedStatus:   http.StatusOK,
+			expectedLanguage: "es",
+			expectedBody:     "hola",
+		},
+		"should fallback to base language": {
+			acceptLanguage:   "en-US",
+			expectedStatus:   http.StatusOK,
+			expectedLanguage: "en",
+			expectedBody:     "hello",
+		},
+		"should respond with regional variant of base language": {
+			acceptLanguage:   "pt",
+			expectedStatus:   http.StatusOK,
+			expectedLanguage: "pt-BR",
+			expectedBody:     "olá",
+		},
+		"should respond with first language when accept language is missing": {
+			expectedStatus:   http.StatusOK,
+			expectedLanguage: "en",
+			expectedBody:     "hello",
+		},
+		"should respond not acceptable when no language is accepted": {
+			acceptLanguage: "fr, de;q=0.5",
+			expectedStatus: http.StatusNotAcceptable,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq, _ := http.NewRequest(http.MethodGet, path, http.NoBody)
+			if tc.acceptLanguage != "" {
+				httpReq.Header.Set("Accept-Language", tc.acceptLanguage)
+			}
+
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedStatus, httpResp.StatusCode)
+			assert.Equal(t, tc.expectedLanguage, httpResp.Header.Get("Content-Language"))
+			assert.Equal(t, "Accept-Language", httpResp.Header.Get("Vary"))
+			assertBodyString(t, tc.expectedBody, httpResp)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package mockaso
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OAuth2Options configures the OAuth2 token endpoint. See Server.OAuth2TokenEndpoint.
+type OAuth2Options struct {
+	Path          string        // token endpoint path, default /oauth/token
+	ClientID      string        // client id for the client_credentials grant, any client is accepted if empty
+	ClientSecret  string        // client secret for the client_credentials grant
+	RefreshTokens []string      // refresh tokens accepted by the refresh_token grant, besides the issued ones
+	Scope         string        // scope included in the token response, if any
+	ExpiresIn     time.Duration // access tokens lifetime, default 1 hour
+}
+
+// OAuth2Endpoint is a mocked OAuth2 token endpoint which keeps track of the issued tokens.
+type OAuth2Endpoint struct {
+	opts          OAuth2Options
+	accessTokens  map[string]time.Time // expiration time by token
+	refreshTokens map[string]bool
+	clock         Clock
+	mutex         sync.Mutex
+}
+
+// OAuth2TokenEndpoint stubs an OAuth2 token endpoint (RFC 6749) supporting the client_credentials and
+// refresh_token grant types. Client credentials are accepted with Basic auth or as form fields.
+// Error responses follow the RFC: invalid_request, invalid_client, invalid_grant and unsupported_grant_type.
+// The client_credentials grant issues an access token, the refresh_token grant issues an access token and a new
+// refresh token. Issued access tokens expire after the configured lifetime, measured with the server Clock
+// (see WithClock).
+func (s *Server) OAuth2TokenEndpoint(opts OAuth2Options) *OAuth2Endpoint {
+	if opts.Path == "" {
+		opts.Path = "/oauth/token"
+	}
+
+	if opts.ExpiresIn == 0 {
+		opts.ExpiresIn = time.Hour
+	}
+
+	endpoint := &OAuth2Endpoint{
+		opts:          opts,
+		accessTokens:  make(map[string]time.Time),
+		refreshTokens: make(map[string]bool),
+		clock:         s.clock,
+	}
+
+	for _, token := range opts.RefreshTokens {
+		endpoint.refreshTokens[token] = true
+	}
+
+	s.Stub(http.MethodPost, Path(opts.Path)).Respond(func(r *stubResponse) {
+		r.selector = func(_ *stub, req *http.Request) *stubResponse {
+			return endpoint.token(req)
+		}
+	})
+
+	return endpoint
+}
+
+// ValidToken reports whether the access token was issued by the endpoint and it is not expired.
+func (e *OAuth2Endpoint) ValidToken(token string) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	expiration, ok := e.accessTokens[token]
+
+	return ok && e.clock.Now().Before(expiration)
+}
+
+// ExpireTokens expires all the issued access tokens.
+func (e *OAuth2Endpoint) ExpireTokens() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for token := range e.accessTokens {
+		e.accessTokens[token] = time.Time{}
+	}
+}
+
+// MatchToken sets a rule to match the http request with a valid access token issued by the endpoint,
+// sent as Authorization: Bearer <token>.
+func (e *OAuth2Endpoint) MatchToken() StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		token, ok := bearerToken(r)
+		return ok && e.ValidToken(token)
+	})
+
+	return describedRule(matcher, "token mismatch")
+}
+
+func (e *OAuth2Endpoint) token(r *http.Request) *stubResponse {
+	form, err := url.ParseQuery(string(mustReadDecodedBody(r)))
+	if err != nil {
+		return oauth2Error(http.StatusBadRequest, "invalid_request", "malformed form body")
+	}
+
+	switch form.Get("grant_type") {
+	case "client_credentials":
+		if !e.validClient(r, form) {
+			return oauth2Error(http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		}
+
+		return e.issue(false)
+	case "refresh_token":
+		if !e.validRefreshToken(form.Get("refresh_token")) {
+			return oauth2Error(http.StatusBadRequest, "invalid_grant", "invalid refresh token")
+		}
+
+		return e.issue(true)
+	case "":
+		return oauth2Error(http.StatusBadRequest, "invalid_request", "missing grant_type")
+	default:
+		return oauth2Error(http.StatusBadRequest, "unsupported_grant_type", "unsupported grant type")
+	}
+}
+
+func (e *OAuth2Endpoint) validClient(r *http.Request, form url.Values) bool {
+	if e.opts.ClientID == "" {
+		return true
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = form.Get("client_id"), form.Get("client_secret")
+	}
+
+	return clientID == e.opts.ClientID && clientSecret == e.opts.ClientSecret
+}
+
+func (e *OAuth2Endpoint) validRefreshToken(token string) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return token != "" && e.refreshTokens[token]
+}
+
+func (e *OAuth2Endpoint) issue(withRefreshToken bool) *stubResponse {
+	accessToken := randomHex(16)
+
+	body := map[string]any{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(e.opts.ExpiresIn.Seconds()),
+	}
+
+	if e.opts.Scope != "" {
+		body["scope"] = e.opts.Scope
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.accessTokens[accessToken] = e.clock.Now().Add(e.opts.ExpiresIn)
+
+	if withRefreshToken {
+		refreshToken := randomHex(16)
+		e.refreshTokens[refreshToken] = true
+		body["refresh_token"] = refreshToken
+	}
+
+	return Response{WithJSON(body), WithHeader("Cache-Control", "no-store")}.build()
+}
+
+func oauth2Error(statusCode int, code, description string) *stubResponse {
+	body := map[string]string{"error": code, "error_description": description}
+	return Response{WithStatusCode(statusCode), WithJSON(body)}.build()
+}
+
+// bearerToken returns the token of the Authorization: Bearer header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	return token, found && strings.EqualFold(scheme, "Bearer") && token != ""
+}
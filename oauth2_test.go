@@ -0,0 +1,231 @@
+package mockaso_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+type oauth2TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	Scope            string `json:"scope"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func TestServer_OAuth2TokenEndpoint(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	endpoint := server.OAuth2TokenEndpoint(mockaso.OAuth2Options{
+		ClientID:      "my-client",
+		ClientSecret:  "my-secret",
+		RefreshTokens: []string{"initial-refresh-token"},
+		Scope:         "read write",
+		ExpiresIn:     30 * time.Minute,
+	})
+
+	server.Stub(http.MethodGet, mockaso.Path("/api/protected")).
+		Match(endpoint.MatchToken()).
+		Respond(mockaso.WithBody("protected"))
+
+	requestToken := func(t *testing.T, form url.Values, basicAuth bool) (*http.Response, oauth2TokenResponse) {
+		t.Helper()
+
+		httpReq, _ := http.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+		httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		if basicAuth {
+			httpReq.SetBasicAuth("my-client", "my-secret")
+		}
+
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		var tokenResp oauth2TokenResponse
+		require.NoError(t, json.NewDecoder(httpResp.Body).Decode(&tokenResp))
+
+		return httpResp, tokenResp
+	}
+
+	t.Run("should issue access token with client credentials grant", func(t *testing.T) {
+		t.Parallel()
+
+		testCases := map[string]struct {
+			form      url.Values
+			basicAuth bool
+		}{
+			"credentials in basic auth": {
+				form:      url.Values{"grant_type": {"client_credentials"}},
+				basicAuth: true,
+			},
+			"credentials in form": {
+				form: url.Values{
+					"grant_type":    {"client_credentials"},
+					"client_id":     {"my-client"},
+					"client_secret": {"my-secret"},
+				},
+			},
+		}
+
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				httpResp, tokenResp := requestToken(t, tc.form, tc.basicAuth)
+
+				assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+				assert.Equal(t, "application/json", httpResp.Header.Get("Content-Type"))
+				assert.Equal(t, "no-store", httpResp.Header.Get("Cache-Control"))
+				assert.NotEmpty(t, tokenResp.AccessToken)
+				assert.Equal(t, "Bearer", tokenResp.TokenType)
+				assert.Equal(t, 1800, tokenResp.ExpiresIn)
+				assert.Equal(t, "read write", tokenResp.Scope)
+				assert.Empty(t, tokenResp.RefreshToken)
+				assert.True(t, endpoint.ValidToken(tokenResp.AccessToken))
+			})
+		}
+	})
+
+	t.Run("should issue access and refresh token with refresh token grant", func(t *testing.T) {
+		t.Parallel()
+
+		form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {"initial-refresh-token"}}
+		httpResp, tokenResp := requestToken(t, form, true)
+
+		require.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assert.NotEmpty(t, tokenResp.AccessToken)
+		require.NotEmpty(t, tokenResp.RefreshToken)
+
+		form = url.Values{"grant_type": {"refresh_token"}, "refresh_token": {tokenResp.RefreshToken}}
+		httpResp, _ = requestToken(t, form, true)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+	})
+
+	t.Run("should respond with error", func(t *testing.T) {
+		t.Parallel()
+
+		testCases := map[string]struct {
+			form           url.Values
+			basicAuth      bool
+			expectedStatus int
+			expectedError  string
+		}{
+			"when client credentials are invalid": {
+				form:           url.Values{"grant_type": {"client_credentials"}, "client_id": {"other-client"}},
+				expectedStatus: http.StatusUnauthorized,
+				expectedError:  "invalid_client",
+			},
+			"when refresh token is invalid": {
+				form:           url.Values{"grant_type": {"refresh_token"}, "refresh_token": {"unknown"}},
+				basicAuth:      true,
+				expectedStatus: http.StatusBadRequest,
+				expectedError:  "invalid_grant",
+			},
+			"when grant type is not supported": {
+				form:           url.Values{"grant_type": {"password"}},
+				basicAuth:      true,
+				expectedStatus: http.StatusBadRequest,
+				expectedError:  "unsupported_grant_type",
+			},
+			"when grant type is missing": {
+				form:           url.Values{},
+				basicAuth:      true,
+				expectedStatus: http.StatusBadRequest,
+				expectedError:  "invalid_request",
+			},
+		}
+
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				httpResp, tokenResp := requestToken(t, tc.form, tc.basicAuth)
+
+				assert.Equal(t, tc.expectedStatus, httpResp.StatusCode)
+				assert.Equal(t, tc.expectedError, tokenResp.Error)
+				assert.NotEmpty(t, tokenResp.ErrorDescription)
+			})
+		}
+	})
+
+	t.Run("should match requests with valid issued tokens", func(t *testing.T) {
+		t.Parallel()
+
+		_, tokenResp := requestToken(t, url.Values{"grant_type": {"client_credentials"}}, true)
+
+		httpReq, _ := http.NewRequest(http.MethodGet, "/api/protected", http.NoBody)
+		httpReq.Header.Set("Authorization", "Bearer "+tokenResp.AccessToken)
+
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assertBodyString(t, "protected", httpResp)
+
+		httpReq.Header.Set("Authorization", "Bearer unknown-token")
+
+		httpResp, err = server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assertNotMatchedResponse(t, httpReq, httpResp)
+	})
+}
+
+func TestOAuth2Endpoint_ExpireTokens(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	endpoint := server.OAuth2TokenEndpoint(mockaso.OAuth2Options{Path: "/token"})
+
+	t.Run("should expire the issued tokens", func(t *testing.T) {
+		form := url.Values{"grant_type": {"client_credentials"}}
+		httpResp, err := server.Client().PostForm("/token", form)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, httpResp.StatusCode)
+
+		var tokenResp oauth2TokenResponse
+		require.NoError(t, json.NewDecoder(httpResp.Body).Decode(&tokenResp))
+		require.True(t, endpoint.ValidToken(tokenResp.AccessToken))
+
+		endpoint.ExpireTokens()
+
+		assert.False(t, endpoint.ValidToken(tokenResp.AccessToken))
+	})
+}
+
+func TestServer_OAuth2TokenEndpoint_Clock(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Now()}
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithClock(clock))
+	t.Cleanup(server.MustShutdown)
+
+	endpoint := server.OAuth2TokenEndpoint(mockaso.OAuth2Options{ExpiresIn: time.Minute})
+
+	httpResp, err := server.Client().PostForm("/oauth/token", url.Values{"grant_type": {"client_credentials"}})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, httpResp.StatusCode)
+
+	var tokenResp oauth2TokenResponse
+	require.NoError(t, json.NewDecoder(httpResp.Body).Decode(&tokenResp))
+
+	clock.Advance(59 * time.Second)
+	assert.True(t, endpoint.ValidToken(tokenResp.AccessToken), "the token is not expired yet")
+
+	clock.Advance(time.Second)
+	assert.False(t, endpoint.ValidToken(tokenResp.AccessToken), "the token expires with the server clock")
+}
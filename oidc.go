@@ -0,0 +1,173 @@
+package mockaso
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	oidcDiscoveryPath = "/.well-known/openid-configuration"
+	oidcJWKSPath      = "/.well-known/jwks.json"
+)
+
+// OIDCOptions configures the OIDC provider. See Server.OIDCProvider.
+type OIDCOptions struct {
+	KeyID     string        // key id of the signing key, default "mockaso"
+	Audience  string        // default audience (aud) of the minted tokens, if any
+	ExpiresIn time.Duration // default lifetime of the minted tokens, default 1 hour
+
+	// TokenEndpoint, if set, stubs an OAuth2 token endpoint which is advertised in the discovery document.
+	// See Server.OAuth2TokenEndpoint.
+	TokenEndpoint *OAuth2Options
+}
+
+// OIDCProvider is a mocked OpenID Connect provider that signs tokens with a generated RSA key (RS256).
+type OIDCProvider struct {
+	server        *Server
+	opts          OIDCOptions
+	key           *rsa.PrivateKey
+	tokenEndpoint *OAuth2Endpoint
+}
+
+// OIDCProvider stubs the OpenID Connect discovery document (/.well-known/openid-configuration) and the JWKS
+// endpoint (/.well-known/jwks.json) with the public key of a generated key pair. The issuer is the server URL.
+// Use MintToken to create signed JWTs that validate against the published keys. The discovery document only
+// advertises the stubbed endpoints, i.e. the token endpoint if OIDCOptions.TokenEndpoint is set.
+func (s *Server) OIDCProvider(opts OIDCOptions) *OIDCProvider {
+	if opts.KeyID == "" {
+		opts.KeyID = "mockaso"
+	}
+
+	if opts.ExpiresIn == 0 {
+		opts.ExpiresIn = time.Hour
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(fmt.Errorf("OIDCProvider err: generate key failed: %w", err))
+	}
+
+	provider := &OIDCProvider{server: s, opts: opts, key: key}
+
+	if opts.TokenEndpoint != nil {
+		provider.tokenEndpoint = s.OAuth2TokenEndpoint(*opts.TokenEndpoint)
+	}
+
+	s.Stub(http.MethodGet, Path(oidcDiscoveryPath)).Respond(withJSONFunc(provider.discovery))
+	s.Stub(http.MethodGet, Path(oidcJWKSPath)).Respond(withJSONFunc(provider.jwks))
+
+	return provider
+}
+
+// TokenEndpoint returns the OAuth2 token endpoint of the provider, or nil if OIDCOptions.TokenEndpoint is not set.
+func (p *OIDCProvider) TokenEndpoint() *OAuth2Endpoint {
+	return p.tokenEndpoint
+}
+
+// Issuer returns the issuer (iss) of the provider, that is the server URL.
+func (p *OIDCProvider) Issuer() string {
+	return p.server.URL()
+}
+
+// PublicKey returns the public key used to verify the minted tokens.
+func (p *OIDCProvider) PublicKey() *rsa.PublicKey {
+	return &p.key.PublicKey
+}
+
+// MintToken returns a JWT signed by the provider with the given claims.
+// The iss, iat, exp and aud (if configured) claims are set unless they are specified. The iat and exp claims use
+// the server Clock (see WithClock).
+func (p *OIDCProvider) MintToken(claims map[string]any) string {
+	now := p.server.clock.Now()
+
+	payload := map[string]any{
+		"iss": p.Issuer(),
+		"iat": now.Unix(),
+		"exp": now.Add(p.opts.ExpiresIn).Unix(),
+	}
+
+	if p.opts.Audience != "" {
+		payload["aud"] = p.opts.Audience
+	}
+
+	for k, v := range claims {
+		payload[k] = v
+	}
+
+	header := map[string]any{"alg": "RS256", "typ": "JWT", "kid": p.opts.KeyID}
+
+	signingInput := encodeJWTPart(header) + "." + encodeJWTPart(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(fmt.Errorf("MintToken err: sign failed: %w", err))
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (p *OIDCProvider) discovery() any {
+	issuer := strings.TrimSuffix(p.Issuer(), "/")
+
+	document := map[string]any{
+		"issuer":                                issuer,
+		"jwks_uri":                              issuer + oidcJWKSPath,
+		"response_types_supported":              []string{"id_token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	}
+
+	if p.tokenEndpoint != nil {
+		document["token_endpoint"] = issuer + p.tokenEndpoint.opts.Path
+		document["grant_types_supported"] = []string{"client_credentials", "refresh_token"}
+	}
+
+	return document
+}
+
+func (p *OIDCProvider) jwks() any {
+	key := map[string]string{
+		"kty": "RSA",
+		"use": "sig",
+		"alg": "RS256",
+		"kid": p.opts.KeyID,
+		"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+	}
+
+	return map[string]any{"keys": []any{key}}
+}
+
+func encodeJWTPart(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Errorf("marshal JWT failed: %w", err))
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// withJSONFunc sets the response content with the marshal output of the value returned by fn on every request.
+func withJSONFunc(fn func() any) StubResponseRule {
+	return func(r *stubResponse) {
+		r.setJSON(nil)
+		r.bodyFunc = func(*stub, *http.Request) []byte {
+			data, err := json.Marshal(fn())
+			if err != nil {
+				panic(fmt.Errorf("marshal body failed: %w", err))
+			}
+
+			return data
+		}
+	}
+}
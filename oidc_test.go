@@ -0,0 +1,166 @@
+package mockaso_test
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestServer_OIDCProvider(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	provider := server.OIDCProvider(mockaso.OIDCOptions{KeyID: "test-key", Audience: "my-api"})
+
+	t.Run("should serve the discovery document", func(t *testing.T) {
+		t.Parallel()
+
+		var discovery map[string]any
+		getJSON(t, server, "/.well-known/openid-configuration", &discovery)
+
+		assert.Equal(t, server.URL(), discovery["issuer"])
+		assert.Equal(t, server.URL()+"/.well-known/jwks.json", discovery["jwks_uri"])
+		assert.Equal(t, []any{"RS256"}, discovery["id_token_signing_alg_values_supported"])
+		assert.NotContains(t, discovery, "token_endpoint", "only the stubbed endpoints are advertised")
+		assert.NotContains(t, discovery, "authorization_endpoint")
+		assert.NotContains(t, discovery, "userinfo_endpoint")
+		assert.Nil(t, provider.TokenEndpoint())
+	})
+
+	t.Run("should mint tokens verifiable with the published keys", func(t *testing.T) {
+		t.Parallel()
+
+		var jwks struct {
+			Keys []struct {
+				Kty string `json:"kty"`
+				Kid string `json:"kid"`
+				Alg string `json:"alg"`
+				N   string `json:"n"`
+				E   string `json:"e"`
+			} `json:"keys"`
+		}
+		getJSON(t, server, "/.well-known/jwks.json", &jwks)
+
+		require.Len(t, jwks.Keys, 1)
+		jwk := jwks.Keys[0]
+		assert.Equal(t, "RSA", jwk.Kty)
+		assert.Equal(t, "test-key", jwk.Kid)
+		assert.Equal(t, "RS256", jwk.Alg)
+
+		n, _ := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, _ := base64.RawURLEncoding.DecodeString(jwk.E)
+		publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		assert.True(t, publicKey.Equal(provider.PublicKey()))
+
+		token := provider.MintToken(map[string]any{"sub": "john", "scope": "read"})
+
+		parts := strings.Split(token, ".")
+		require.Len(t, parts, 3)
+
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature))
+
+		var header, claims map[string]any
+		decodeJWTPart(t, parts[0], &header)
+		decodeJWTPart(t, parts[1], &claims)
+
+		assert.Equal(t, map[string]any{"alg": "RS256", "typ": "JWT", "kid": "test-key"}, header)
+		assert.Equal(t, server.URL(), claims["iss"])
+		assert.Equal(t, "my-api", claims["aud"])
+		assert.Equal(t, "john", claims["sub"])
+		assert.Equal(t, "read", claims["scope"])
+		assert.InDelta(t, time.Now().Add(time.Hour).Unix(), claims["exp"], 5)
+	})
+
+	t.Run("should override default claims", func(t *testing.T) {
+		t.Parallel()
+
+		expired := time.Now().Add(-time.Minute).Unix()
+		token := provider.MintToken(map[string]any{"exp": expired, "aud": "other-api"})
+
+		var claims map[string]any
+		decodeJWTPart(t, strings.Split(token, ".")[1], &claims)
+
+		assert.EqualValues(t, expired, claims["exp"])
+		assert.Equal(t, "other-api", claims["aud"])
+	})
+}
+
+func TestServer_OIDCProvider_TokenEndpoint(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	provider := server.OIDCProvider(mockaso.OIDCOptions{TokenEndpoint: &mockaso.OAuth2Options{Path: "/token"}})
+
+	var discovery map[string]any
+	getJSON(t, server, "/.well-known/openid-configuration", &discovery)
+
+	assert.Equal(t, server.URL()+"/token", discovery["token_endpoint"])
+	assert.Equal(t, []any{"client_credentials", "refresh_token"}, discovery["grant_types_supported"])
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+
+	httpResp, err := server.Client().PostForm("/token", url.Values{"grant_type": {"client_credentials"}})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, httpResp.StatusCode)
+	require.NoError(t, json.NewDecoder(httpResp.Body).Decode(&token))
+
+	require.NotNil(t, provider.TokenEndpoint())
+	assert.True(t, provider.TokenEndpoint().ValidToken(token.AccessToken))
+}
+
+func TestOIDCProvider_MintToken_Clock(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithClock(clock))
+	t.Cleanup(server.MustShutdown)
+
+	provider := server.OIDCProvider(mockaso.OIDCOptions{ExpiresIn: time.Minute})
+
+	var claims map[string]any
+	decodeJWTPart(t, strings.Split(provider.MintToken(nil), ".")[1], &claims)
+
+	assert.InDelta(t, 1700000000, claims["iat"], 0, "the time claims use the server clock")
+	assert.InDelta(t, 1700000060, claims["exp"], 0)
+}
+
+func getJSON(t *testing.T, server *mockaso.Server, path string, v any) {
+	t.Helper()
+
+	httpReq, _ := http.NewRequest(http.MethodGet, path, http.NoBody)
+	httpResp, err := server.Client().Do(httpReq)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, httpResp.StatusCode)
+	require.Equal(t, "application/json", httpResp.Header.Get("Content-Type"))
+	require.NoError(t, json.NewDecoder(httpResp.Body).Decode(v))
+}
+
+func decodeJWTPart(t *testing.T, part string, v any) {
+	t.Helper()
+
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, v))
+}
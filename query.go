@@ -0,0 +1,95 @@
+package mockaso
+
+import (
+	"net/url"
+	"strings"
+)
+
+// QueryOption configures how query string matchers parse the request query string.
+type QueryOption func(*queryOptions)
+
+// DuplicateKeyPolicy sets which value of a query param sent more than once is used by the query string matchers.
+type DuplicateKeyPolicy int
+
+const (
+	DuplicateFirst  DuplicateKeyPolicy = iota // the first value is used (net/url behavior)
+	DuplicateLast                             // the last value is used
+	DuplicateAny                              // any of the values can match
+	DuplicateReject                           // the request does not match when the param is duplicated
+)
+
+type queryOptions struct {
+	semicolon  bool
+	duplicates DuplicateKeyPolicy
+}
+
+// SemicolonSeparator accepts ";" as query params separator besides "&", as legacy clients do.
+// By default net/url rejects the params containing a semicolon.
+func SemicolonSeparator() QueryOption {
+	return func(o *queryOptions) {
+		o.semicolon = true
+	}
+}
+
+// DuplicateKeys sets the policy for the query params sent more than once, e.g. ?id=1&id=2.
+func DuplicateKeys(policy DuplicateKeyPolicy) QueryOption {
+	return func(o *queryOptions) {
+		o.duplicates = policy
+	}
+}
+
+func newQueryOptions(opts []QueryOption) queryOptions {
+	var options queryOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options
+}
+
+// parse parses the raw query string. Unlike url.ParseQuery, malformed escapes are kept as they were sent.
+func (o queryOptions) parse(rawQuery string) url.Values {
+	values := make(url.Values)
+
+	separators := "&"
+	if o.semicolon {
+		separators += ";"
+	}
+
+	params := strings.FieldsFunc(rawQuery, func(r rune) bool { return strings.ContainsRune(separators, r) })
+
+	for _, param := range params {
+		if !o.semicolon && strings.Contains(param, ";") {
+			continue // same as net/url
+		}
+
+		key, value, _ := strings.Cut(param, "=")
+		values.Add(decodeQueryComponent(key), decodeQueryComponent(value))
+	}
+
+	return values
+}
+
+// match reports whether the value of the param matches according to the duplicate keys policy.
+func (o queryOptions) match(values []string, match func(string) bool) bool {
+	if len(values) == 0 {
+		return match("")
+	}
+
+	switch o.duplicates {
+	case DuplicateLast:
+		return match(values[len(values)-1])
+	case DuplicateAny:
+		for _, value := range values {
+			if match(value) {
+				return true
+			}
+		}
+
+		return false
+	case DuplicateReject:
+		return len(values) == 1 && match(values[0])
+	default:
+		return match(values[0])
+	}
+}
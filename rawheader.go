@@ -0,0 +1,134 @@
+package mockaso
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// rawHeaderField is a request header field as it was sent, before the name canonicalization of net/http.
+type rawHeaderField struct {
+	name  string
+	value string
+}
+
+// rawHeaderListener records the bytes read from its connections to recover the raw request headers.
+type rawHeaderListener struct {
+	net.Listener
+}
+
+func (l *rawHeaderListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return &rawHeaderConn{Conn: conn, recording: true}, nil
+}
+
+// rawHeaderConn records the bytes read while it is not serving a request, i.e. the request heads.
+type rawHeaderConn struct {
+	net.Conn
+	buff      bytes.Buffer
+	recording bool
+	mutex     sync.Mutex
+}
+
+func (c *rawHeaderConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.recording {
+		c.buff.Write(p[:n])
+	}
+
+	return n, err
+}
+
+// takeHeader returns the raw header fields of the given request and stops recording until resume is called.
+func (c *rawHeaderConn) takeHeader(r *http.Request) []rawHeaderField {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	data := c.buff.Bytes()
+	c.buff.Reset()
+	c.recording = false
+
+	start := bytes.Index(data, []byte(r.Method+" "+r.RequestURI+" "+r.Proto+"\r\n"))
+	if start < 0 {
+		return nil
+	}
+
+	head, _, _ := bytes.Cut(data[start:], []byte("\r\n\r\n"))
+	lines := strings.Split(string(head), "\r\n")[1:] // skip the request line
+	fields := make([]rawHeaderField, 0, len(lines))
+
+	for _, line := range lines {
+		if name, value, found := strings.Cut(line, ":"); found {
+			fields = append(fields, rawHeaderField{name: name, value: strings.TrimSpace(value)})
+		}
+	}
+
+	return fields
+}
+
+func (c *rawHeaderConn) resume() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.recording = true
+}
+
+type rawHeaderConnKey struct{}
+
+type rawHeaderKey struct{}
+
+func withRawHeaderConn(ctx context.Context, conn net.Conn) context.Context {
+	if c, ok := conn.(*rawHeaderConn); ok {
+		return context.WithValue(ctx, rawHeaderConnKey{}, c)
+	}
+
+	return ctx
+}
+
+// captureRawHeader returns the request with its raw header fields and a func to call once the request was served.
+func captureRawHeader(r *http.Request) (*http.Request, func()) {
+	conn, ok := r.Context().Value(rawHeaderConnKey{}).(*rawHeaderConn)
+	if !ok {
+		return r, func() {}
+	}
+
+	fields := conn.takeHeader(r)
+
+	return r.WithContext(context.WithValue(r.Context(), rawHeaderKey{}, fields)), conn.resume
+}
+
+// rawHeaderFields returns the header fields of the request as they were sent. When they were not captured,
+// e.g. HTTP/2 requests, they are taken from the request header (lowercase for HTTP/2, as sent on the wire).
+func rawHeaderFields(r *http.Request) []rawHeaderField {
+	if fields, ok := r.Context().Value(rawHeaderKey{}).([]rawHeaderField); ok && fields != nil {
+		return fields
+	}
+
+	var fields []rawHeaderField
+
+	for _, name := range slices.Sorted(maps.Keys(r.Header)) {
+		wireName := name
+		if r.ProtoMajor >= 2 {
+			wireName = strings.ToLower(name)
+		}
+
+		for _, value := range r.Header[name] {
+			fields = append(fields, rawHeaderField{name: wireName, value: value})
+		}
+	}
+
+	return fields
+}
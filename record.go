@@ -0,0 +1,325 @@
+package mockaso
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// recordSkippedHeaders are the response headers which are not recorded, since they are set when served.
+var recordSkippedHeaders = []string{"Content-Length", "Date", "Transfer-Encoding", "Connection", "Keep-Alive"}
+
+// RecordTo records the requests forwarded by the fallback proxy (see WithFallbackProxy) and their responses as stub
+// files in dir, to be loaded later with LoadStubsFromDir. It is the fastest way to bootstrap realistic fixtures.
+//
+// Each request is written to its own file, named by its order, method and path, e.g. 0001-get-api-users.json.
+// Files with the same name are overwritten. The request is matched by its method and URL, and by its body when it
+// is JSON. Non-text response bodies are written to a separate file, see the bodyFile response field.
+// Only the first value of each response header is recorded.
+//
+// The recorded requests can be selected with RecordInclude, RecordExclude and RecordMaxBodySize. The requests
+// which are not recorded are still forwarded.
+//
+// Example:
+//
+//	server.RecordTo("testdata/stubs",
+//		RecordInclude(RecordFilter{Path: "/api/*"}),
+//		RecordExclude(RecordFilter{Method: http.MethodGet, Path: "/api/health"}),
+//		RecordMaxBodySize(1<<20),
+//	)
+func (s *Server) RecordTo(dir string, opts ...RecordOption) error {
+	if s.fallback == nil {
+		return errors.New("RecordTo requires a fallback proxy, see WithFallbackProxy")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create record dir failed: %w", err)
+	}
+
+	rec := &recorder{dir: dir}
+
+	for _, opt := range opts {
+		opt(rec)
+	}
+
+	s.recorder.Store(rec)
+
+	return nil
+}
+
+// RecordOption sets an option of the recording, see RecordTo.
+type RecordOption func(*recorder)
+
+// RecordFilter selects requests to record by their host, path and method. Empty fields match any request.
+type RecordFilter struct {
+	Host   string // the request host, with or without port (case-insensitive)
+	Path   string // a path.Match pattern of the request path, e.g. "/api/*/orders"
+	Method string // the request method (case-insensitive)
+}
+
+// RecordInclude sets the recording to include only the requests which match any of the given filters.
+// It panics if a path pattern is not valid.
+func RecordInclude(filters ...RecordFilter) RecordOption {
+	validateRecordFilters("RecordInclude", filters)
+
+	return func(rec *recorder) {
+		rec.includes = append(rec.includes, filters...)
+	}
+}
+
+// RecordExclude sets the recording to exclude the requests which match any of the given filters, even if they are
+// included by RecordInclude. It panics if a path pattern is not valid.
+func RecordExclude(filters ...RecordFilter) RecordOption {
+	validateRecordFilters("RecordExclude", filters)
+
+	return func(rec *recorder) {
+		rec.excludes = append(rec.excludes, filters...)
+	}
+}
+
+// RecordMaxBodySize sets the recording to skip the requests whose request or response body is larger than n bytes,
+// e.g. file downloads.
+func RecordMaxBodySize(n int64) RecordOption {
+	if n <= 0 {
+		panic(fmt.Errorf("RecordMaxBodySize err: n must be positive, got %d", n))
+	}
+
+	return func(rec *recorder) {
+		rec.maxBodySize = n
+	}
+}
+
+func validateRecordFilters(name string, filters []RecordFilter) {
+	for _, filter := range filters {
+		if _, err := path.Match(filter.Path, ""); err != nil {
+			panic(fmt.Errorf("%s err: invalid path pattern %q: %w", name, filter.Path, err))
+		}
+	}
+}
+
+func (f RecordFilter) match(r *http.Request) bool {
+	if f.Host != "" && !strings.EqualFold(f.Host, r.Host) && !strings.EqualFold(f.Host, hostname(r.Host)) {
+		return false
+	}
+
+	if f.Path != "" {
+		if ok, _ := path.Match(f.Path, r.URL.Path); !ok { // validated when the filter was set
+			return false
+		}
+	}
+
+	return f.Method == "" || strings.EqualFold(f.Method, r.Method)
+}
+
+// serveFallback serves the request with the fallback proxy, and records it if RecordTo was called.
+func (s *Server) serveFallback(w http.ResponseWriter, r *http.Request) {
+	rec := s.recorder.Load()
+	if rec == nil {
+		s.fallback.ServeHTTP(w, r)
+		return
+	}
+
+	if !rec.selected(r) {
+		s.fallback.ServeHTTP(w, r)
+		return
+	}
+
+	reqBody, tooLarge := rec.readRequestBody(r)
+	rw := &recordingWriter{ResponseWriter: w, statusCode: http.StatusOK, maxBodySize: rec.maxBodySize}
+
+	s.fallback.ServeHTTP(rw, r)
+
+	if tooLarge || rw.tooLarge {
+		s.logger.Logf("%s %s not recorded: body larger than %d bytes", r.Method, r.URL.String(), rec.maxBodySize)
+		return
+	}
+
+	file, err := rec.record(r, reqBody, rw)
+	if err != nil {
+		s.logger.Logf("record %s %s failed: %v", r.Method, r.URL.String(), err)
+		return
+	}
+
+	s.logger.Logf("recorded %s %s to %s", r.Method, r.URL.String(), file)
+}
+
+// recorder writes the recorded requests as stub files.
+type recorder struct {
+	dir         string
+	count       atomic.Int64
+	includes    []RecordFilter
+	excludes    []RecordFilter
+	maxBodySize int64 // the requests with larger bodies are not recorded, if set
+}
+
+// selected reports whether the request passes the include and exclude filters.
+func (rec *recorder) selected(r *http.Request) bool {
+	included := len(rec.includes) == 0
+
+	for _, filter := range rec.includes {
+		if filter.match(r) {
+			included = true
+			break
+		}
+	}
+
+	if !included {
+		return false
+	}
+
+	for _, filter := range rec.excludes {
+		if filter.match(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// readRequestBody reads the request body to be recorded, leaving it readable for the proxy. When the body is larger
+// than the max body size, it is not buffered beyond the limit and tooLarge is true.
+func (rec *recorder) readRequestBody(r *http.Request) (body []byte, tooLarge bool) {
+	if rec.maxBodySize == 0 {
+		return mustReadBody(r), false
+	}
+
+	if r.ContentLength > rec.maxBodySize {
+		return nil, true
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, rec.maxBodySize+1))
+	if err != nil {
+		panic(fmt.Errorf("read request body failed: %w", err))
+	}
+
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+
+	return data, int64(len(data)) > rec.maxBodySize
+}
+
+func (rec *recorder) record(r *http.Request, reqBody []byte, rw *recordingWriter) (string, error) {
+	name := fmt.Sprintf("%04d-%s", rec.count.Add(1), recordName(r))
+
+	def := stubDefinition{
+		Request:  requestDefinition{Method: r.Method, URL: r.URL.RequestURI()},
+		Response: responseDefinition{Status: rw.statusCode, Headers: make(map[string]string)},
+	}
+
+	if len(reqBody) > 0 && json.Valid(reqBody) {
+		def.Request.Body = reqBody
+	}
+
+	for key, values := range rw.Header() {
+		if len(values) > 0 && !containsFold(recordSkippedHeaders, key) {
+			def.Response.Headers[key] = values[0]
+		}
+	}
+
+	body := rw.body.Bytes()
+
+	switch {
+	case len(body) == 0:
+	case json.Valid(body) && rw.Header().Get("Content-Encoding") == "":
+		def.Response.JSON = body
+	case utf8.Valid(body) && rw.Header().Get("Content-Encoding") == "":
+		def.Response.Body = string(body)
+	default:
+		def.Response.BodyFile = name + ".body"
+
+		if err := os.WriteFile(filepath.Join(rec.dir, def.Response.BodyFile), body, 0o600); err != nil {
+			return "", fmt.Errorf("write body file failed: %w", err)
+		}
+	}
+
+	var buff bytes.Buffer
+
+	encoder := json.NewEncoder(&buff)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(stubFile{Version: StubFileVersion, Stubs: []stubDefinition{def}}); err != nil {
+		return "", fmt.Errorf("encode stub file failed: %w", err)
+	}
+
+	path := filepath.Join(rec.dir, name+".json")
+
+	if err := os.WriteFile(path, buff.Bytes(), 0o600); err != nil {
+		return "", fmt.Errorf("write stub file failed: %w", err)
+	}
+
+	return path, nil
+}
+
+// recordName returns a file name for the request made of its method and path, e.g. get-api-users.
+func recordName(r *http.Request) string {
+	name := strings.Map(func(c rune) rune {
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' {
+			return c
+		}
+
+		return '-'
+	}, strings.ToLower(r.Method+" "+r.URL.Path))
+
+	for strings.Contains(name, "--") {
+		name = strings.ReplaceAll(name, "--", "-")
+	}
+
+	return strings.Trim(name, "-")
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recordingWriter is a http.ResponseWriter that keeps a copy of the response status and body. The body is no longer
+// kept once it is larger than maxBodySize, if set, and the response is then too large to be recorded.
+type recordingWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	body        bytes.Buffer
+	maxBodySize int64
+	tooLarge    bool
+}
+
+func (w *recordingWriter) WriteHeader(statusCode int) {
+	if statusCode >= http.StatusOK { // informational responses are not the response
+		w.statusCode = statusCode
+	}
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	switch {
+	case w.tooLarge:
+	case w.maxBodySize > 0 && int64(w.body.Len()+len(data)) > w.maxBodySize:
+		w.tooLarge = true
+		w.body = bytes.Buffer{} // release the buffered body
+	default:
+		w.body.Write(data)
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
+// Unwrap allows http.ResponseController to access the underlying writer, e.g. to flush the response.
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,218 @@
+package mockaso_test
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestServer_RecordTo(t *testing.T) {
+	t.Parallel()
+
+	upstream := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(upstream.MustShutdown)
+
+	upstream.Stub(http.MethodPost, mockaso.URL("/api/users?notify=true")).
+		Match(mockaso.MatchRawJSONBody(`{"name":"john"}`)).
+		Respond(
+			mockaso.WithStatusCode(http.StatusCreated),
+			mockaso.WithRawJSON(`{"id":1,"name":"john"}`),
+			mockaso.WithHeader("X-Request-Id", "abc"),
+		)
+
+	upstream.Stub(http.MethodGet, mockaso.Path("/api/health")).Respond(mockaso.WithBody("ok"))
+	upstream.Stub(http.MethodGet, mockaso.Path("/api/avatar")).Respond(mockaso.WithBody([]byte{0xff, 0xd8, 0xff}))
+
+	dir := t.TempDir()
+
+	proxy := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithFallbackProxy(upstream.URL()))
+	t.Cleanup(proxy.MustShutdown)
+
+	require.NoError(t, proxy.RecordTo(dir))
+
+	httpResp, err := proxy.Client().Post("/api/users?notify=true", "application/json",
+		strings.NewReader(`{"name":"john"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, httpResp.StatusCode)
+
+	_, err = proxy.Client().Get("/api/health")
+	require.NoError(t, err)
+
+	_, err = proxy.Client().Get("/api/avatar")
+	require.NoError(t, err)
+
+	t.Run("should write a stub file per request", func(t *testing.T) {
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+
+		expectedNames := []string{
+			"0001-post-api-users.json",
+			"0002-get-api-health.json",
+			"0003-get-api-avatar.body",
+			"0003-get-api-avatar.json",
+		}
+		assert.Equal(t, expectedNames, names)
+	})
+
+	t.Run("should replay the recorded responses", func(t *testing.T) {
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		require.NoError(t, server.LoadStubsFromDir(dir))
+
+		httpResp, err := server.Client().Post("/api/users?notify=true", "application/json",
+			strings.NewReader(`{"name": "john"}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, httpResp.StatusCode)
+		assert.Equal(t, "abc", httpResp.Header.Get("X-Request-Id"))
+		assert.Equal(t, "application/json", httpResp.Header.Get("Content-Type"))
+		assertBodyString(t, `{"id":1,"name":"john"}`, httpResp)
+
+		httpResp, err = server.Client().Get("/api/health")
+		require.NoError(t, err)
+		assertBodyString(t, "ok", httpResp)
+
+		httpResp, err = server.Client().Get("/api/avatar")
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(httpResp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0xff, 0xd8, 0xff}, body)
+	})
+
+	t.Run("should fail without a fallback proxy", func(t *testing.T) {
+		err := mockaso.NewServer().RecordTo(t.TempDir())
+		assert.EqualError(t, err, "RecordTo requires a fallback proxy, see WithFallbackProxy")
+	})
+}
+
+func TestServer_RecordTo_Filters(t *testing.T) {
+	t.Parallel()
+
+	upstream := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(upstream.MustShutdown)
+
+	upstream.Stub(http.MethodPost, mockaso.Path("/api/users")).Respond(mockaso.WithRawJSON(`{"id":1}`))
+	upstream.Stub(http.MethodGet, mockaso.Path("/api/health")).Respond(mockaso.WithBody("ok"))
+	upstream.Stub(http.MethodGet, mockaso.Path("/static/logo")).Respond(mockaso.WithBody(strings.Repeat("x", 100)))
+
+	testCases := map[string]struct {
+		opts          []mockaso.RecordOption
+		expectedNames []string
+	}{
+		"should record every request without options": {
+			expectedNames: []string{"0001-post-api-users.json", "0002-get-api-health.json", "0003-get-static-logo.json"},
+		},
+		"should record the included paths": {
+			opts:          []mockaso.RecordOption{mockaso.RecordInclude(mockaso.RecordFilter{Path: "/api/*"})},
+			expectedNames: []string{"0001-post-api-users.json", "0002-get-api-health.json"},
+		},
+		"should record the included methods": {
+			opts:          []mockaso.RecordOption{mockaso.RecordInclude(mockaso.RecordFilter{Method: "post"})},
+			expectedNames: []string{"0001-post-api-users.json"},
+		},
+		"should record the included hosts": {
+			opts: []mockaso.RecordOption{mockaso.RecordInclude(
+				mockaso.RecordFilter{Host: "example.com"},
+				mockaso.RecordFilter{Host: "127.0.0.1", Method: http.MethodGet},
+			)},
+			expectedNames: []string{"0001-get-api-health.json", "0002-get-static-logo.json"},
+		},
+		"should not record the excluded requests": {
+			opts: []mockaso.RecordOption{
+				mockaso.RecordInclude(mockaso.RecordFilter{Path: "/api/*"}),
+				mockaso.RecordExclude(mockaso.RecordFilter{Method: http.MethodGet, Path: "/api/health"}),
+			},
+			expectedNames: []string{"0001-post-api-users.json"},
+		},
+		"should not record bodies larger than the max size": {
+			opts:          []mockaso.RecordOption{mockaso.RecordMaxBodySize(50)},
+			expectedNames: []string{"0001-post-api-users.json", "0002-get-api-health.json"},
+		},
+		"should not record request bodies larger than the max size": {
+			opts:          []mockaso.RecordOption{mockaso.RecordMaxBodySize(10)},
+			expectedNames: []string{"0001-get-api-health.json"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+
+			proxy := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithFallbackProxy(upstream.URL()))
+			t.Cleanup(proxy.MustShutdown)
+
+			require.NoError(t, proxy.RecordTo(dir, tc.opts...))
+
+			httpResp, err := proxy.Client().Post("/api/users", "application/json",
+				strings.NewReader(`{"name":"john"}`))
+			require.NoError(t, err)
+			assertBodyString(t, `{"id":1}`, httpResp)
+
+			httpResp, err = proxy.Client().Get("/api/health")
+			require.NoError(t, err)
+			assertBodyString(t, "ok", httpResp) // requests which are not recorded are still forwarded
+
+			_, err = proxy.Client().Get("/static/logo")
+			require.NoError(t, err)
+
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+
+			var names []string
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+
+			assert.Equal(t, tc.expectedNames, names)
+		})
+	}
+
+	t.Run("should forward request bodies larger than the max size of unknown length", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		body := strings.Repeat("x", 100)
+
+		upstream.Stub(http.MethodPost, mockaso.Path("/api/upload")).
+			Match(mockaso.MatchBodyStringFunc(func(s string) bool { return s == body })).
+			Respond(mockaso.WithBody("uploaded"))
+
+		proxy := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithFallbackProxy(upstream.URL()))
+		t.Cleanup(proxy.MustShutdown)
+
+		require.NoError(t, proxy.RecordTo(dir, mockaso.RecordMaxBodySize(10)))
+
+		chunked := struct{ io.Reader }{strings.NewReader(body)} // not a known length reader
+		httpResp, err := proxy.Client().Post("/api/upload", "text/plain", chunked)
+		require.NoError(t, err)
+		assertBodyString(t, "uploaded", httpResp)
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("should panic with an invalid path pattern", func(t *testing.T) {
+		t.Parallel()
+
+		assert.PanicsWithError(t, `RecordInclude err: invalid path pattern "/api/[": syntax error in pattern`, func() {
+			mockaso.RecordInclude(mockaso.RecordFilter{Path: "/api/["})
+		})
+	})
+}
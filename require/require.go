@@ -0,0 +1,75 @@
+// Package require provides the mockaso verifications which stop the test on failure,
+// like the testify require package does for its assertions.
+package require
+
+import (
+	"github.com/royhq/mockaso"
+	"github.com/royhq/mockaso/mockasotest"
+)
+
+// TestingT is the interface used to report failures and stop the test. Intended for use with testing.T.
+type TestingT interface {
+	mockaso.TestingT
+	FailNow()
+}
+
+type tHelper interface {
+	Helper()
+}
+
+// Called requires that the stub matched at least one request. See Stub.AssertCalled.
+func Called(t TestingT, st mockaso.Stub) {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	if !st.AssertCalled(t) {
+		t.FailNow()
+	}
+}
+
+// CalledTimes requires that the stub matched exactly n requests. See Stub.AssertCalledTimes.
+func CalledTimes(t TestingT, st mockaso.Stub, n int) {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	if !st.AssertCalledTimes(t, n) {
+		t.FailNow()
+	}
+}
+
+// NotCalled requires that the stub did not match any request. See Stub.AssertNotCalled.
+func NotCalled(t TestingT, st mockaso.Stub) {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	if !st.AssertNotCalled(t) {
+		t.FailNow()
+	}
+}
+
+// NoUnmatched requires that every request received by the server matched a stub.
+// See Server.AssertNoUnmatched.
+func NoUnmatched(t TestingT, server *mockaso.Server) {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	if !server.AssertNoUnmatched(t) {
+		t.FailNow()
+	}
+}
+
+// BodyJSON requires that the body of the captured request is equal to the JSON of expected.
+// See mockasotest.AssertJSONRequest.
+func BodyJSON(t TestingT, captured *mockaso.CapturedRequest, expected any) {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	if !mockasotest.AssertJSONRequest(t, captured, expected) {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,105 @@
+package require_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/royhq/mockaso"
+	"github.com/royhq/mockaso/require"
+)
+
+// fakeT records the failures instead of failing the test.
+type fakeT struct {
+	errors []string
+	failed bool
+}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) FailNow() {
+	f.failed = true
+}
+
+func TestCalled(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	st := server.Stub(http.MethodGet, mockaso.Path("/users"))
+	st.Respond(mockaso.WithStatusCode(http.StatusOK))
+
+	fake := new(fakeT)
+	require.Called(fake, st)
+	assert.True(t, fake.failed)
+
+	_, err := server.Client().Get("/users")
+	assert.NoError(t, err)
+
+	fake = new(fakeT)
+	require.Called(fake, st)
+	assert.False(t, fake.failed)
+}
+
+func TestCalledTimes(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	st := server.Stub(http.MethodGet, mockaso.Path("/users"))
+	st.Respond(mockaso.WithStatusCode(http.StatusOK))
+
+	fake := new(fakeT)
+	require.NotCalled(fake, st)
+	assert.False(t, fake.failed)
+
+	_, err := server.Client().Get("/users")
+	assert.NoError(t, err)
+
+	require.CalledTimes(fake, st, 1)
+	assert.False(t, fake.failed)
+
+	require.NotCalled(fake, st)
+	assert.True(t, fake.failed)
+
+	fake = new(fakeT)
+	require.CalledTimes(fake, st, 2)
+	assert.True(t, fake.failed)
+}
+
+func TestNoUnmatched(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	fake := new(fakeT)
+	require.NoUnmatched(fake, server)
+	assert.False(t, fake.failed)
+
+	_, err := server.Client().Get("/users")
+	assert.NoError(t, err)
+
+	require.NoUnmatched(fake, server)
+	assert.True(t, fake.failed)
+}
+
+func TestBodyJSON(t *testing.T) {
+	t.Parallel()
+
+	captured := &mockaso.CapturedRequest{Body: []byte(`{"name":"john"}`)}
+
+	fake := new(fakeT)
+	require.BodyJSON(fake, captured, map[string]string{"name": "john"})
+	assert.False(t, fake.failed)
+
+	require.BodyJSON(fake, captured, map[string]string{"name": "rick"})
+	assert.True(t, fake.failed)
+	assert.Len(t, fake.errors, 1)
+}
@@ -1,14 +1,48 @@
 package mockaso
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
+	"maps"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 type StubResponseRule func(*stubResponse)
 
+// Response is a set of response rules.
+type Response []StubResponseRule
+
+func (rules Response) build() *stubResponse {
+	r := newStubResponse()
+
+	for _, rule := range rules {
+		rule(r)
+	}
+
+	return r
+}
+
+// First returns the first response of a sequence. See StubResponder.RespondSequence.
+func First(rules ...StubResponseRule) Response {
+	return rules
+}
+
+// Then returns the next response of a sequence. See StubResponder.RespondSequence.
+func Then(rules ...StubResponseRule) Response {
+	return rules
+}
+
 // WithStatusCode sets the response status code.
 func WithStatusCode(statusCode int) StubResponseRule {
 	return func(r *stubResponse) {
@@ -17,6 +51,8 @@ func WithStatusCode(statusCode int) StubResponseRule {
 }
 
 // WithBody sets the response body.
+// An io.Reader is drained once, when WithBody is called, and its content is sent on every request.
+// Reusing the reader for another rule results in an empty body, use WithBodyReaderFunc instead.
 func WithBody(body any) StubResponseRule {
 	data, err := anyBodyToBytes(body)
 	if err != nil {
@@ -24,7 +60,32 @@ func WithBody(body any) StubResponseRule {
 	}
 
 	return func(r *stubResponse) {
-		r.body = data
+		r.setBody(data)
+	}
+}
+
+// WithBodyReaderFunc sets the response body read from the reader returned by fn, which is called on every request.
+// Useful for regenerated or streamed bodies. If the reader is an io.Closer, it is closed once read.
+func WithBodyReaderFunc(fn func() io.Reader) StubResponseRule {
+	bodyFunc := func(*stub, *http.Request) []byte {
+		reader := fn()
+
+		if closer, ok := reader.(io.Closer); ok {
+			defer closer.Close()
+		}
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			panic(fmt.Errorf("WithBodyReaderFunc err: failed to read body: %w", err))
+		}
+
+		return data
+	}
+
+	return func(r *stubResponse) {
+		r.body = nil
+		r.bodyFunc = bodyFunc
+		r.stream = nil
 	}
 }
 
@@ -55,6 +116,66 @@ func WithJSON(body any) StubResponseRule {
 	}
 }
 
+// WithYAML sets the response content with the gopkg.in/yaml.v3 marshal output of the given body.
+// The response will include the Content-Type:application/yaml header.
+func WithYAML(body any) StubResponseRule {
+	data, err := yaml.Marshal(body)
+	if err != nil {
+		panic(fmt.Errorf("WithYAML err: body marshal failed: %w", err))
+	}
+
+	return func(r *stubResponse) {
+		r.headers["Content-Type"] = "application/yaml"
+		r.setBody(data)
+	}
+}
+
+// WithBodyFile sets the response content with the content of the given file, so large fixtures can live in files,
+// e.g. WithBodyFile("testdata/user.json"). The file is read once, when the rule is created.
+// It panics if the file can not be read.
+func WithBodyFile(path string) StubResponseRule {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		panic(fmt.Errorf("WithBodyFile err: read file failed: %w", err))
+	}
+
+	return func(r *stubResponse) {
+		r.setBody(data)
+	}
+}
+
+// WithBodyFS sets the response content with the content of the named file of the file system, as WithBodyFile does,
+// e.g. to use fixtures embedded with go:embed regardless of the working directory.
+// It panics if the file can not be read.
+func WithBodyFS(fsys fs.FS, name string) StubResponseRule {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		panic(fmt.Errorf("WithBodyFS err: read file failed: %w", err))
+	}
+
+	return func(r *stubResponse) {
+		r.setBody(data)
+	}
+}
+
+// WithJSONFile sets the response content with the JSON of the given file, as WithBodyFile does.
+// The response will include the Content-Type:application/json header.
+// It panics if the file can not be read or its content is not valid JSON.
+func WithJSONFile(path string) StubResponseRule {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		panic(fmt.Errorf("WithJSONFile err: read file failed: %w", err))
+	}
+
+	if !json.Valid(data) {
+		panic(fmt.Errorf("WithJSONFile err: json is not valid: %s", path))
+	}
+
+	return func(r *stubResponse) {
+		r.setJSON(data)
+	}
+}
+
 // WithHeader sets a response header.
 // If the key already exists it will be overwritten.
 func WithHeader(key, value string) StubResponseRule {
@@ -72,6 +193,22 @@ func WithHeaders(headers map[string]string) StubResponseRule {
 	}
 }
 
+// WithCookie adds a Set-Cookie header to the response with the given cookie and its attributes, e.g. Path, MaxAge,
+// Secure, HttpOnly and SameSite. It can be repeated to set several cookies. It panics if the cookie is not valid.
+//
+// Example:
+//
+//	WithCookie(http.Cookie{Name: "session", Value: "abc", Path: "/", HttpOnly: true, SameSite: http.SameSiteLaxMode})
+func WithCookie(cookie http.Cookie) StubResponseRule {
+	if err := cookie.Valid(); err != nil {
+		panic(fmt.Errorf("WithCookie err: invalid cookie: %w", err))
+	}
+
+	return func(r *stubResponse) {
+		r.cookies = append(r.cookies, &cookie)
+	}
+}
+
 // WithDelay sets a delay time to the response.
 func WithDelay(d time.Duration) StubResponseRule {
 	return func(r *stubResponse) {
@@ -79,6 +216,191 @@ func WithDelay(d time.Duration) StubResponseRule {
 	}
 }
 
+// WithFlushEvery sets the response body to be written in pieces of n bytes, each one flushed to the client,
+// so streaming consumers receive the data at this exact granularity.
+func WithFlushEvery(n int) StubResponseRule {
+	if n <= 0 {
+		panic(fmt.Errorf("WithFlushEvery err: n must be positive, got %d", n))
+	}
+
+	return func(r *stubResponse) {
+		r.flushEvery = n
+	}
+}
+
+// WithoutBuffering sets the response header and body to be flushed to the client as soon as they are written,
+// instead of being buffered by the server.
+func WithoutBuffering() StubResponseRule {
+	return func(r *stubResponse) {
+		r.unbuffered = true
+	}
+}
+
+// WithExpectContinue sets how requests with the Expect: 100-continue header are handled.
+// If reject is false, a 100 Continue is sent so the client uploads the body before the final response is written.
+// If reject is true, the final response is written without reading the body, so the client does not upload it, and
+// the connection is closed (Connection: close) since it can not be reused.
+// Use WithStatusCode to set the final status, e.g. 417 Expectation Failed.
+// Note that body matchers read the body, which sends the 100 Continue automatically.
+func WithExpectContinue(reject bool) StubResponseRule {
+	return func(r *stubResponse) {
+		r.expectContinue = expectContinueAccept
+		if reject {
+			r.expectContinue = expectContinueReject
+		}
+	}
+}
+
+// WithCacheControl sets the Cache-Control response header with the given max-age and directives.
+//
+// Example:
+//
+//	WithCacheControl(time.Hour, "public", "must-revalidate") // Cache-Control: max-age=3600, public, must-revalidate
+func WithCacheControl(maxAge time.Duration, directives ...string) StubResponseRule {
+	values := append([]string{fmt.Sprintf("max-age=%d", int(maxAge.Seconds()))}, directives...)
+	return WithHeader("Cache-Control", strings.Join(values, ", "))
+}
+
+// WithLastModified sets the Last-Modified response header formatted as an HTTP date.
+func WithLastModified(t time.Time) StubResponseRule {
+	return WithHeader("Last-Modified", t.UTC().Format(http.TimeFormat))
+}
+
+// WithExpires sets the Expires response header formatted as an HTTP date.
+func WithExpires(t time.Time) StubResponseRule {
+	return WithHeader("Expires", t.UTC().Format(http.TimeFormat))
+}
+
+// WithETag sets the ETag response header.
+// When the request If-None-Match header matches the tag, the response will be 304 Not Modified without body.
+// The tag is quoted if it is not already, e.g. WithETag("v1") and WithETag(`W/"v1"`) are both valid.
+func WithETag(tag string) StubResponseRule {
+	if !strings.HasSuffix(tag, `"`) {
+		tag = `"` + tag + `"`
+	}
+
+	return func(r *stubResponse) {
+		r.etag = tag
+		r.setHeader("ETag", tag)
+	}
+}
+
+// etagMatches reports whether the If-None-Match header matches the tag using weak comparison.
+func etagMatches(ifNoneMatch, tag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RespondThrottled sets the response as 429 Too Many Requests with the Retry-After header (in seconds).
+func RespondThrottled(retryAfter time.Duration) StubResponseRule {
+	return func(r *stubResponse) {
+		r.statusCode = http.StatusTooManyRequests
+		r.setHeader("Retry-After", retryAfterSeconds(retryAfter))
+	}
+}
+
+// ThrottleFirst sets the first n responses as 429 Too Many Requests with the Retry-After header (in seconds).
+// The following requests receive the stub response.
+func ThrottleFirst(n int, retryAfter time.Duration) StubResponseRule {
+	throttled := Response{RespondThrottled(retryAfter)}.build()
+
+	var calls atomic.Int64
+
+	return func(r *stubResponse) {
+		r.selector = func(*stub, *http.Request) *stubResponse {
+			if calls.Add(1) <= int64(n) {
+				return throttled
+			}
+
+			return r
+		}
+	}
+}
+
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// RespondByParam sets the response depending on the value of the given param.
+// The value is taken from the path params (see URLPattern) or, if it is not a path param, from the query string.
+// When the value is not in the responses map, the default response is used.
+//
+// Example:
+//
+//	RespondByParam("user_id", map[string]Response{
+//		"1": {WithJSON(john)},
+//		"2": {WithJSON(rick)},
+//	}, Response{WithStatusCode(http.StatusNotFound)})
+func RespondByParam(key string, responses map[string]Response, defaultResponse Response) StubResponseRule {
+	built := make(map[string]*stubResponse, len(responses))
+	for value, rules := range responses {
+		built[value] = rules.build()
+	}
+
+	fallback := defaultResponse.build()
+
+	return func(r *stubResponse) {
+		r.selector = func(st *stub, req *http.Request) *stubResponse {
+			value, ok := ParamsFromRequest(req)[key]
+			if !ok {
+				value = req.URL.Query().Get(key)
+			}
+
+			if response, found := built[value]; found {
+				return response
+			}
+
+			return fallback
+		}
+	}
+}
+
+// RespondWith sets the response computed by fn on every request, e.g. to echo the request data.
+// The request path params (see URLPattern) are available with r.PathValue or ParamsFromRequest.
+// If fn returns an error, the response is 500 Internal Server Error with the error message as body.
+//
+// Example:
+//
+//	RespondWith(func(r *http.Request) (Response, error) {
+//		return Response{WithJSON(map[string]string{"id": r.PathValue("user_id")})}, nil
+//	})
+func RespondWith(fn func(*http.Request) (Response, error)) StubResponseRule {
+	return func(r *stubResponse) {
+		r.selector = func(st *stub, req *http.Request) *stubResponse {
+			rules, err := fn(req)
+			if err != nil {
+				return Response{WithStatusCode(http.StatusInternalServerError), WithBody(err.Error())}.build()
+			}
+
+			return rules.build()
+		}
+	}
+}
+
+// withPathValues returns a copy of the request with the given path values set, see ParamsFromRequest.
+func withPathValues(r *http.Request, values map[string]string) *http.Request {
+	if len(values) == 0 {
+		return r
+	}
+
+	r = r.Clone(context.WithValue(r.Context(), paramsContextKey{}, maps.Clone(values)))
+
+	for name, value := range values {
+		r.SetPathValue(name, value)
+	}
+
+	return r
+}
+
 func anyBodyToBytes(body any) ([]byte, error) {
 	switch v := body.(type) {
 	case []byte:
@@ -5,9 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/stretchr/testify/assert"
@@ -274,6 +279,123 @@ func TestWithJSON(t *testing.T) {
 	})
 }
 
+func TestWithYAML(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	type config struct {
+		Name     string   `yaml:"name"`
+		Replicas int      `yaml:"replicas"`
+		Ports    []int    `yaml:"ports"`
+		Labels   []string `yaml:"labels,omitempty"`
+	}
+
+	server.Stub(http.MethodGet, mockaso.Path("/test/with-yaml")).
+		Respond(mockaso.WithYAML(config{Name: "api", Replicas: 2, Ports: []int{80, 443}}))
+
+	httpResp, err := server.Client().Get("/test/with-yaml")
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+	assert.Equal(t, "application/yaml", httpResp.Header.Get("Content-Type"))
+	assertBodyString(t, "name: api\nreplicas: 2\nports:\n    - 80\n    - 443\n", httpResp)
+}
+
+func TestWithBodyFile(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "users.csv"), "id,name\n1,john\n")
+
+	t.Run("should return the content of the file", func(t *testing.T) {
+		server.Stub(http.MethodGet, mockaso.Path("/test/with-body-file")).
+			Respond(mockaso.WithBodyFile(filepath.Join(dir, "users.csv")))
+
+		httpResp, err := server.Client().Get("/test/with-body-file")
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assertBodyString(t, "id,name\n1,john\n", httpResp)
+	})
+
+	t.Run("should panic when the file can not be read", func(t *testing.T) {
+		assert.Panics(t, func() { mockaso.WithBodyFile(filepath.Join(dir, "missing.csv")) })
+	})
+}
+
+func TestWithBodyFS(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	fsys := fstest.MapFS{"testdata/users.csv": {Data: []byte("id,name\n1,john\n")}}
+
+	t.Run("should return the content of the file", func(t *testing.T) {
+		server.Stub(http.MethodGet, mockaso.Path("/test/with-body-fs")).
+			Respond(mockaso.WithBodyFS(fsys, "testdata/users.csv"))
+
+		httpResp, err := server.Client().Get("/test/with-body-fs")
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assertBodyString(t, "id,name\n1,john\n", httpResp)
+	})
+
+	t.Run("should panic when the file can not be read", func(t *testing.T) {
+		assert.Panics(t, func() { mockaso.WithBodyFS(fsys, "testdata/missing.csv") })
+	})
+}
+
+func TestWithJSONFile(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "user.json"), `{"name":"john","age":57}`)
+	writeFile(t, filepath.Join(dir, "invalid.json"), `{"name":"john",}`)
+
+	t.Run("should return the json of the file", func(t *testing.T) {
+		server.Stub(http.MethodGet, mockaso.Path("/test/with-json-file")).
+			Respond(mockaso.WithJSONFile(filepath.Join(dir, "user.json")))
+
+		httpResp, err := server.Client().Get("/test/with-json-file")
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assert.Equal(t, "application/json", httpResp.Header.Get("Content-Type"))
+		assertBodyString(t, `{"name":"john","age":57}`, httpResp)
+	})
+
+	t.Run("should panic", func(t *testing.T) {
+		testCases := map[string]struct {
+			file string
+		}{
+			"when the file can not be read": {
+				file: "missing.json",
+			},
+			"when the json is not valid": {
+				file: "invalid.json",
+			},
+		}
+
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				assert.Panics(t, func() { mockaso.WithJSONFile(filepath.Join(dir, tc.file)) })
+			})
+		}
+	})
+}
+
 func TestWithHeader_And_WithHeaders(t *testing.T) {
 	t.Parallel()
 
@@ -333,6 +455,47 @@ func TestWithHeader_And_WithHeaders(t *testing.T) {
 	})
 }
 
+func TestWithCookie(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	t.Run("should set a cookie for each rule", func(t *testing.T) {
+		server.Stub(http.MethodGet, mockaso.Path("/test/with-cookie")).
+			Respond(
+				mockaso.WithCookie(http.Cookie{
+					Name:     "session",
+					Value:    "abc",
+					Path:     "/",
+					MaxAge:   3600,
+					Secure:   true,
+					HttpOnly: true,
+					SameSite: http.SameSiteStrictMode,
+				}),
+				mockaso.WithCookie(http.Cookie{Name: "theme", Value: "dark"}),
+			)
+
+		httpResp, err := server.Client().Get("/test/with-cookie")
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assert.Equal(t, []string{
+			"session=abc; Path=/; Max-Age=3600; HttpOnly; Secure; SameSite=Strict",
+			"theme=dark",
+		}, httpResp.Header.Values("Set-Cookie"))
+
+		cookies := httpResp.Cookies()
+		require.Len(t, cookies, 2)
+		assert.Equal(t, "abc", cookies[0].Value)
+		assert.Equal(t, "dark", cookies[1].Value)
+	})
+
+	t.Run("should panic when the cookie is not valid", func(t *testing.T) {
+		assert.Panics(t, func() { mockaso.WithCookie(http.Cookie{Name: "invalid name", Value: "abc"}) })
+	})
+}
+
 func TestWithDelay(t *testing.T) {
 	t.Parallel()
 
@@ -362,6 +525,339 @@ func TestWithDelay(t *testing.T) {
 	})
 }
 
+func TestWithExpectContinue(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	accept := server.Stub(http.MethodPut, mockaso.URL("/test/expect-continue/accept"))
+	accept.Respond(
+		mockaso.WithStatusCode(http.StatusCreated),
+		mockaso.WithExpectContinue(false),
+	)
+
+	server.Stub(http.MethodPut, mockaso.URL("/test/expect-continue/reject")).
+		Respond(
+			mockaso.WithStatusCode(http.StatusExpectationFailed),
+			mockaso.WithExpectContinue(true),
+		)
+
+	client := &http.Client{Transport: &http.Transport{ExpectContinueTimeout: 5 * time.Second}}
+
+	newRequest := func(path string, body *trackingReader) *http.Request {
+		httpReq, _ := http.NewRequest(http.MethodPut, server.URL()+path, body)
+		httpReq.Header.Set("Expect", "100-continue")
+
+		return httpReq
+	}
+
+	t.Run("should send 100 continue and read the body", func(t *testing.T) {
+		t.Parallel()
+
+		body := &trackingReader{reader: strings.NewReader("large upload")}
+
+		httpResp, err := client.Do(newRequest("/test/expect-continue/accept", body))
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, httpResp.StatusCode)
+		assert.True(t, body.read.Load())
+
+		lastRequest := accept.LastRequest()
+		require.NotNil(t, lastRequest)
+		assert.Equal(t, "large upload", readString(lastRequest.Body), "the body is captured after the 100 Continue")
+	})
+
+	t.Run("should reject before the body is uploaded", func(t *testing.T) {
+		t.Parallel()
+
+		body := &trackingReader{reader: strings.NewReader("large upload")}
+
+		httpResp, err := client.Do(newRequest("/test/expect-continue/reject", body))
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusExpectationFailed, httpResp.StatusCode)
+		assert.True(t, httpResp.Close, "the connection is closed since the body was not uploaded")
+		assert.False(t, body.read.Load())
+	})
+}
+
+type trackingReader struct {
+	reader io.Reader
+	read   atomic.Bool
+}
+
+func (r *trackingReader) Read(p []byte) (int, error) {
+	r.read.Store(true)
+	return r.reader.Read(p)
+}
+
+func TestWithCacheControl_WithLastModified_WithExpires(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	lastModified := time.Date(2025, time.March, 7, 10, 30, 0, 0, time.FixedZone("UTC-3", -3*60*60))
+	expires := time.Date(2025, time.March, 8, 10, 30, 0, 0, time.UTC)
+
+	server.Stub(http.MethodGet, mockaso.URL("/test/cache-headers")).
+		Respond(
+			mockaso.WithCacheControl(time.Hour, "public", "must-revalidate"),
+			mockaso.WithLastModified(lastModified),
+			mockaso.WithExpires(expires),
+		)
+
+	server.Stub(http.MethodGet, mockaso.URL("/test/cache-headers/no-directives")).
+		Respond(mockaso.WithCacheControl(90 * time.Second))
+
+	t.Run("should return the cache headers", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, _ := http.NewRequest(http.MethodGet, "/test/cache-headers", http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, "max-age=3600, public, must-revalidate", httpResp.Header.Get("Cache-Control"))
+		assert.Equal(t, "Fri, 07 Mar 2025 13:30:00 GMT", httpResp.Header.Get("Last-Modified"))
+		assert.Equal(t, "Sat, 08 Mar 2025 10:30:00 GMT", httpResp.Header.Get("Expires"))
+	})
+
+	t.Run("should return cache control with max-age only", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, _ := http.NewRequest(http.MethodGet, "/test/cache-headers/no-directives", http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, "max-age=90", httpResp.Header.Get("Cache-Control"))
+	})
+}
+
+func TestWithETag(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodGet, mockaso.URL("/test/with-etag")).
+		Respond(
+			mockaso.WithStatusCode(http.StatusOK),
+			mockaso.WithBody("cached content"),
+			mockaso.WithETag("v1"),
+		)
+
+	testCases := map[string]struct {
+		ifNoneMatch    string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"should return full response when request has no If-None-Match": {
+			expectedStatus: http.StatusOK,
+			expectedBody:   "cached content",
+		},
+		"should return full response when If-None-Match does not match": {
+			ifNoneMatch:    `"v0"`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "cached content",
+		},
+		"should return not modified when If-None-Match match": {
+			ifNoneMatch:    `"v1"`,
+			expectedStatus: http.StatusNotModified,
+		},
+		"should return not modified when weak If-None-Match match in list": {
+			ifNoneMatch:    `"v0", W/"v1"`,
+			expectedStatus: http.StatusNotModified,
+		},
+		"should return not modified when If-None-Match is any": {
+			ifNoneMatch:    `*`,
+			expectedStatus: http.StatusNotModified,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq, _ := http.NewRequest(http.MethodGet, "/test/with-etag", http.NoBody)
+			if tc.ifNoneMatch != "" {
+				httpReq.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedStatus, httpResp.StatusCode)
+			assert.Equal(t, `"v1"`, httpResp.Header.Get("ETag"))
+			assertBodyString(t, tc.expectedBody, httpResp)
+		})
+	}
+}
+
+func TestRespondThrottled(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	t.Run("should respond too many requests with retry after", func(t *testing.T) {
+		t.Parallel()
+
+		server.Stub(http.MethodGet, mockaso.URL("/test/throttled")).
+			Respond(mockaso.RespondThrottled(1500 * time.Millisecond))
+
+		httpReq, _ := http.NewRequest(http.MethodGet, "/test/throttled", http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusTooManyRequests, httpResp.StatusCode)
+		assert.Equal(t, "2", httpResp.Header.Get("Retry-After"))
+	})
+}
+
+func TestThrottleFirst(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	t.Run("should throttle the first requests and then respond the stub response", func(t *testing.T) {
+		server.Stub(http.MethodGet, mockaso.URL("/test/throttle-first")).
+			Respond(
+				mockaso.WithBody("ok"),
+				mockaso.ThrottleFirst(2, 3*time.Second),
+			)
+
+		expectedStatuses := []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK, http.StatusOK}
+
+		for i, expectedStatus := range expectedStatuses {
+			httpReq, _ := http.NewRequest(http.MethodGet, "/test/throttle-first", http.NoBody)
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+
+			assert.Equal(t, expectedStatus, httpResp.StatusCode, "request #%d", i+1)
+
+			if expectedStatus == http.StatusTooManyRequests {
+				assert.Equal(t, "3", httpResp.Header.Get("Retry-After"))
+			} else {
+				assert.Empty(t, httpResp.Header.Get("Retry-After"))
+				assertBodyString(t, "ok", httpResp)
+			}
+		}
+	})
+}
+
+func TestRespondByParam(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	responses := map[string]mockaso.Response{
+		"1": {mockaso.WithJSON(userResponse{Name: "john", Age: 57})},
+		"2": {mockaso.WithJSON(userResponse{Name: "rick", Age: 39})},
+	}
+	notFound := mockaso.Response{mockaso.WithStatusCode(http.StatusNotFound), mockaso.WithBody("user not found")}
+
+	server.Stub(http.MethodGet, mockaso.PathPattern("/api/users/{user_id}")).
+		Respond(mockaso.RespondByParam("user_id", responses, notFound))
+
+	server.Stub(http.MethodGet, mockaso.Path("/api/users")).
+		Respond(mockaso.RespondByParam("user_id", responses, notFound))
+
+	testCases := map[string]struct {
+		url            string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"should respond by path param": {
+			url:            "/api/users/1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"name":"john","age":57}`,
+		},
+		"should respond by another path param": {
+			url:            "/api/users/2",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"name":"rick","age":39}`,
+		},
+		"should respond by query param": {
+			url:            "/api/users?user_id=2",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"name":"rick","age":39}`,
+		},
+		"should respond with default response when param value is unknown": {
+			url:            "/api/users/3",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "user not found",
+		},
+		"should respond with default response when param is missing": {
+			url:            "/api/users",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "user not found",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq, _ := http.NewRequest(http.MethodGet, tc.url, http.NoBody)
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedStatus, httpResp.StatusCode)
+			assertBodyString(t, tc.expectedBody, httpResp)
+		})
+	}
+}
+
+func TestRespondWith(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodPost, mockaso.PathPattern("/api/users/{user_id}")).
+		Respond(mockaso.RespondWith(func(r *http.Request) (mockaso.Response, error) {
+			var body map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				return nil, fmt.Errorf("invalid body: %w", err)
+			}
+
+			body["id"] = r.PathValue("user_id")
+			body["page"] = r.URL.Query().Get("page")
+
+			return mockaso.Response{
+				mockaso.WithStatusCode(http.StatusCreated),
+				mockaso.WithHeader("X-Method", r.Method),
+				mockaso.WithJSON(body),
+			}, nil
+		}))
+
+	t.Run("should respond with the response computed from the request", func(t *testing.T) {
+		t.Parallel()
+
+		body := strings.NewReader(`{"name":"john"}`)
+
+		httpResp, err := server.Client().Post("/api/users/10?page=2", "application/json", body)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, httpResp.StatusCode)
+		assert.Equal(t, http.MethodPost, httpResp.Header.Get("X-Method"))
+		assertBodyString(t, `{"id":"10","name":"john","page":"2"}`, httpResp)
+	})
+
+	t.Run("should respond internal server error when the func fails", func(t *testing.T) {
+		t.Parallel()
+
+		httpResp, err := server.Client().Post("/api/users/10", "application/json", strings.NewReader(`{`))
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusInternalServerError, httpResp.StatusCode)
+		assertBodyString(t, "invalid body: unexpected EOF", httpResp)
+	})
+}
+
 type userResponse struct {
 	Name string `json:"name"`
 	Age  int    `json:"age"`
@@ -372,3 +868,99 @@ type invalidJSON string
 func (p invalidJSON) MarshalJSON() ([]byte, error) {
 	return nil, errors.New("invalid json")
 }
+
+func TestWithBodyReaderFunc(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const path = "/test/with-body-reader-func"
+
+	var calls atomic.Int64
+
+	server.Stub(http.MethodGet, mockaso.Path(path)).
+		Respond(mockaso.WithBodyReaderFunc(func() io.Reader {
+			return strings.NewReader(fmt.Sprintf("call %d", calls.Add(1)))
+		}))
+
+	for _, expected := range []string{"call 1", "call 2"} {
+		httpReq, _ := http.NewRequest(http.MethodGet, path, http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assertBodyString(t, expected, httpResp)
+	}
+}
+
+func TestWithFlushEvery(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodGet, mockaso.Path("/test/flush-every")).
+		Respond(mockaso.WithBody("abcdefghij"), mockaso.WithFlushEvery(4))
+
+	server.Stub(http.MethodGet, mockaso.Path("/test/buffered")).
+		Respond(mockaso.WithBody("abcdefghij"))
+
+	t.Run("should write a chunk for each flushed piece", func(t *testing.T) {
+		t.Parallel()
+
+		raw := rawGet(t, server, "/test/flush-every")
+
+		assert.Contains(t, raw, "Transfer-Encoding: chunked")
+		assert.Contains(t, raw, "\r\n\r\n4\r\nabcd\r\n4\r\nefgh\r\n2\r\nij\r\n0\r\n")
+	})
+
+	t.Run("should write the whole body when it is buffered", func(t *testing.T) {
+		t.Parallel()
+
+		raw := rawGet(t, server, "/test/buffered")
+
+		assert.Contains(t, raw, "Content-Length: 10")
+		assert.True(t, strings.HasSuffix(raw, "\r\n\r\nabcdefghij"))
+	})
+
+	t.Run("should panic when n is not positive", func(t *testing.T) {
+		t.Parallel()
+
+		fn := func() { mockaso.WithFlushEvery(0) }
+		assert.PanicsWithError(t, "WithFlushEvery err: n must be positive, got 0", fn)
+	})
+}
+
+func TestWithoutBuffering(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodGet, mockaso.Path("/test/without-buffering")).
+		Respond(mockaso.WithBody("abcdefghij"), mockaso.WithoutBuffering())
+
+	raw := rawGet(t, server, "/test/without-buffering")
+
+	assert.Contains(t, raw, "Transfer-Encoding: chunked")
+	assert.Contains(t, raw, "\r\n\r\na\r\nabcdefghij\r\n0\r\n")
+}
+
+// rawGet sends a GET request and returns the raw http response, including the chunked encoding.
+func rawGet(t *testing.T, server *mockaso.Server, path string) string {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL(), "http://"))
+	require.NoError(t, err)
+
+	defer conn.Close()
+
+	_, err = fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: mockaso\r\nConnection: close\r\n\r\n", path)
+	require.NoError(t, err)
+
+	raw, err := io.ReadAll(conn)
+	require.NoError(t, err)
+
+	return string(raw)
+}
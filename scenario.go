@@ -0,0 +1,102 @@
+package mockaso
+
+import (
+	"net/http"
+	"sync"
+)
+
+// ScenarioStarted is the initial state of every scenario.
+const ScenarioStarted = "started"
+
+// Scenario is a named state machine shared by stubs, so the same endpoint can respond differently depending on
+// the previous calls, e.g. to test multi-step workflows like create, get and delete.
+//
+// Example:
+//
+//	cart := server.Scenario("cart")
+//	cart.Stub(http.MethodGet, Path("/cart")).WhenState(ScenarioStarted).Respond(WithJSON(emptyCart))
+//	cart.Stub(http.MethodPost, Path("/cart/items")).WillSetState("has-items").Respond(WithStatusCode(201))
+//	cart.Stub(http.MethodGet, Path("/cart")).WhenState("has-items").Respond(WithJSON(cartWithItems))
+type Scenario struct {
+	server *Server
+	name   string
+	state  string
+	mutex  sync.RWMutex
+}
+
+// ScenarioStub is a stub of a scenario. See Scenario.Stub.
+type ScenarioStub struct {
+	Stub
+	stub     *stub
+	scenario *Scenario
+}
+
+// Scenario returns the scenario with the given name, created in the ScenarioStarted state if it does not exist.
+func (s *Server) Scenario(name string) *Scenario {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.scenarios == nil {
+		s.scenarios = make(map[string]*Scenario)
+	}
+
+	scenario, ok := s.scenarios[name]
+	if !ok {
+		scenario = &Scenario{server: s, name: name, state: ScenarioStarted}
+		s.scenarios[name] = scenario
+	}
+
+	return scenario
+}
+
+// Name returns the scenario name.
+func (sc *Scenario) Name() string {
+	return sc.name
+}
+
+// State returns the current state of the scenario.
+func (sc *Scenario) State() string {
+	sc.mutex.RLock()
+	defer sc.mutex.RUnlock()
+
+	return sc.state
+}
+
+// SetState sets the current state of the scenario.
+func (sc *Scenario) SetState(state string) {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+
+	sc.state = state
+}
+
+// Reset sets the scenario back to the ScenarioStarted state.
+func (sc *Scenario) Reset() {
+	sc.SetState(ScenarioStarted)
+}
+
+// Stub registers a stub in the server which belongs to the scenario. See ScenarioStub.WhenState and
+// ScenarioStub.WillSetState.
+func (sc *Scenario) Stub(method string, url URLMatcher) *ScenarioStub {
+	st, _ := sc.server.Stub(method, url).(*stub)
+	return &ScenarioStub{Stub: st, stub: st, scenario: sc}
+}
+
+// WhenState sets the stub to match only when the scenario is in the given state.
+func (s *ScenarioStub) WhenState(state string) *ScenarioStub {
+	s.stub.matchers = append(s.stub.matchers, describedMatcher(func(*matchState, *http.Request) bool {
+		return s.scenario.State() == state
+	}, "scenario %s is not in state %s", s.scenario.Name(), state))
+
+	return s
+}
+
+// WillSetState sets the scenario to the given state when the stub responds a matched request. The state is not
+// changed when the request is rejected, e.g. by MaxConcurrent.
+func (s *ScenarioStub) WillSetState(state string) *ScenarioStub {
+	s.stub.onResponded = append(s.stub.onResponded, func(*http.Request) {
+		s.scenario.SetState(state)
+	})
+
+	return s
+}
@@ -0,0 +1,121 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestServer_Scenario(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	cart := server.Scenario("cart")
+
+	cart.Stub(http.MethodGet, mockaso.Path("/cart")).
+		WhenState(mockaso.ScenarioStarted).
+		Respond(mockaso.WithBody("empty"))
+
+	cart.Stub(http.MethodPost, mockaso.Path("/cart/items")).
+		WillSetState("has-items").
+		Respond(mockaso.WithStatusCode(http.StatusCreated))
+
+	cart.Stub(http.MethodGet, mockaso.Path("/cart")).
+		WhenState("has-items").
+		Respond(mockaso.WithBody("1 item"))
+
+	cart.Stub(http.MethodDelete, mockaso.Path("/cart")).
+		WhenState("has-items").
+		WillSetState(mockaso.ScenarioStarted).
+		Respond(mockaso.WithStatusCode(http.StatusNoContent))
+
+	send := func(t *testing.T, method, path string) *http.Response {
+		httpReq, _ := http.NewRequest(method, path, http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		return httpResp
+	}
+
+	t.Run("should respond depending on the scenario state", func(t *testing.T) {
+		httpResp := send(t, http.MethodGet, "/cart")
+		assertBodyString(t, "empty", httpResp)
+
+		httpResp = send(t, http.MethodDelete, "/cart")
+		assert.Equal(t, 666, httpResp.StatusCode, "should not match in the started state")
+
+		httpResp = send(t, http.MethodPost, "/cart/items")
+		assert.Equal(t, http.StatusCreated, httpResp.StatusCode)
+		assert.Equal(t, "has-items", cart.State())
+
+		httpResp = send(t, http.MethodGet, "/cart")
+		assertBodyString(t, "1 item", httpResp)
+
+		httpResp = send(t, http.MethodDelete, "/cart")
+		assert.Equal(t, http.StatusNoContent, httpResp.StatusCode)
+		assert.Equal(t, mockaso.ScenarioStarted, cart.State())
+
+		httpResp = send(t, http.MethodGet, "/cart")
+		assertBodyString(t, "empty", httpResp)
+	})
+
+	t.Run("should set and reset the scenario state", func(t *testing.T) {
+		cart.SetState("has-items")
+
+		httpResp := send(t, http.MethodGet, "/cart")
+		assertBodyString(t, "1 item", httpResp)
+
+		cart.Reset()
+
+		httpResp = send(t, http.MethodGet, "/cart")
+		assertBodyString(t, "empty", httpResp)
+	})
+
+	t.Run("should return the same scenario for the same name", func(t *testing.T) {
+		assert.Same(t, cart, server.Scenario("cart"))
+		assert.Equal(t, "cart", cart.Name())
+		assert.NotSame(t, cart, server.Scenario("orders"))
+	})
+}
+
+func TestScenarioStub_WillSetState_Rejected(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	gate := mockaso.NewGate()
+	jobs := server.Scenario("jobs")
+
+	st := jobs.Stub(http.MethodPost, mockaso.Path("/jobs")).WillSetState("running")
+	st.MaxConcurrent(1)
+	st.Respond(mockaso.WithStatusCode(http.StatusAccepted), mockaso.WithGate(gate))
+
+	held := make(chan *http.Response, 1)
+	go func() {
+		httpResp, _ := server.Client().Post("/jobs", "text/plain", http.NoBody)
+		held <- httpResp
+	}()
+
+	require.Eventually(t, func() bool { return st.Calls() == 1 }, time.Second, 10*time.Millisecond)
+
+	httpResp, err := server.Client().Post("/jobs", "text/plain", http.NoBody)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusServiceUnavailable, httpResp.StatusCode)
+	assert.Equal(t, mockaso.ScenarioStarted, jobs.State(), "a rejected request does not change the state")
+
+	gate.Release()
+
+	httpResp = <-held
+	require.NotNil(t, httpResp)
+	assert.Equal(t, http.StatusAccepted, httpResp.StatusCode)
+	assert.Equal(t, "running", jobs.State())
+}
@@ -0,0 +1,98 @@
+package mockaso
+
+import (
+	"net/http"
+	"slices"
+)
+
+// ScopeHeader is the request header carrying the scope id. See Server.Scope.
+const ScopeHeader = "X-Mockaso-Scope"
+
+// ScopeT is the interface of testing.T used by Server.Scope.
+type ScopeT interface {
+	Name() string
+	Cleanup(func())
+}
+
+// Scope is an isolated view of a shared server. Its stubs only match the requests carrying the scope header,
+// so one long-lived server can safely serve many parallel tests and processes.
+type Scope struct {
+	server *Server
+	id     string
+	stubs  []*stub
+}
+
+// Scope returns a new scope of the server for the given test, identified by a unique id.
+// Stubs registered with Scope.Stub only match requests with the ScopeHeader set to this id, which Scope.Client
+// injects automatically. The scope stubs are removed when the test finishes.
+//
+// Example:
+//
+//	scope := server.Scope(t)
+//	scope.Stub(http.MethodGet, mockaso.Path("/users/1")).Respond(mockaso.WithJSON(john))
+//
+//	client := scope.Client()
+func (s *Server) Scope(t ScopeT) *Scope {
+	scope := &Scope{server: s, id: t.Name() + "/" + randomHex(8)}
+	t.Cleanup(scope.clear)
+
+	return scope
+}
+
+// ID returns the scope id. Processes not using Scope.Client must send it in the ScopeHeader.
+func (sc *Scope) ID() string {
+	return sc.id
+}
+
+// Stub registers a stub in the server which only matches the requests of the scope.
+func (sc *Scope) Stub(method string, url URLMatcher) Stub {
+	s := sc.server
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	st := s.newStub(method, url, sc.matcher)
+	s.appendStubs(st)
+	sc.stubs = append(sc.stubs, st)
+
+	return st
+}
+
+// Client returns an http.Client like Server.Client which sends the ScopeHeader on every request.
+func (sc *Scope) Client() *http.Client {
+	client := sc.server.Client()
+	if client == nil {
+		return nil
+	}
+
+	baseTransport := client.Transport
+	client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		scoped := r.Clone(r.Context())
+		if scoped.Header == nil { // requests sent with RoundTrip may have no header
+			scoped.Header = make(http.Header)
+		}
+
+		scoped.Header.Set(ScopeHeader, sc.id)
+
+		return baseTransport.RoundTrip(scoped)
+	})
+
+	return client
+}
+
+func (sc *Scope) matcher(_ *matchState, r *http.Request) bool {
+	return r.Header.Get(ScopeHeader) == sc.id
+}
+
+func (sc *Scope) clear() {
+	s := sc.server
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.addCleared(sc.stubs...)
+	s.setStubs(slices.DeleteFunc(s.stubs, func(st *stub) bool {
+		return slices.Contains(sc.stubs, st)
+	}))
+	sc.stubs = nil
+}
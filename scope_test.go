@@ -0,0 +1,101 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestServer_Scope(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	get := func(t *testing.T, client *http.Client) *http.Response {
+		httpResp, err := client.Get("/users/1")
+		require.NoError(t, err)
+
+		return httpResp
+	}
+
+	for _, name := range []string{"john", "rick", "morty"} {
+		t.Run("should match only the requests of the scope "+name, func(t *testing.T) {
+			t.Parallel()
+
+			scope := server.Scope(t)
+			scope.Stub(http.MethodGet, mockaso.Path("/users/1")).Respond(mockaso.WithBody(name))
+
+			httpResp := get(t, scope.Client())
+			assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+			assertBodyString(t, name, httpResp)
+		})
+	}
+
+	t.Run("should not match the requests without the scope header", func(t *testing.T) {
+		t.Parallel()
+
+		scope := server.Scope(t)
+		scope.Stub(http.MethodGet, mockaso.Path("/users/2")).Respond(mockaso.WithBody("summer"))
+
+		httpResp, err := server.Client().Get("/users/2")
+		require.NoError(t, err)
+
+		assert.Equal(t, 666, httpResp.StatusCode)
+	})
+
+	t.Run("should match the requests with the scope id sent manually", func(t *testing.T) {
+		t.Parallel()
+
+		scope := server.Scope(t)
+		scope.Stub(http.MethodGet, mockaso.Path("/users/3")).Respond(mockaso.WithBody("beth"))
+
+		httpReq, _ := http.NewRequest(http.MethodGet, "/users/3", http.NoBody)
+		httpReq.Header.Set(mockaso.ScopeHeader, scope.ID())
+
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assertBodyString(t, "beth", httpResp)
+	})
+
+	t.Run("should round trip a request without header", func(t *testing.T) {
+		t.Parallel()
+
+		scope := server.Scope(t)
+		scope.Stub(http.MethodGet, mockaso.Path("/users/4")).Respond(mockaso.WithBody("jerry"))
+
+		reqURL, _ := url.Parse("/users/4")
+
+		httpResp, err := scope.Client().Transport.RoundTrip(&http.Request{Method: http.MethodGet, URL: reqURL})
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assertBodyString(t, "jerry", httpResp)
+	})
+}
+
+func TestServer_Scope_Cleanup(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	var scope *mockaso.Scope
+
+	t.Run("register", func(t *testing.T) {
+		scope = server.Scope(t)
+		scope.Stub(http.MethodGet, mockaso.Path("/users/1")).Respond(mockaso.WithBody("john"))
+	})
+
+	httpResp, err := scope.Client().Get("/users/1")
+	require.NoError(t, err)
+
+	assert.Equal(t, 666, httpResp.StatusCode, "the scope stubs should be removed when the test finishes")
+}
@@ -4,21 +4,51 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Server struct {
-	server *httptest.Server
-	stubs  []*stub
-	logger Logger
-	mutex  sync.RWMutex
+	server    *httptest.Server
+	stubs     []*stub
+	cleared   []*stub // stubs removed by Clear, to detect late requests
+	scenarios map[string]*Scenario
+	journal   journal
+	logger    Logger
+	clock     Clock
+	mutex     sync.RWMutex
+
+	shuttingDown atomic.Bool
+	nearMissDiff bool
+	matchTracing bool
+	http2        bool
+	addr         string
+	listener     net.Listener
+	fallback     http.Handler // serves the requests which do not match any stub, if set
+	recorder     atomic.Pointer[recorder]
+	index        *stubIndex // the stubs indexed by method and path, see appendStubs
+
+	requestHooks  []func(*http.Request)
+	responseHooks []func(*http.Request, *ResponseInfo)
+	unusedStubsT  TestingT // asserts that every stub matched on Shutdown, if set
 }
 
 func (s *Server) Start() error {
 	if s.server == nil {
-		s.server = s.newTestServer()
+		listener, err := s.listen()
+		if err != nil {
+			return err
+		}
+
+		s.server = s.newTestServer(listener)
 	}
 
 	s.logger.Logf("server started at %s", s.server.URL)
@@ -31,10 +61,15 @@ func (s *Server) Shutdown() error {
 		return nil
 	}
 
+	s.shuttingDown.Store(true)
 	s.server.Close()
 
 	s.logger.Logf("server stopped at %s", s.server.URL)
 
+	if s.unusedStubsT != nil {
+		s.AssertAllStubsMatched(s.unusedStubsT)
+	}
+
 	return nil
 }
 
@@ -54,7 +89,8 @@ func (s *Server) Clear() {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	s.stubs = nil
+	s.addCleared(s.stubs...)
+	s.setStubs(nil)
 
 	if s.server == nil {
 		return
@@ -80,10 +116,10 @@ func (s *Server) Client() *http.Client {
 		return nil
 	}
 
-	client := s.server.Client()
+	client := *s.server.Client() // copied, the test server client is shared
 	client.Transport = newTransportWithBaseURL(client.Transport, s.URL())
 
-	return client
+	return &client
 }
 
 func (s *Server) Logger() Logger {
@@ -94,41 +130,201 @@ func (s *Server) Stub(method string, url URLMatcher) Stub {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	st := &stub{
-		response:      newStubResponse(),
-		matchers:      defaultMatchers(method, url),
-		patternParams: make(map[string]string),
-	}
+	st := s.newStub(method, url)
+	s.appendStubs(st)
+
+	return st
+}
+
+// newStub returns a stub with the default matchers of the method and URL, followed by the given matchers.
+func (s *Server) newStub(method string, url URLMatcher, matchers ...requestMatcherFunc) *stub {
+	description := describeURL(url)
 
-	s.stubs = append(s.stubs, st)
+	st := newStub(s.clock, append(defaultMatchers(method, url), matchers...))
+	st.key = newStubKey(method, description)
+	st.description = strings.TrimSpace(method + " " + description.text)
+	st.muxPattern = description.muxPattern
 
 	return st
 }
 
-func (s *Server) newTestServer() *httptest.Server {
-	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		s.mutex.RLock()
-		defer s.mutex.RUnlock()
+// listen returns the listener set with WithListener or WithAddr, nil if none was set.
+func (s *Server) listen() (net.Listener, error) {
+	if s.listener != nil || s.addr == "" {
+		return s.listener, nil
+	}
+
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen on %s failed: %w", s.addr, err)
+	}
 
-		for _, st := range s.stubs {
-			if st.match(r) {
-				st.write(w)
-				return
+	return listener, nil
+}
+
+// newTestServer returns a started test server. If listener is nil, it listens on a random local port.
+func (s *Server) newTestServer(listener net.Listener) *httptest.Server {
+	server := httptest.NewUnstartedServer(s.handler())
+
+	if listener != nil {
+		_ = server.Listener.Close()
+		server.Listener = listener
+	}
+
+	if s.http2 { // raw headers are not captured, since the connection is encrypted
+		server.EnableHTTP2 = true
+		server.StartTLS()
+
+		return server
+	}
+
+	server.Listener = &rawHeaderListener{Listener: server.Listener}
+	server.Config.ConnContext = withRawHeaderConn
+	server.Start()
+
+	return server
+}
+
+// handler returns the handler which serves the requests with the stubs.
+func (s *Server) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received := time.Now()
+
+		r, served := captureRawHeader(r)
+		defer served()
+
+		r = withBodyCache(r)
+
+		rr := s.journal.record(r, received)
+		tw := &timingWriter{ResponseWriter: w, received: received}
+		w = tw
+
+		for _, hook := range s.requestHooks {
+			hook(hookRequest(r))
+		}
+
+		st, state := s.matchStub(r)
+		matched := time.Since(received)
+
+		s.journal.setStub(rr, st)
+
+		if reason := s.lateReason(r, st); reason != "" {
+			s.journal.setLate(rr, reason)
+			s.logger.Logf("late request %s %s received %s", r.Method, r.URL.String(), reason)
+		}
+
+		defer func() {
+			s.journal.setTiming(rr, RequestTiming{
+				Method:    r.Method,
+				URL:       r.URL.String(),
+				Received:  received,
+				Match:     matched,
+				FirstByte: tw.firstByte,
+				Total:     time.Since(received),
+			})
+
+			if len(s.responseHooks) > 0 {
+				s.runResponseHooks(r, tw, st, received)
 			}
+		}()
+
+		// the stub is written without holding the lock, since responses could block (e.g. long polling)
+		if st != nil {
+			st.write(w, withPathValues(r, state.params))
+			return
 		}
 
 		// http request does not match with any stub
 		s.logger.Logf("no stub matched for %s %s", r.Method, r.URL.String())
+
+		if s.nearMissDiff {
+			s.logNearMisses(r)
+		}
+
+		if s.matchTracing {
+			s.logMatchTrace(r)
+		}
+
+		if s.fallback != nil {
+			s.serveFallback(w, r)
+			return
+		}
+
 		writeNoMatch(w, r)
 	})
+}
+
+// matchStub returns the stub which matches the request and the state of its evaluation, or nil if there is none.
+func (s *Server) matchStub(r *http.Request) (*stub, *matchState) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.index == nil {
+		return nil, nil
+	}
+
+	candidates := s.index.candidates(r)
+
+	for i, st := range candidates {
+		state, ok := st.evaluate(r)
+		if !ok {
+			continue
+		}
+
+		preferred, preferredState := preferredStub(st, state, candidates[i+1:], r)
+		if preferred != st && preferred.use() {
+			return preferred, preferredState
+		}
 
-	return httptest.NewServer(h)
+		if st.use() {
+			return st, state
+		}
+	}
+
+	return nil, nil
+}
+
+// maxClearedStubs bounds the cleared stubs kept to detect late requests, so long-lived servers (e.g. shared by
+// scopes) neither grow nor slow down the unmatched requests over time.
+const maxClearedStubs = 100
+
+// addCleared keeps the given cleared stubs to detect late requests, dropping the oldest beyond maxClearedStubs.
+func (s *Server) addCleared(stubs ...*stub) {
+	s.cleared = append(s.cleared, stubs...)
+
+	if excess := len(s.cleared) - maxClearedStubs; excess > 0 {
+		s.cleared = slices.Delete(s.cleared, 0, excess)
+	}
+}
+
+// lateReason returns why the request is late, if it is: it was received while the server was shutting down,
+// or after Clear and it would have matched a cleared stub. Empty if the request is not late.
+func (s *Server) lateReason(r *http.Request, st *stub) string {
+	if s.shuttingDown.Load() {
+		return "during shutdown"
+	}
+
+	if st != nil {
+		return ""
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, cleared := range s.cleared {
+		if cleared.match(r) {
+			return "after clear"
+		}
+	}
+
+	return ""
 }
 
 func NewServer(opts ...ServerOption) *Server {
 	server := &Server{
 		logger: &noLogger{},
 		stubs:  make([]*stub, 0),
+		clock:  realClock{},
 	}
 
 	for _, opt := range opts {
@@ -161,6 +357,51 @@ func WithLogger(logger Logger) ServerOption {
 	}
 }
 
+// WithHTTP2 enables HTTP/2 in the server, which is served over TLS. The server Client negotiates HTTP/2 and trusts
+// the server certificate.
+func WithHTTP2() ServerOption {
+	return func(s *Server) {
+		s.http2 = true
+	}
+}
+
+// WithAddr sets the address the server listens on, e.g. "127.0.0.1:18080", instead of a random local port.
+// Useful when the system under test reads the dependency URL from a config that can't be rewritten per test.
+// Start fails if the address can't be listened on.
+func WithAddr(addr string) ServerOption {
+	return func(s *Server) {
+		s.addr = addr
+	}
+}
+
+// WithListener sets the listener of the server, instead of listening on a random local port.
+// The listener is closed when the server is shut down.
+func WithListener(listener net.Listener) ServerOption {
+	return func(s *Server) {
+		s.listener = listener
+	}
+}
+
+// WithFallbackProxy sets the requests which do not match any stub to be forwarded to the target URL and its
+// response relayed back, e.g. to stub only a few endpoints and let the rest hit a staging environment.
+// The Host header is set to the target host. It panics if the target URL is not valid.
+func WithFallbackProxy(targetURL string) ServerOption {
+	target, err := url.Parse(targetURL)
+	if err != nil {
+		panic(fmt.Errorf("WithFallbackProxy err: invalid target URL: %w", err))
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+		},
+	}
+
+	return func(s *Server) {
+		s.fallback = proxy
+	}
+}
+
 // WithSlogLogger sets a Logger from slog.Logger.
 // level is the slog.LogLevel that will be used.
 func WithSlogLogger(logger *slog.Logger, level slog.Level) ServerOption {
@@ -6,7 +6,9 @@ import (
 	"io"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -123,6 +125,243 @@ func TestServer_Stub(t *testing.T) {
 	})
 }
 
+func TestServer_SharedBody(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	for i := range 20 {
+		server.Stub(http.MethodPost, mockaso.Path("/api/events")).
+			Match(mockaso.MatchJSONPath("id", i), mockaso.MatchBodyStringFunc(func(body string) bool {
+				return strings.Contains(body, "payload")
+			})).
+			Respond(mockaso.RespondWith(func(r *http.Request) (mockaso.Response, error) {
+				body, err := io.ReadAll(r.Body)
+				return mockaso.Response{mockaso.WithBody(body)}, err
+			}))
+	}
+
+	const body = `{"id":19,"payload":"data"}`
+
+	httpResp, err := server.Client().Post("/api/events", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+	assertBodyString(t, body, httpResp)
+
+	requests := server.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, body, string(requests[0].Body))
+}
+
+func TestServer_IndexedStubs(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	for i := range 200 {
+		server.Stub(http.MethodGet, mockaso.Path(fmt.Sprintf("/api/users/%d", i))).
+			Respond(mockaso.WithBody(fmt.Sprintf("user %d", i)))
+	}
+
+	server.Stub(http.MethodGet, mockaso.PathPattern("/api/users/{id:int}")).Respond(mockaso.WithBody("pattern"))
+	server.Stub(http.MethodGet, mockaso.Path("/api/users/7")).Respond(mockaso.WithBody("shadowed"))
+	server.Stub(http.MethodGet, mockaso.Path("/api/users/%41", mockaso.IgnoreEncoding())).
+		Respond(mockaso.WithBody("decoded"))
+
+	testCases := map[string]struct {
+		method   string
+		path     string
+		expected string
+	}{
+		"should match an exact path": {
+			method:   http.MethodGet,
+			path:     "/api/users/150",
+			expected: "user 150",
+		},
+		"should match the first registered stub": {
+			method:   http.MethodGet,
+			path:     "/api/users/7",
+			expected: "user 7",
+		},
+		"should fall back to the stubs which are not indexed": {
+			method:   http.MethodGet,
+			path:     "/api/users/1000",
+			expected: "pattern",
+		},
+		"should match a decoded path": {
+			method:   http.MethodGet,
+			path:     "/api/users/A",
+			expected: "decoded",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq, err := http.NewRequest(tc.method, tc.path, nil)
+			require.NoError(t, err)
+
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+			assertBodyString(t, tc.expected, httpResp)
+		})
+	}
+
+	t.Run("should not match a different method", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, err := http.NewRequest(http.MethodPost, "/api/users/150", nil)
+		require.NoError(t, err)
+
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assertNotMatchedResponse(t, httpReq, httpResp)
+	})
+}
+
+func TestWithHTTP2(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithHTTP2())
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodGet, mockaso.URL("/api/users")).
+		Match(mockaso.MatchHTTPProto("HTTP/2.0")).
+		Respond(mockaso.WithBody("h2"))
+
+	t.Run("should serve http2 over tls", func(t *testing.T) {
+		assert.True(t, strings.HasPrefix(server.URL(), "https://"))
+
+		httpResp, err := server.Client().Get("/api/users")
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, httpResp.ProtoMajor)
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assertBodyString(t, "h2", httpResp)
+	})
+}
+
+func TestWithAddr(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should listen on the given address", func(t *testing.T) {
+		t.Parallel()
+
+		addr := freeAddr(t)
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithAddr(addr))
+		t.Cleanup(server.MustShutdown)
+
+		server.Stub(http.MethodGet, mockaso.URL("/api/users"))
+
+		assert.Equal(t, "http://"+addr, server.URL())
+
+		httpResp, err := http.Get("http://" + addr + "/api/users")
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+	})
+
+	t.Run("should fail to start when the address is in use", func(t *testing.T) {
+		t.Parallel()
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = listener.Close() })
+
+		addr := listener.Addr().String()
+
+		err = mockaso.NewServer(mockaso.WithAddr(addr)).Start()
+		assert.ErrorContains(t, err, "listen on "+addr+" failed: ")
+	})
+}
+
+func TestWithListener(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithListener(listener))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodGet, mockaso.URL("/api/users"))
+
+	assert.Equal(t, "http://"+listener.Addr().String(), server.URL())
+
+	httpResp, err := server.Client().Get("/api/users")
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+}
+
+func TestWithFallbackProxy(t *testing.T) {
+	t.Parallel()
+
+	upstream := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(upstream.MustShutdown)
+
+	upstream.Stub(http.MethodPost, mockaso.URL("/api/orders?page=1")).
+		Match(
+			mockaso.MatchRawJSONBody(`{"id":1}`),
+			mockaso.MatchRequest(func(r *http.Request) bool { return r.Host == strings.TrimPrefix(upstream.URL(), "http://") }),
+		).
+		Respond(mockaso.WithStatusCode(http.StatusCreated), mockaso.WithBody("from upstream"))
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithFallbackProxy(upstream.URL()))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodGet, mockaso.URL("/api/users")).Respond(mockaso.WithBody("from stub"))
+
+	t.Run("should respond with the stub when it matches", func(t *testing.T) {
+		httpResp, err := server.Client().Get("/api/users")
+		require.NoError(t, err)
+
+		assertBodyString(t, "from stub", httpResp)
+	})
+
+	t.Run("should proxy the request to the target when no stub matches", func(t *testing.T) {
+		httpResp, err := server.Client().Post("/api/orders?page=1", "application/json", strings.NewReader(`{"id":1}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, httpResp.StatusCode)
+		assertBodyString(t, "from upstream", httpResp)
+	})
+
+	t.Run("should relay the target response when it does not match either", func(t *testing.T) {
+		httpResp, err := server.Client().Get("/api/products")
+		require.NoError(t, err)
+
+		assert.Equal(t, 666, httpResp.StatusCode)
+	})
+
+	t.Run("should panic when the target URL is not valid", func(t *testing.T) {
+		fn := func() { mockaso.WithFallbackProxy("http://[::1") }
+		assert.PanicsWithError(t, `WithFallbackProxy err: invalid target URL: parse "http://[::1": `+
+			`missing ']' in host`, fn)
+	})
+}
+
+// freeAddr returns a local address which is free to listen on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	return addr
+}
+
 func TestWithSlogLogger(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,145 @@
+package mockaso
+
+import (
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const (
+	soap11Namespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12Namespace = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// MatchSOAPAction sets a rule to match the SOAP request of the given action, read from the SOAPAction header
+// (SOAP 1.1) or from the action parameter of the application/soap+xml Content-Type (SOAP 1.2). Quotes are ignored.
+//
+// Example:
+//
+//	server.Stub(http.MethodPost, Path("/ws/users")).
+//		Match(MatchSOAPAction("urn:GetUser"), MatchSOAPBody(GetUserRequest{ID: 100})).
+//		Respond(WithSOAPEnvelope(GetUserResponse{Name: "john"}))
+func MatchSOAPAction(action string) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		if soapAction := r.Header.Get("SOAPAction"); soapAction != "" {
+			return strings.Trim(soapAction, `"`) == action
+		}
+
+		mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+		return err == nil && mediaType == "application/soap+xml" && params["action"] == action
+	})
+
+	return describedRule(matcher, "SOAP action %s mismatch", action)
+}
+
+// MatchRawSOAPBody sets a rule to match the SOAP request whose envelope body has the given raw XML content.
+// The envelope header is ignored and the content is compared as in MatchRawXMLBody. Both SOAP 1.1 and 1.2
+// envelopes are supported. It panics if the given XML is not valid.
+func MatchRawSOAPBody[T string | []byte](raw T) StubMatcherRule {
+	expected, err := parseXML([]byte(raw))
+	if err != nil {
+		panic(fmt.Errorf("MatchRawSOAPBody err: invalid xml: %w", err))
+	}
+
+	return matchSOAPBodyNode(expected)
+}
+
+// MatchSOAPBody sets a rule to match the SOAP request whose envelope body has the given content.
+// The specified body will be marshaled with encoding/xml and compared as in MatchRawSOAPBody.
+func MatchSOAPBody(body any) StubMatcherRule {
+	data, err := xml.Marshal(body)
+	if err != nil {
+		panic(fmt.Errorf("MatchSOAPBody err: marshal body failed: %w", err))
+	}
+
+	expected, err := parseXML(data)
+	if err != nil {
+		panic(fmt.Errorf("MatchSOAPBody err: invalid xml: %w", err))
+	}
+
+	return matchSOAPBodyNode(expected)
+}
+
+// WithSOAPEnvelope sets the response content to a SOAP 1.1 envelope whose body is the encoding/xml marshal output
+// of the given body. The response will include the Content-Type:text/xml; charset=utf-8 header.
+func WithSOAPEnvelope(body any) StubResponseRule {
+	data, err := xml.Marshal(body)
+	if err != nil {
+		panic(fmt.Errorf("WithSOAPEnvelope err: body marshal failed: %w", err))
+	}
+
+	return func(r *stubResponse) {
+		r.setSOAP(data)
+	}
+}
+
+// WithSOAPFault sets the response to a SOAP 1.1 fault with the given code and message, e.g.
+// WithSOAPFault("soap:Client", "user not found"), and the status code to 500 Internal Server Error.
+func WithSOAPFault(code, message string) StubResponseRule {
+	fault := soapFault{Code: code, Message: message}
+
+	data, err := xml.Marshal(fault)
+	if err != nil {
+		panic(fmt.Errorf("WithSOAPFault err: fault marshal failed: %w", err))
+	}
+
+	return func(r *stubResponse) {
+		r.statusCode = http.StatusInternalServerError
+		r.setSOAP(data)
+	}
+}
+
+type soapFault struct {
+	XMLName xml.Name `xml:"soap:Fault"`
+	Code    string   `xml:"faultcode"`
+	Message string   `xml:"faultstring"`
+}
+
+func (r *stubResponse) setSOAP(body []byte) {
+	envelope := xml.Header + `<soap:Envelope xmlns:soap="` + soap11Namespace + `"><soap:Body>` +
+		string(body) + `</soap:Body></soap:Envelope>`
+
+	r.headers["Content-Type"] = "text/xml; charset=utf-8"
+	r.setBody([]byte(envelope))
+}
+
+func matchSOAPBodyNode(expected *xmlNode) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		envelope, err := parseXML(mustReadDecodedBody(r))
+		if err != nil { // the request body is not valid XML
+			return false
+		}
+
+		content, ok := soapBodyContent(envelope)
+
+		return ok && reflect.DeepEqual(expected, content)
+	})
+
+	return describedRule(matcher, "SOAP body mismatch")
+}
+
+// soapBodyContent returns the single element of the envelope body, reporting false if the document is not
+// a SOAP envelope or its body does not have a single element.
+func soapBodyContent(envelope *xmlNode) (*xmlNode, bool) {
+	space := envelope.name.Space
+
+	if envelope.name.Local != "Envelope" || space != soap11Namespace && space != soap12Namespace {
+		return nil, false
+	}
+
+	for _, child := range envelope.children {
+		if child.name.Space == space && child.name.Local == "Body" {
+			if len(child.children) != 1 {
+				return nil, false
+			}
+
+			return child.children[0], true
+		}
+	}
+
+	return nil, false
+}
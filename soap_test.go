@@ -0,0 +1,175 @@
+package mockaso_test
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+type getUserRequest struct {
+	XMLName xml.Name `xml:"urn:users GetUser"`
+	ID      int      `xml:"id"`
+}
+
+type getUserResponse struct {
+	XMLName xml.Name `xml:"urn:users GetUserResponse"`
+	Name    string   `xml:"name"`
+}
+
+func TestMatchSOAPAction(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		header   http.Header
+		expected bool
+	}{
+		"should match the SOAPAction header": {
+			header:   http.Header{"Soapaction": {"urn:GetUser"}},
+			expected: true,
+		},
+		"should match the quoted SOAPAction header": {
+			header:   http.Header{"Soapaction": {`"urn:GetUser"`}},
+			expected: true,
+		},
+		"should match the SOAP 1.2 content type action": {
+			header:   http.Header{"Content-Type": {`application/soap+xml; charset=utf-8; action="urn:GetUser"`}},
+			expected: true,
+		},
+		"should not match another action": {
+			header: http.Header{"Soapaction": {"urn:DeleteUser"}},
+		},
+		"should not match another SOAP 1.2 action": {
+			header: http.Header{"Content-Type": {`application/soap+xml; action="urn:DeleteUser"`}},
+		},
+		"should not match without action": {
+			header: http.Header{"Content-Type": {"text/xml"}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(http.MethodPost, "/ws/users", nil)
+			httpReq.Header = tc.header
+
+			assert.Equal(t, tc.expected, stubMatches(httpReq, mockaso.MatchSOAPAction("urn:GetUser")))
+		})
+	}
+}
+
+func TestMatchSOAPBody(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		rule     mockaso.StubMatcherRule
+		body     string
+		expected bool
+	}{
+		"should match a SOAP 1.1 envelope ignoring the header": {
+			rule: mockaso.MatchSOAPBody(getUserRequest{ID: 100}),
+			body: `<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:u="urn:users">
+				<soapenv:Header><u:Token>abc</u:Token></soapenv:Header>
+				<soapenv:Body><u:GetUser><u:id>100</u:id></u:GetUser></soapenv:Body>
+			</soapenv:Envelope>`,
+			expected: true,
+		},
+		"should match a SOAP 1.2 envelope": {
+			rule: mockaso.MatchRawSOAPBody(`<GetUser xmlns="urn:users"><id>100</id></GetUser>`),
+			body: `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope">
+				<env:Body><GetUser xmlns="urn:users"><id> 100 </id></GetUser></env:Body>
+			</env:Envelope>`,
+			expected: true,
+		},
+		"should not match a different body": {
+			rule: mockaso.MatchSOAPBody(getUserRequest{ID: 100}),
+			body: `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+				<soap:Body><GetUser xmlns="urn:users"><id>200</id></GetUser></soap:Body>
+			</soap:Envelope>`,
+		},
+		"should not match a document which is not an envelope": {
+			rule: mockaso.MatchSOAPBody(getUserRequest{ID: 100}),
+			body: `<GetUser xmlns="urn:users"><id>100</id></GetUser>`,
+		},
+		"should not match an envelope of another namespace": {
+			rule: mockaso.MatchSOAPBody(getUserRequest{ID: 100}),
+			body: `<Envelope><Body><GetUser xmlns="urn:users"><id>100</id></GetUser></Body></Envelope>`,
+		},
+		"should not match an invalid body": {
+			rule: mockaso.MatchSOAPBody(getUserRequest{ID: 100}),
+			body: `<soap:Envelope`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(http.MethodPost, "/ws/users", strings.NewReader(tc.body))
+			assert.Equal(t, tc.expected, stubMatches(httpReq, tc.rule))
+		})
+	}
+
+	t.Run("should panic when the raw body is not valid xml", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Panics(t, func() { mockaso.MatchRawSOAPBody(`<GetUser>`) })
+	})
+}
+
+func TestWithSOAPEnvelope(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodPost, mockaso.Path("/ws/users")).
+		Match(mockaso.MatchSOAPAction("urn:GetUser")).
+		Respond(mockaso.WithSOAPEnvelope(getUserResponse{Name: "john"}))
+
+	server.Stub(http.MethodPost, mockaso.Path("/ws/users")).
+		Respond(mockaso.WithSOAPFault("soap:Client", "unknown action"))
+
+	testCases := map[string]struct {
+		action             string
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		"should respond the envelope": {
+			action:             "urn:GetUser",
+			expectedStatusCode: http.StatusOK,
+			expectedBody: `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+				`<GetUserResponse xmlns="urn:users"><name>john</name></GetUserResponse></soap:Body></soap:Envelope>`,
+		},
+		"should respond the fault": {
+			action:             "urn:DeleteUser",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody: `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` +
+				`<soap:Fault><faultcode>soap:Client</faultcode><faultstring>unknown action</faultstring></soap:Fault>` +
+				`</soap:Body></soap:Envelope>`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq, _ := http.NewRequest(http.MethodPost, "/ws/users", strings.NewReader("<soap:Envelope/>"))
+			httpReq.Header.Set("SOAPAction", tc.action)
+
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedStatusCode, httpResp.StatusCode)
+			assert.Equal(t, "text/xml; charset=utf-8", httpResp.Header.Get("Content-Type"))
+			assertBodyString(t, xml.Header+tc.expectedBody, httpResp)
+		})
+	}
+}
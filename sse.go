@@ -0,0 +1,78 @@
+package mockaso
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SSEEvent is an event of a Server-Sent Events stream, see WithSSE.
+type SSEEvent struct {
+	ID    string        // the event id, not sent if empty
+	Event string        // the event name, not sent if empty (the client handles it as "message")
+	Data  string        // the event data, sent as several data lines if it has line breaks
+	Retry time.Duration // the reconnection time, not sent if zero
+	Delay time.Duration // the time to wait before sending the event, measured with the server clock (see WithClock)
+}
+
+// WithSSE sets the response to be a Server-Sent Events stream (text/event-stream) of the given events. Every event
+// is flushed to the client once its delay elapses, and the response ends when all the events were sent or the
+// request is canceled, e.g. to mock streaming APIs.
+//
+// Example:
+//
+//	WithSSE(
+//		SSEEvent{Event: "token", Data: "Hello"},
+//		SSEEvent{Event: "token", Data: " world", Delay: 100 * time.Millisecond},
+//		SSEEvent{Event: "done", Data: "[DONE]"},
+//	)
+func WithSSE(events ...SSEEvent) StubResponseRule {
+	return func(r *stubResponse) {
+		r.setHeader("Content-Type", "text/event-stream")
+		r.setHeader("Cache-Control", "no-cache")
+
+		r.setStream(func(st *stub, w io.Writer, req *http.Request) {
+			for _, event := range events {
+				if event.Delay > 0 {
+					select {
+					case <-st.clock.After(event.Delay):
+					case <-req.Context().Done():
+						return
+					}
+				}
+
+				if _, err := io.WriteString(w, event.format()); err != nil {
+					return
+				}
+			}
+		})
+	}
+}
+
+// format returns the event in the text/event-stream format.
+func (e SSEEvent) format() string {
+	var b strings.Builder
+
+	if e.ID != "" {
+		b.WriteString("id: " + e.ID + "\n")
+	}
+
+	if e.Event != "" {
+		b.WriteString("event: " + e.Event + "\n")
+	}
+
+	if e.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	data := strings.ReplaceAll(e.Data, "\r\n", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+
+	b.WriteString("\n")
+
+	return b.String()
+}
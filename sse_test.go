@@ -0,0 +1,66 @@
+package mockaso_test
+
+import (
+	"bufio"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestWithSSE(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Now()}
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithClock(clock))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodGet, mockaso.Path("/completions")).
+		Respond(mockaso.WithSSE(
+			mockaso.SSEEvent{ID: "1", Event: "token", Data: "Hello", Retry: 3 * time.Second},
+			mockaso.SSEEvent{ID: "2", Event: "token", Data: "line 1\nline 2", Delay: time.Second},
+			mockaso.SSEEvent{Data: "[DONE]"},
+		))
+
+	httpResp, err := server.Client().Get("/completions")
+	require.NoError(t, err)
+
+	defer httpResp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+	assert.Equal(t, "text/event-stream", httpResp.Header.Get("Content-Type"))
+	assert.Equal(t, "no-cache", httpResp.Header.Get("Cache-Control"))
+
+	reader := bufio.NewReader(httpResp.Body)
+
+	readEvent := func() string {
+		var event strings.Builder
+
+		for {
+			line, readErr := reader.ReadString('\n')
+			require.NoError(t, readErr)
+
+			if line == "\n" {
+				return event.String()
+			}
+
+			event.WriteString(line)
+		}
+	}
+
+	assert.Equal(t, "id: 1\nevent: token\nretry: 3000\ndata: Hello\n", readEvent())
+
+	require.Eventually(t, func() bool { return clock.Waiting() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Second)
+
+	assert.Equal(t, "id: 2\nevent: token\ndata: line 1\ndata: line 2\n", readEvent())
+	assert.Equal(t, "data: [DONE]\n", readEvent())
+
+	assert.Empty(t, readString(reader), "the stream ends once the events are sent")
+}
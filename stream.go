@@ -0,0 +1,139 @@
+package mockaso
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WithStreamBody sets the response body to be written by fn, with every write flushed to the client as a chunk
+// (chunked transfer encoding), e.g. to test clients which process partial responses or enforce read deadlines.
+// The response header is written before fn is called.
+//
+// Example:
+//
+//	WithStreamBody(func(w io.Writer) {
+//		for _, event := range events {
+//			fmt.Fprintln(w, event)
+//			time.Sleep(100 * time.Millisecond)
+//		}
+//	})
+func WithStreamBody(fn func(io.Writer)) StubResponseRule {
+	return func(r *stubResponse) {
+		r.setStream(func(_ *stub, w io.Writer, _ *http.Request) {
+			fn(w)
+		})
+	}
+}
+
+// WithChunks sets the response body to be written in the given chunks, each one flushed to the client, waiting the
+// delay between them (measured with the server clock, see WithClock). The stream stops if the request is canceled.
+func WithChunks(chunks [][]byte, delay time.Duration) StubResponseRule {
+	return func(r *stubResponse) {
+		r.setStream(func(st *stub, w io.Writer, req *http.Request) {
+			for i, chunk := range chunks {
+				if i > 0 && delay > 0 {
+					select {
+					case <-st.clock.After(delay):
+					case <-req.Context().Done():
+						return
+					}
+				}
+
+				if _, err := w.Write(chunk); err != nil {
+					return
+				}
+			}
+		})
+	}
+}
+
+// JSONLine is an item of WithJSONLines which is written after the given delay, measured with the server clock.
+type JSONLine struct {
+	Value any
+	Delay time.Duration
+}
+
+// WithJSONLines sets the response body to be a stream of newline delimited JSON (NDJSON), one line per item, each
+// one flushed to the client. Items of type JSONLine are written after their delay. The stream stops if the request
+// is canceled. The response will include the Content-Type:application/x-ndjson header.
+//
+// Example:
+//
+//	WithJSONLines(
+//		map[string]any{"id": 1},
+//		JSONLine{Value: map[string]any{"id": 2}, Delay: time.Second},
+//	)
+func WithJSONLines(items ...any) StubResponseRule {
+	lines := make([][]byte, len(items))
+	delays := make([]time.Duration, len(items))
+
+	for i, item := range items {
+		line, ok := item.(JSONLine)
+		if !ok {
+			line = JSONLine{Value: item}
+		}
+
+		data, err := json.Marshal(line.Value)
+		if err != nil {
+			panic(fmt.Errorf("WithJSONLines err: item #%d marshal failed: %w", i, err))
+		}
+
+		lines[i] = append(data, '\n')
+		delays[i] = line.Delay
+	}
+
+	return func(r *stubResponse) {
+		r.setHeader("Content-Type", "application/x-ndjson")
+
+		r.setStream(func(st *stub, w io.Writer, req *http.Request) {
+			for i, line := range lines {
+				if delays[i] > 0 {
+					select {
+					case <-st.clock.After(delays[i]):
+					case <-req.Context().Done():
+						return
+					}
+				}
+
+				if _, err := w.Write(line); err != nil {
+					return
+				}
+			}
+		})
+	}
+}
+
+func (r *stubResponse) setStream(stream func(*stub, io.Writer, *http.Request)) {
+	r.body = nil
+	r.bodyFunc = nil
+	r.stream = stream
+}
+
+// writeStream writes the response header and then the streamed body, flushing every write.
+func (r *stubResponse) writeStream(st *stub, w http.ResponseWriter, req *http.Request) {
+	w.Header().Del("Content-Length")
+	w.WriteHeader(r.statusCode)
+
+	rc := http.NewResponseController(w)
+	_ = rc.Flush()
+
+	r.stream(st, &flushWriter{writer: w, controller: rc}, req)
+}
+
+// flushWriter flushes every write to the client.
+type flushWriter struct {
+	writer     io.Writer
+	controller *http.ResponseController
+}
+
+func (w *flushWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	if err != nil {
+		return n, err
+	}
+
+	return n, w.controller.Flush()
+}
@@ -0,0 +1,142 @@
+package mockaso_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestWithStreamBody(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	release := make(chan struct{})
+
+	server.Stub(http.MethodGet, mockaso.Path("/events")).
+		Respond(
+			mockaso.WithStatusCode(http.StatusAccepted),
+			mockaso.WithHeader("Content-Type", "text/plain"),
+			mockaso.WithStreamBody(func(w io.Writer) {
+				fmt.Fprint(w, "first;")
+				<-release
+				fmt.Fprint(w, "second;")
+			}),
+		)
+
+	httpResp, err := server.Client().Get("/events")
+	require.NoError(t, err)
+
+	defer httpResp.Body.Close()
+
+	assert.Equal(t, http.StatusAccepted, httpResp.StatusCode)
+	assert.Equal(t, "text/plain", httpResp.Header.Get("Content-Type"))
+	assert.Equal(t, []string{"chunked"}, httpResp.TransferEncoding)
+
+	first := make([]byte, len("first;"))
+	_, err = io.ReadFull(httpResp.Body, first)
+	require.NoError(t, err)
+	assert.Equal(t, "first;", string(first), "the first chunk is received before the stream ends")
+
+	close(release)
+
+	assert.Equal(t, "second;", readString(httpResp.Body))
+}
+
+func TestWithChunks(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Now()}
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithClock(clock))
+	t.Cleanup(server.MustShutdown)
+
+	chunks := [][]byte{[]byte(`{"page":1}`), []byte(`{"page":2}`), []byte(`{"page":3}`)}
+
+	server.Stub(http.MethodGet, mockaso.Path("/pages")).Respond(mockaso.WithChunks(chunks, time.Second))
+
+	httpResp, err := server.Client().Get("/pages")
+	require.NoError(t, err)
+
+	defer httpResp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+
+	for i, chunk := range chunks {
+		if i > 0 {
+			require.Eventually(t, func() bool { return clock.Waiting() == 1 }, time.Second, time.Millisecond)
+			clock.Advance(time.Second)
+		}
+
+		received := make([]byte, len(chunk))
+		_, err = io.ReadFull(httpResp.Body, received)
+		require.NoError(t, err)
+		assert.Equal(t, string(chunk), string(received))
+	}
+
+	assert.Empty(t, readString(httpResp.Body))
+}
+
+func TestWithJSONLines(t *testing.T) {
+	t.Parallel()
+
+	t.Run("items are streamed as json lines", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &fakeClock{now: time.Now()}
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithClock(clock))
+		t.Cleanup(server.MustShutdown)
+
+		server.Stub(http.MethodGet, mockaso.Path("/events")).
+			Respond(mockaso.WithJSONLines(
+				map[string]any{"id": 1},
+				mockaso.JSONLine{Value: map[string]any{"id": 2}, Delay: time.Second},
+				"done",
+			))
+
+		httpResp, err := server.Client().Get("/events")
+		require.NoError(t, err)
+
+		defer httpResp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assert.Equal(t, "application/x-ndjson", httpResp.Header.Get("Content-Type"))
+
+		reader := bufio.NewReader(httpResp.Body)
+
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "{\"id\":1}\n", line)
+
+		require.Eventually(t, func() bool { return clock.Waiting() == 1 }, time.Second, time.Millisecond)
+		clock.Advance(time.Second)
+
+		line, err = reader.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "{\"id\":2}\n", line)
+
+		line, err = reader.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "\"done\"\n", line)
+
+		assert.Empty(t, readString(reader))
+	})
+
+	t.Run("should panic when an item can't be marshaled", func(t *testing.T) {
+		t.Parallel()
+
+		assert.PanicsWithError(t, "WithJSONLines err: item #1 marshal failed: json: unsupported type: func()", func() {
+			mockaso.WithJSONLines("ok", func() {})
+		})
+	})
+}
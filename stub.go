@@ -1,23 +1,103 @@
 package mockaso
 
 import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Stub interface {
 	StubResponder
 	Match(...StubMatcherRule) StubResponder
+	ExpiresAfter(time.Duration) Stub
+	ExpiresAt(time.Time) Stub
+	MaxConcurrent(int, ...StubResponseRule) Stub
+	Times(int) Stub
+	Once() Stub
+	Name(string) Stub
+	OnMatch(func(*http.Request)) Stub
+	Capture() <-chan *CapturedRequest
+	AssertCalled(TestingT) bool
+	AssertCalledTimes(TestingT, int) bool
+	AssertNotCalled(TestingT) bool
 }
 
 type StubResponder interface {
+	Calls() int
+	LastRequest() *http.Request
 	Respond(...StubResponseRule)
+	RespondWhen([]StubMatcherRule, []StubResponseRule) ConditionalResponder
+	RespondNegotiated(map[string]Response)
+	RespondByLanguage(map[string]Response)
+	RespondSequence(...Response)
+}
+
+// ConditionalResponder allows to chain conditional responses of a stub.
+type ConditionalResponder interface {
+	RespondWhen([]StubMatcherRule, []StubResponseRule) ConditionalResponder
+	RespondDefault(...StubResponseRule)
 }
 
 type stub struct {
 	matchers      []requestMatcherFunc
 	response      *stubResponse
-	patternParams map[string]string
+	branches      []*stubBranch
+	clock         Clock
+	expiresAt     time.Time // the stub does not match from this time, if set
+	concurrency   *concurrencyLimit
+	maxUses       int64 // the stub matches up to this number of requests, if set
+	uses          atomic.Int64
+	calls         atomic.Int64
+	lastRequest   atomic.Pointer[matchedRequest]
+	onMatch       []func(*http.Request) // called for every matched request, before the response is written
+	onResponded   []func(*http.Request) // called after the stub response is written, e.g. not when it is rejected
+	captures      []chan *CapturedRequest
+	capturesMutex sync.Mutex
+	key           *stubKey    // the method and static path of the stub to index it, if any
+	description   string      // the method and URL of the stub, e.g. "GET /api/users"
+	muxPattern    *muxPattern // the pattern of the MuxPattern URL matcher of the stub, if any
+	name          string
+}
+
+// concurrencyLimit rejects the requests in flight beyond the limit.
+type concurrencyLimit struct {
+	limit    int64
+	inFlight atomic.Int64
+	rejected *stubResponse
+}
+
+func (l *concurrencyLimit) acquire() bool {
+	if l.inFlight.Add(1) > l.limit {
+		l.inFlight.Add(-1)
+		return false
+	}
+
+	return true
+}
+
+func (l *concurrencyLimit) release() {
+	l.inFlight.Add(-1)
+}
+
+// stubBranch is a conditional response of a stub.
+type stubBranch struct {
+	matchers []requestMatcherFunc
+	response *stubResponse
+}
+
+func newStub(clock Clock, matchers []requestMatcherFunc) *stub {
+	return &stub{
+		response: newStubResponse(),
+		matchers: matchers,
+		clock:    clock,
+	}
 }
 
 func (s *stub) Match(rules ...StubMatcherRule) StubResponder {
@@ -34,9 +114,160 @@ func (s *stub) Respond(rules ...StubResponseRule) {
 	}
 }
 
+// RespondWhen sets the response rules used when the request also matches the given matcher rules.
+// Conditions are evaluated in the order they were specified and the first one that matches is used.
+// When none of them matches, the default response is used (see RespondDefault).
+func (s *stub) RespondWhen(matchers []StubMatcherRule, rules []StubResponseRule) ConditionalResponder {
+	branch := &stubBranch{response: Response(rules).build()}
+
+	for _, matcher := range matchers {
+		branch.matchers = append(branch.matchers, matcher())
+	}
+
+	s.branches = append(s.branches, branch)
+
+	return s
+}
+
+// RespondDefault sets the response rules used when none of the RespondWhen conditions matches.
+func (s *stub) RespondDefault(rules ...StubResponseRule) {
+	s.Respond(rules...)
+}
+
+// RespondSequence sets the responses of successive calls to the stub, e.g. to test retry logic.
+// The nth request receives the nth response, and the last response is repeated once the sequence is exhausted.
+//
+// Example:
+//
+//	st.RespondSequence(
+//		First(WithStatusCode(http.StatusInternalServerError)),
+//		Then(WithStatusCode(http.StatusOK), WithBody("ok")),
+//	)
+func (s *stub) RespondSequence(responses ...Response) {
+	if len(responses) == 0 {
+		panic(fmt.Errorf("RespondSequence err: at least one response is required"))
+	}
+
+	built := make([]*stubResponse, 0, len(responses))
+	for _, rules := range responses {
+		built = append(built, rules.build())
+	}
+
+	var calls atomic.Int64
+
+	s.Respond(func(r *stubResponse) {
+		r.selector = func(*stub, *http.Request) *stubResponse {
+			i := min(calls.Add(1), int64(len(built))) - 1
+			return built[i]
+		}
+	})
+}
+
+// ExpiresAfter sets the stub to stop matching once the given duration elapses, e.g. to simulate expiring resources.
+func (s *stub) ExpiresAfter(d time.Duration) Stub {
+	return s.ExpiresAt(s.clock.Now().Add(d))
+}
+
+// ExpiresAt sets the stub to stop matching from the given time.
+func (s *stub) ExpiresAt(t time.Time) Stub {
+	s.expiresAt = t
+	return s
+}
+
+// MaxConcurrent limits the requests in flight for the stub to n, e.g. to simulate capacity-limited upstreams.
+// Requests beyond the limit receive 503 Service Unavailable, or the response set by the given rules.
+func (s *stub) MaxConcurrent(n int, rules ...StubResponseRule) Stub {
+	rejected := append(Response{WithStatusCode(http.StatusServiceUnavailable)}, rules...)
+	s.concurrency = &concurrencyLimit{limit: int64(n), rejected: rejected.build()}
+
+	return s
+}
+
+// Times sets the stub to match only the first n requests. Then it is skipped and the requests fall through to the
+// following stubs or the no match response, e.g. to express that a token endpoint is only called once.
+func (s *stub) Times(n int) Stub {
+	if n <= 0 {
+		panic(fmt.Errorf("Times err: n must be positive, got %d", n))
+	}
+
+	s.maxUses = int64(n)
+
+	return s
+}
+
+// Once sets the stub to match only the first request. See Times.
+func (s *stub) Once() Stub {
+	return s.Times(1)
+}
+
+// Name sets a human-readable name of the stub, e.g. "create-user-conflict", which identifies it in the logs, the
+// match traces and the verification failures instead of its position.
+func (s *stub) Name(name string) Stub {
+	s.name = name
+	return s
+}
+
+// OnMatch adds a callback called for every request matched by the stub, before the response is written, e.g. to
+// signal the test or to record side effects. The callback can read the request body, as in WithRequestHook.
+func (s *stub) OnMatch(fn func(*http.Request)) Stub {
+	s.onMatch = append(s.onMatch, func(r *http.Request) {
+		fn(hookRequest(r))
+	})
+
+	return s
+}
+
+// label returns how the stub is referred to in the logs: by its name, if set, or else by its position (from 1).
+// A position of 0 means it is unknown.
+func (s *stub) label(position int) string {
+	switch {
+	case s.name != "":
+		return fmt.Sprintf("stub %q", s.name)
+	case position > 0:
+		return fmt.Sprintf("stub #%d", position)
+	default:
+		return "stub"
+	}
+}
+
+// title returns the label of the stub followed by its method and URL, if known, e.g. "stub #3 GET /api/users".
+func (s *stub) title(position int) string {
+	if s.description == "" {
+		return s.label(position)
+	}
+
+	return s.label(position) + " " + s.description
+}
+
+// use reserves a use of the stub for a matched request, reporting false if the stub was already used up.
+func (s *stub) use() bool {
+	return s.maxUses == 0 || s.uses.Add(1) <= s.maxUses
+}
+
 func (s *stub) match(r *http.Request) bool {
-	for _, match := range s.matchers {
-		if !match(s, r) {
+	_, ok := s.evaluate(r)
+	return ok
+}
+
+// evaluate reports whether the stub matches the request, and returns the state of the evaluation, e.g. the path
+// params, which belongs to the request.
+func (s *stub) evaluate(r *http.Request) (*matchState, bool) {
+	if s.expired() {
+		return nil, false
+	}
+
+	state := new(matchState)
+
+	return state, matchAll(state, s.matchers, r)
+}
+
+func (s *stub) expired() bool {
+	return !s.expiresAt.IsZero() && !s.clock.Now().Before(s.expiresAt)
+}
+
+func matchAll(state *matchState, matchers []requestMatcherFunc, r *http.Request) bool {
+	for _, match := range matchers {
+		if !match(state, r) {
 			return false
 		}
 	}
@@ -44,24 +275,204 @@ func (s *stub) match(r *http.Request) bool {
 	return true
 }
 
-func (s *stub) write(w http.ResponseWriter) {
-	if s.response.delay > 0 {
-		time.Sleep(s.response.delay)
+func (s *stub) responseFor(r *http.Request) *stubResponse {
+	response := s.response
+
+	state := &matchState{params: ParamsFromRequest(r)}
+
+	for _, branch := range s.branches {
+		if matchAll(state, branch.matchers, r) {
+			response = branch.response
+			break
+		}
 	}
 
-	for k, v := range s.response.headers {
+	for response.selector != nil {
+		selected := response.selector(s, r)
+		if selected == response { // the response selected itself
+			break
+		}
+
+		response = selected
+	}
+
+	return response
+}
+
+func (s *stub) write(w http.ResponseWriter, r *http.Request) {
+	s.calls.Add(1)
+
+	if s.response.expectContinue == expectContinueAccept && expectsContinue(r) {
+		acceptContinue(w, r) // before the capture, so the captured request has the body
+	}
+
+	s.capture(r)
+
+	for _, fn := range s.onMatch {
+		fn(r)
+	}
+
+	if s.concurrency != nil {
+		if !s.concurrency.acquire() {
+			s.writeResponse(w, r, s.concurrency.rejected)
+			return
+		}
+
+		defer s.concurrency.release()
+	}
+
+	s.writeResponse(w, r, s.responseFor(r))
+
+	for _, fn := range s.onResponded {
+		fn(r)
+	}
+}
+
+func (s *stub) writeResponse(w http.ResponseWriter, r *http.Request, response *stubResponse) {
+	if expectsContinue(r) {
+		switch response.expectContinue {
+		case expectContinueAccept:
+			acceptContinue(w, r)
+		case expectContinueReject: // the final response is written without reading the body
+			w.Header().Set("Connection", "close") // the body was not uploaded, so the connection is not reusable
+		}
+	}
+
+	for _, wait := range response.waits {
+		wait(r)
+	}
+
+	if response.delay > 0 {
+		<-s.clock.After(response.delay)
+	}
+
+	if response.hijack != nil {
+		hijackConn(w, response.hijack)
+		return
+	}
+
+	if response.webSocket != nil {
+		response.webSocket.serve(s, w, r)
+		return
+	}
+
+	for k, v := range response.headers {
 		w.Header().Set(k, v)
 	}
 
-	w.WriteHeader(s.response.statusCode)
-	_, _ = w.Write(s.response.body)
+	for _, cookie := range response.cookies {
+		http.SetCookie(w, cookie)
+	}
+
+	if response.etag != "" && etagMatches(r.Header.Get("If-None-Match"), response.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if response.stream != nil {
+		response.writeStream(s, w, r)
+		return
+	}
+
+	body := response.bodyFor(s, r)
+
+	for _, setHeader := range response.bodyHeaders {
+		setHeader(s, w.Header(), body)
+	}
+
+	if response.compression != "" {
+		body = encodeCompressed(w, response.compression, body)
+	}
+
+	if response.negotiateEncoding {
+		var ok bool
+
+		if body, ok = encodeNegotiated(w, r, body); !ok {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+	}
+
+	w.WriteHeader(response.statusCode)
+	response.writeBody(w, body)
 }
 
 type stubResponse struct {
 	statusCode int
 	body       []byte
+	bodyFunc   func(*stub, *http.Request) []byte        // when set, the body is computed on every request
+	stream     func(*stub, io.Writer, *http.Request)    // when set, the body is streamed, see WithStreamBody
+	selector   func(*stub, *http.Request) *stubResponse // when set, the response is selected on every request
 	headers    map[string]string
+	cookies    []*http.Cookie
+	graphQL    *graphQLResponse // the GraphQL response envelope, see WithGraphQLData
 	delay      time.Duration
+	waits      []func(*http.Request) // block the response until they return, e.g. gates
+	etag       string
+
+	bodyHeaders []func(*stub, http.Header, []byte) // headers computed from the body, e.g. signatures
+
+	negotiateEncoding bool
+	compression       string // when set, the body is compressed with this encoding, see WithCompression
+
+	flushEvery int  // when set, the body is written in pieces of this size, flushed one by one
+	unbuffered bool // when set, the header and the body are flushed as soon as they are written
+
+	hijack    func(net.Conn, *bufio.ReadWriter) // when set, the connection is handed to it instead of writing a response
+	webSocket *webSocketScript                  // when set, the connection is upgraded to run it, see StubWebSocket
+
+	expectContinue expectContinueMode
+}
+
+type expectContinueMode int
+
+const (
+	expectContinueAccept expectContinueMode = iota + 1
+	expectContinueReject
+)
+
+func expectsContinue(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Expect"), "100-continue")
+}
+
+// acceptContinue sends the 100 Continue, unless the body was already read, and reads the uploaded body.
+func acceptContinue(w http.ResponseWriter, r *http.Request) {
+	if !requestBodyCache(r).read.Load() {
+		w.WriteHeader(http.StatusContinue)
+	}
+
+	_ = mustReadBody(r)
+}
+
+func (r *stubResponse) bodyFor(st *stub, req *http.Request) []byte {
+	if r.bodyFunc != nil {
+		return r.bodyFunc(st, req)
+	}
+
+	return r.body
+}
+
+func (r *stubResponse) writeBody(w http.ResponseWriter, body []byte) {
+	rc := http.NewResponseController(w)
+
+	if r.flushEvery > 0 {
+		for piece := range slices.Chunk(body, r.flushEvery) {
+			_, _ = w.Write(piece)
+			_ = rc.Flush()
+		}
+
+		return
+	}
+
+	if r.unbuffered {
+		_ = rc.Flush()
+	}
+
+	_, _ = w.Write(body)
+
+	if r.unbuffered {
+		_ = rc.Flush()
+	}
 }
 
 func (r *stubResponse) setHeader(key, value string) {
@@ -76,7 +487,13 @@ func (r *stubResponse) setHeaders(headers map[string]string) {
 
 func (r *stubResponse) setJSON(content []byte) {
 	r.headers["Content-Type"] = "application/json"
+	r.setBody(content)
+}
+
+func (r *stubResponse) setBody(content []byte) {
 	r.body = content
+	r.bodyFunc = nil
+	r.stream = nil
 }
 
 func newStubResponse() *stubResponse {
@@ -0,0 +1,339 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestStub_RespondWhen(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const path = "/test/respond-when"
+
+	server.Stub(http.MethodPost, mockaso.Path(path)).
+		RespondWhen(
+			[]mockaso.StubMatcherRule{mockaso.MatchHeader("X-Role", "admin")},
+			[]mockaso.StubResponseRule{mockaso.WithStatusCode(http.StatusOK), mockaso.WithBody("admin")},
+		).
+		RespondWhen(
+			[]mockaso.StubMatcherRule{mockaso.MatchRawJSONBody(`{"name":"john"}`)},
+			[]mockaso.StubResponseRule{mockaso.WithStatusCode(http.StatusConflict), mockaso.WithBody("john exists")},
+		).
+		RespondDefault(
+			mockaso.WithStatusCode(http.StatusCreated),
+			mockaso.WithBody("created"),
+		)
+
+	testCases := map[string]struct {
+		header         string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"should respond with the first matching condition": {
+			header:         "admin",
+			body:           `{"name":"john"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "admin",
+		},
+		"should respond with the second condition when the first does not match": {
+			header:         "user",
+			body:           `{"name":"john"}`,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "john exists",
+		},
+		"should respond with the default response when no condition match": {
+			header:         "user",
+			body:           `{"name":"rick"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   "created",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(tc.body))
+			httpReq.Header.Set("X-Role", tc.header)
+
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedStatus, httpResp.StatusCode)
+			assertBodyString(t, tc.expectedBody, httpResp)
+		})
+	}
+
+	t.Run("should respond with http 200 when no condition match and no default was specified", func(t *testing.T) {
+		t.Parallel()
+
+		const path = path + "/no-default"
+
+		server.Stub(http.MethodGet, mockaso.Path(path)).
+			Match(mockaso.MatchQuery("page", "1")).
+			RespondWhen(
+				[]mockaso.StubMatcherRule{mockaso.MatchHeader("X-Role", "admin")},
+				[]mockaso.StubResponseRule{mockaso.WithStatusCode(http.StatusAccepted)},
+			)
+
+		httpReq, _ := http.NewRequest(http.MethodGet, path+"?page=1", http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+	})
+}
+
+func TestStub_RespondSequence(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should respond the sequence and then repeat the last response", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		server.Stub(http.MethodGet, mockaso.Path("/users")).RespondSequence(
+			mockaso.First(mockaso.WithStatusCode(http.StatusInternalServerError)),
+			mockaso.Then(mockaso.WithStatusCode(http.StatusBadGateway)),
+			mockaso.Then(mockaso.WithStatusCode(http.StatusOK), mockaso.WithBody("ok")),
+		)
+
+		expectedStatuses := []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK, http.StatusOK}
+
+		for i, expectedStatus := range expectedStatuses {
+			httpResp, err := server.Client().Get("/users")
+			require.NoError(t, err)
+
+			assert.Equal(t, expectedStatus, httpResp.StatusCode, "request #%d", i+1)
+		}
+	})
+
+	t.Run("should panic when the sequence is empty", func(t *testing.T) {
+		t.Parallel()
+
+		st := mockaso.NewServer().Stub(http.MethodGet, mockaso.Path("/users"))
+
+		fn := func() { st.RespondSequence() }
+		assert.PanicsWithError(t, "RespondSequence err: at least one response is required", fn)
+	})
+}
+
+func TestStub_Times(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should match only the first n requests and then fall through", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		server.Stub(http.MethodGet, mockaso.Path("/users")).Times(2).Respond(mockaso.WithStatusCode(http.StatusOK))
+		server.Stub(http.MethodGet, mockaso.Path("/users")).Respond(mockaso.WithStatusCode(http.StatusGone))
+
+		expectedStatuses := []int{http.StatusOK, http.StatusOK, http.StatusGone}
+
+		for i, expectedStatus := range expectedStatuses {
+			httpResp, err := server.Client().Get("/users")
+			require.NoError(t, err)
+
+			assert.Equal(t, expectedStatus, httpResp.StatusCode, "request #%d", i+1)
+		}
+	})
+
+	t.Run("should panic when n is not positive", func(t *testing.T) {
+		t.Parallel()
+
+		st := mockaso.NewServer().Stub(http.MethodGet, mockaso.Path("/users"))
+
+		fn := func() { st.Times(0) }
+		assert.PanicsWithError(t, "Times err: n must be positive, got 0", fn)
+	})
+}
+
+func TestStub_Once(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodPost, mockaso.Path("/oauth/token")).Once().Respond(mockaso.WithStatusCode(http.StatusOK))
+
+	httpResp, err := server.Client().Post("/oauth/token", "", http.NoBody)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+
+	httpResp, err = server.Client().Post("/oauth/token", "", http.NoBody)
+	require.NoError(t, err)
+	assert.Equal(t, 666, httpResp.StatusCode)
+}
+
+func TestStub_ExpiresAfter(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Now()}
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithClock(clock))
+	t.Cleanup(server.MustShutdown)
+
+	const path = "/test/expires-after"
+
+	server.Stub(http.MethodGet, mockaso.Path(path)).
+		ExpiresAfter(time.Minute).
+		Respond(mockaso.WithStatusCode(http.StatusOK))
+
+	send := func() (*http.Request, *http.Response) {
+		httpReq, _ := http.NewRequest(http.MethodGet, path, http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		return httpReq, httpResp
+	}
+
+	_, httpResp := send()
+	assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+
+	clock.Advance(59 * time.Second)
+	_, httpResp = send()
+	assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+
+	clock.Advance(time.Second)
+	httpReq, httpResp := send()
+	assertNotMatchedResponse(t, httpReq, httpResp)
+}
+
+func TestStub_ExpiresAt(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodGet, mockaso.Path("/test/expires-at/past")).
+		ExpiresAt(time.Now().Add(-time.Second)).
+		Respond(mockaso.WithStatusCode(http.StatusOK))
+
+	server.Stub(http.MethodGet, mockaso.Path("/test/expires-at/future")).
+		ExpiresAt(time.Now().Add(time.Hour)).
+		Respond(mockaso.WithStatusCode(http.StatusOK))
+
+	t.Run("should not match when the stub expired", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, _ := http.NewRequest(http.MethodGet, "/test/expires-at/past", http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assertNotMatchedResponse(t, httpReq, httpResp)
+	})
+
+	t.Run("should match when the stub did not expire", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, _ := http.NewRequest(http.MethodGet, "/test/expires-at/future", http.NoBody)
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+	})
+}
+
+func TestStub_MaxConcurrent(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Now()}
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t), mockaso.WithClock(clock))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodGet, mockaso.Path("/test/max-concurrent/default")).
+		MaxConcurrent(1).
+		Respond(mockaso.WithStatusCode(http.StatusOK), mockaso.WithDelay(time.Second))
+
+	server.Stub(http.MethodGet, mockaso.Path("/test/max-concurrent/custom")).
+		MaxConcurrent(1, mockaso.WithStatusCode(http.StatusTooManyRequests), mockaso.WithBody("busy")).
+		Respond(mockaso.WithStatusCode(http.StatusOK), mockaso.WithDelay(time.Second))
+
+	testCases := map[string]struct {
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"should respond with http 503 when the limit is exceeded": {
+			path:           "/test/max-concurrent/default",
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		"should respond with the given response when the limit is exceeded": {
+			path:           "/test/max-concurrent/custom",
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   "busy",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) { // not parallel, the clock is shared
+			inFlight := make(chan *http.Response, 1)
+
+			go func() {
+				httpReq, _ := http.NewRequest(http.MethodGet, tc.path, http.NoBody)
+				httpResp, _ := server.Client().Do(httpReq)
+				inFlight <- httpResp
+			}()
+
+			require.Eventually(t, func() bool { return clock.Waiting() == 1 }, time.Second, 10*time.Millisecond)
+
+			httpReq, _ := http.NewRequest(http.MethodGet, tc.path, http.NoBody)
+			httpResp, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedStatus, httpResp.StatusCode)
+			assertBodyString(t, tc.expectedBody, httpResp)
+
+			clock.Advance(time.Second)
+
+			httpResp = <-inFlight
+			require.NotNil(t, httpResp)
+			assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		})
+	}
+}
+
+func TestStub_Name(t *testing.T) {
+	t.Parallel()
+
+	logger, buff := newTestLogLogger()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(logger), mockaso.WithMatchTracing())
+	t.Cleanup(server.MustShutdown)
+
+	st := server.Stub(http.MethodPost, mockaso.Path("/api/users")).Name("create-user-conflict")
+	st.Match(mockaso.MatchHeader("X-Token", "abc")).Respond(mockaso.WithStatusCode(http.StatusConflict))
+
+	server.Stub(http.MethodGet, mockaso.Path("/api/users"))
+
+	t.Run("should refer to the name in the verification failures", func(t *testing.T) {
+		fake := new(fakeT)
+		assert.False(t, st.AssertCalled(fake))
+		assert.Equal(t, []string{`stub "create-user-conflict" was not called`}, fake.errors)
+	})
+
+	t.Run("should refer to the name in the match traces", func(t *testing.T) {
+		httpResp, err := server.Client().Post("/api/users", "application/json", nil)
+		require.NoError(t, err)
+		httpResp.Body.Close()
+
+		expected := "match trace for POST /api/users:\n" +
+			"\tstub \"create-user-conflict\" POST /api/users: header X-Token mismatch\n" +
+			"\tstub #2 GET /api/users: method mismatch\n"
+
+		assert.Contains(t, buff.String(), expected)
+	})
+}
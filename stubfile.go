@@ -0,0 +1,512 @@
+package mockaso
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StubFileVersion is the current version of the stub file format. Files of older versions are upgraded when loaded,
+// see LoadStubs and MigrateStubs. A file without version is considered version 1.
+const StubFileVersion = 1
+
+// stubFileMigrations upgrade decoded stub files to StubFileVersion, see stubFileFormat.
+var stubFileMigrations []func(map[string]any) error
+
+type stubFile struct {
+	Version int              `json:"version"`
+	Stubs   []stubDefinition `json:"stubs"`
+}
+
+type stubDefinition struct {
+	Name     string             `json:"name,omitempty"`
+	Request  requestDefinition  `json:"request"`
+	Response responseDefinition `json:"response"`
+}
+
+type requestDefinition struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url,omitempty"`
+	Path        string            `json:"path,omitempty"`
+	URLPattern  string            `json:"urlPattern,omitempty"`
+	PathPattern string            `json:"pathPattern,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Query       map[string]string `json:"query,omitempty"`
+	Params      map[string]string `json:"params,omitempty"`
+	Body        json.RawMessage   `json:"body,omitempty"` // matched as JSON
+}
+
+type responseDefinition struct {
+	Status   int               `json:"status,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     string            `json:"body,omitempty"`
+	BodyFile string            `json:"bodyFile,omitempty"` // relative to the stub file directory
+	JSON     json.RawMessage   `json:"json,omitempty"`
+	Template string            `json:"template,omitempty"` // see WithBodyTemplate, with the FakerFuncs
+	Seed     string            `json:"seed,omitempty"`     // the name the faker seed derives from, see SeedFromName
+	Delay    string            `json:"delay,omitempty"`    // time.Duration format, e.g. 250ms
+}
+
+// LoadStubs reads declarative stub definitions (JSON) and registers them in the server.
+// Files of older format versions are upgraded before being loaded.
+//
+// Example:
+//
+//	{
+//	  "version": 1,
+//	  "stubs": [
+//	    {
+//	      "name": "get-user",
+//	      "request": {"method": "GET", "pathPattern": "/users/{id}", "params": {"id": "1"}},
+//	      "response": {"status": 200, "json": {"id": 1, "name": "john"}, "delay": "100ms"}
+//	    }
+//	  ]
+//	}
+//
+// The request URL is set by one of url, path, urlPattern or pathPattern. The request body is matched as JSON.
+// The response body is set by one of body, bodyFile (relative to the working directory), json or template.
+// The template is rendered as in WithBodyTemplate with the FakerFuncs, seeded with SeedFromName of the response
+// seed or, if not set, of the stub name or else its method and URL, e.g.
+// {"template": "{\"id\":\"{{ fakeUUID }}\",\"user\":\"{{ .Params.id }}\"}", "seed": "users"}.
+// The optional name identifies the stub in the logs and errors, see Stub.Name.
+func (s *Server) LoadStubs(r io.Reader) error {
+	stubs, err := s.readStubs(r, osBodyFiles("."))
+	if err != nil {
+		return err
+	}
+
+	s.addStubs(stubs)
+
+	return nil
+}
+
+// LoadStubsFromFile reads the stub definitions of a file and registers them in the server (see LoadStubs).
+// Files with the .yaml or .yml extension are read as YAML, other files as JSON.
+// The response bodyFile paths are relative to the directory of the file.
+func (s *Server) LoadStubsFromFile(path string) error {
+	stubs, err := s.readStubsFile(path)
+	if err != nil {
+		return err
+	}
+
+	s.addStubs(stubs)
+
+	return nil
+}
+
+// LoadStubsFromDir reads the stub definitions of the .json, .yaml and .yml files in the directory, in lexical
+// order, and registers them in the server (see LoadStubsFromFile). Subdirectories are not read.
+// No stub is registered if any file fails.
+func (s *Server) LoadStubsFromDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("read stubs dir failed: %w", err)
+	}
+
+	stubs, err := readStubsDir(entries, func(name string) ([]*stub, error) {
+		return s.readStubsFile(filepath.Join(dir, name))
+	})
+	if err != nil {
+		return err
+	}
+
+	s.addStubs(stubs)
+
+	return nil
+}
+
+// LoadStubsFromFS reads the stub definitions of the .json, .yaml and .yml files in the directory of the file system,
+// as LoadStubsFromDir does, e.g. to load stubs embedded with go:embed regardless of the working directory.
+// The response bodyFile paths are relative to the directory, in the same file system.
+//
+// Example:
+//
+//	//go:embed testdata/stubs
+//	var stubs embed.FS
+//
+//	server.LoadStubsFromFS(stubs, "testdata/stubs")
+func (s *Server) LoadStubsFromFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("read stubs dir failed: %w", err)
+	}
+
+	stubs, err := readStubsDir(entries, func(name string) ([]*stub, error) {
+		return s.readStubsFSFile(fsys, path.Join(dir, name))
+	})
+	if err != nil {
+		return err
+	}
+
+	s.addStubs(stubs)
+
+	return nil
+}
+
+// readStubsDir reads the stub files of the directory entries, in order, with the given function.
+func readStubsDir(entries []fs.DirEntry, readFile func(name string) ([]*stub, error)) ([]*stub, error) {
+	var stubs []*stub
+
+	for _, entry := range entries {
+		if entry.IsDir() || !isStubFile(entry.Name()) {
+			continue
+		}
+
+		fileStubs, err := readFile(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		stubs = append(stubs, fileStubs...)
+	}
+
+	return stubs, nil
+}
+
+func (s *Server) readStubsFile(path string) ([]*stub, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read stub file failed: %w", err)
+	}
+
+	return s.readStubsData(path, data, osBodyFiles(filepath.Dir(path)))
+}
+
+func (s *Server) readStubsFSFile(fsys fs.FS, name string) ([]*stub, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("read stub file failed: %w", err)
+	}
+
+	return s.readStubsData(name, data, fsBodyFiles(fsys, path.Dir(name)))
+}
+
+// readStubsData decodes the stub definitions of the file with the given name and content.
+func (s *Server) readStubsData(name string, data []byte, bodyFiles bodyFileReader) ([]*stub, error) {
+	var err error
+
+	if isYAMLFile(name) {
+		if data, err = yamlToJSON(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	stubs, err := s.readStubs(bytes.NewReader(data), bodyFiles)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return stubs, nil
+}
+
+// bodyFileReader reads the response bodyFile of the stub definitions.
+type bodyFileReader func(name string) ([]byte, error)
+
+// osBodyFiles reads the bodyFile paths from the OS file system, relative to baseDir unless they are absolute.
+func osBodyFiles(baseDir string) bodyFileReader {
+	return func(name string) ([]byte, error) {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(baseDir, name)
+		}
+
+		return os.ReadFile(name)
+	}
+}
+
+// fsBodyFiles reads the bodyFile paths from the file system, relative to baseDir.
+func fsBodyFiles(fsys fs.FS, baseDir string) bodyFileReader {
+	return func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, path.Join(baseDir, name))
+	}
+}
+
+// readStubs decodes the stub definitions, with the bodyFile paths read by bodyFiles.
+func (s *Server) readStubs(r io.Reader, bodyFiles bodyFileReader) ([]*stub, error) {
+	file, err := decodeStubFile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	stubs := make([]*stub, 0, len(file.Stubs))
+
+	for i, def := range file.Stubs {
+		st, err := def.toStub(s, bodyFiles)
+		if err != nil && def.Name != "" {
+			return nil, fmt.Errorf("stub %q: %w", def.Name, err)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("stub #%d: %w", i, err)
+		}
+
+		stubs = append(stubs, st)
+	}
+
+	return stubs, nil
+}
+
+func (s *Server) addStubs(stubs []*stub) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.appendStubs(stubs...)
+}
+
+func isStubFile(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".json") || isYAMLFile(name)
+}
+
+func isYAMLFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// yamlToJSON converts a YAML document to JSON, so it is decoded as JSON stub files.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var doc any
+
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode yaml failed: %w", err)
+	}
+
+	converted, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("convert yaml to json failed: %w", err)
+	}
+
+	return converted, nil
+}
+
+// MigrateStubs reads stub definitions of any supported format version and writes them in the current version.
+func MigrateStubs(r io.Reader, w io.Writer) error {
+	return currentStubFileFormat().migrate(r, w)
+}
+
+// stubFileFormat is a version of the stub file format with the migrations that upgrade older files to it.
+type stubFileFormat struct {
+	version    int
+	migrations []func(map[string]any) error // the migration at index i upgrades a file from version i+1 to i+2
+}
+
+func currentStubFileFormat() stubFileFormat {
+	return stubFileFormat{version: StubFileVersion, migrations: stubFileMigrations}
+}
+
+// decodeStubFile decodes the stub file and upgrades it to the current version.
+func decodeStubFile(r io.Reader) (*stubFile, error) {
+	return currentStubFileFormat().decode(r)
+}
+
+func (f stubFileFormat) migrate(r io.Reader, w io.Writer) error {
+	file, err := f.decode(r)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err = encoder.Encode(file); err != nil {
+		return fmt.Errorf("encode stub file failed: %w", err)
+	}
+
+	return nil
+}
+
+func (f stubFileFormat) decode(r io.Reader) (*stubFile, error) {
+	var doc map[string]any
+
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode stub file failed: %w", err)
+	}
+
+	version := 1
+
+	if v, ok := doc["version"]; ok {
+		number, isNumber := v.(float64)
+		if !isNumber || number != float64(int(number)) {
+			return nil, fmt.Errorf("invalid stub file version: %v", v)
+		}
+
+		version = int(number)
+	}
+
+	if version < 1 || version > f.version {
+		return nil, fmt.Errorf("unsupported stub file version %d, the current version is %d", version, f.version)
+	}
+
+	for ; version < f.version; version++ {
+		if err := f.migrations[version-1](doc); err != nil {
+			return nil, fmt.Errorf("migrate stub file from version %d failed: %w", version, err)
+		}
+	}
+
+	doc["version"] = f.version
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("encode migrated stub file failed: %w", err)
+	}
+
+	var file stubFile
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err = decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("decode stub file failed: %w", err)
+	}
+
+	return &file, nil
+}
+
+func (d stubDefinition) toStub(s *Server, bodyFiles bodyFileReader) (st *stub, err error) {
+	defer func() { // invalid urls and patterns panic, as when they are set in code
+		if r := recover(); r != nil {
+			st, err = nil, fmt.Errorf("%v", r)
+		}
+	}()
+
+	if d.Request.Method == "" {
+		return nil, errors.New("request method is required")
+	}
+
+	url, err := d.Request.urlMatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	st = s.newStub(d.Request.Method, url)
+	st.name = d.Name
+	st.Match(d.Request.matchers()...)
+
+	rules, err := d.Response.rules(bodyFiles, cmp.Or(d.Response.Seed, d.Name, st.description))
+	if err != nil {
+		return nil, err
+	}
+
+	st.Respond(rules...)
+
+	return st, nil
+}
+
+func (d requestDefinition) urlMatcher() (URLMatcher, error) {
+	var matchers []URLMatcher
+
+	if d.URL != "" {
+		matchers = append(matchers, URL(d.URL))
+	}
+
+	if d.Path != "" {
+		matchers = append(matchers, Path(d.Path))
+	}
+
+	if d.URLPattern != "" {
+		matchers = append(matchers, URLPattern(d.URLPattern))
+	}
+
+	if d.PathPattern != "" {
+		matchers = append(matchers, PathPattern(d.PathPattern))
+	}
+
+	if len(matchers) != 1 {
+		return nil, errors.New("request must have one of url, path, urlPattern or pathPattern")
+	}
+
+	return matchers[0], nil
+}
+
+func (d requestDefinition) matchers() []StubMatcherRule {
+	var rules []StubMatcherRule
+
+	for key, value := range d.Headers {
+		rules = append(rules, MatchHeader(key, value))
+	}
+
+	for key, value := range d.Query {
+		rules = append(rules, MatchQuery(key, value))
+	}
+
+	for key, value := range d.Params {
+		rules = append(rules, MatchParam(key, value))
+	}
+
+	if len(d.Body) > 0 {
+		rules = append(rules, MatchRawJSONBody(d.Body))
+	}
+
+	return rules
+}
+
+// rules returns the response rules of the definition. seedName is the name the faker seed of templates derives from.
+func (d responseDefinition) rules(bodyFiles bodyFileReader, seedName string) ([]StubResponseRule, error) {
+	rules := []StubResponseRule{WithStatusCode(http.StatusOK)}
+
+	if d.Status != 0 {
+		rules = append(rules, WithStatusCode(d.Status))
+	}
+
+	if countNonEmpty(d.Body, d.BodyFile, string(d.JSON), d.Template) > 1 {
+		return nil, errors.New("response must have only one of body, bodyFile, json or template")
+	}
+
+	if d.Body != "" {
+		rules = append(rules, WithBody(d.Body))
+	}
+
+	if d.BodyFile != "" {
+		body, err := bodyFiles(d.BodyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read response body file failed: %w", err)
+		}
+
+		rules = append(rules, WithBody(body))
+	}
+
+	if len(d.JSON) > 0 {
+		rules = append(rules, WithRawJSON(d.JSON))
+	}
+
+	if d.Template != "" {
+		rules = append(rules, WithBodyTemplate(d.Template, FakerFuncs(SeedFromName(seedName))))
+	}
+
+	if len(d.Headers) > 0 { // after the body, so they can override its Content-Type
+		rules = append(rules, WithHeaders(d.Headers))
+	}
+
+	if d.Delay != "" {
+		delay, err := time.ParseDuration(d.Delay)
+		if err != nil {
+			return nil, fmt.Errorf("invalid response delay: %w", err)
+		}
+
+		rules = append(rules, WithDelay(delay))
+	}
+
+	return rules, nil
+}
+
+func countNonEmpty(values ...string) int {
+	var count int
+
+	for _, v := range values {
+		if v != "" {
+			count++
+		}
+	}
+
+	return count
+}
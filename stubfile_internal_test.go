@@ -0,0 +1,83 @@
+package mockaso
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStubFileFormat_Migrations(t *testing.T) {
+	t.Parallel()
+
+	// v2 renames the request uri of v1 to path
+	format := stubFileFormat{
+		version: 2,
+		migrations: []func(map[string]any) error{
+			func(doc map[string]any) error {
+				stubs, _ := doc["stubs"].([]any)
+				for _, s := range stubs {
+					request, _ := s.(map[string]any)["request"].(map[string]any)
+					if uri, ok := request["uri"]; ok {
+						request["path"] = uri
+						delete(request, "uri")
+					}
+				}
+
+				return nil
+			},
+		},
+	}
+
+	const v1File = `{
+		"version": 1,
+		"stubs": [{"request": {"method": "GET", "uri": "/users"}, "response": {"status": 204}}]
+	}`
+
+	t.Run("should decode the stubs upgraded to the current version", func(t *testing.T) {
+		t.Parallel()
+
+		file, err := format.decode(strings.NewReader(v1File))
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, file.Version)
+		require.Len(t, file.Stubs, 1)
+		assert.Equal(t, requestDefinition{Method: "GET", Path: "/users"}, file.Stubs[0].Request)
+		assert.Equal(t, responseDefinition{Status: 204}, file.Stubs[0].Response)
+	})
+
+	t.Run("should write the migrated stubs", func(t *testing.T) {
+		t.Parallel()
+
+		var migrated bytes.Buffer
+
+		require.NoError(t, format.migrate(strings.NewReader(v1File), &migrated))
+
+		assert.JSONEq(t, `{
+			"version": 2,
+			"stubs": [{"request": {"method": "GET", "path": "/users"}, "response": {"status": 204}}]
+		}`, migrated.String())
+	})
+
+	t.Run("should not migrate files of the current version", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := format.decode(strings.NewReader(`{"version": 2, "stubs": [{"request": {"uri": "/users"}}]}`))
+		assert.ErrorContains(t, err, `unknown field "uri"`)
+	})
+
+	t.Run("should fail when a migration fails", func(t *testing.T) {
+		t.Parallel()
+
+		failing := stubFileFormat{
+			version:    2,
+			migrations: []func(map[string]any) error{func(map[string]any) error { return errors.New("boom") }},
+		}
+
+		_, err := failing.decode(strings.NewReader(`{"stubs": []}`))
+		assert.EqualError(t, err, "migrate stub file from version 1 failed: boom")
+	})
+}
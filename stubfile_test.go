@@ -0,0 +1,417 @@
+package mockaso_test
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+const stubFile = `{
+  "version": 1,
+  "stubs": [
+    {
+      "request": {"method": "GET", "pathPattern": "/users/{id}", "params": {"id": "1"}},
+      "response": {"json": {"id": 1, "name": "john"}}
+    },
+    {
+      "request": {
+        "method": "POST",
+        "path": "/users",
+        "headers": {"X-Role": "admin"},
+        "query": {"notify": "true"},
+        "body": {"name": "rick"}
+      },
+      "response": {"status": 201, "body": "created", "headers": {"Location": "/users/2"}}
+    }
+  ]
+}`
+
+func TestServer_LoadStubs(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	require.NoError(t, server.LoadStubs(strings.NewReader(stubFile)))
+
+	t.Run("should respond with the stub matching the path pattern", func(t *testing.T) {
+		t.Parallel()
+
+		httpResp, err := server.Client().Get("/users/1")
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assert.Equal(t, "application/json", httpResp.Header.Get("Content-Type"))
+		assert.JSONEq(t, `{"id": 1, "name": "john"}`, readString(httpResp.Body))
+	})
+
+	t.Run("should respond with the stub matching the request rules", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, _ := http.NewRequest(http.MethodPost, "/users?notify=true", strings.NewReader(`{"name":"rick"}`))
+		httpReq.Header.Set("X-Role", "admin")
+
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, httpResp.StatusCode)
+		assert.Equal(t, "/users/2", httpResp.Header.Get("Location"))
+		assertBodyString(t, "created", httpResp)
+	})
+
+	t.Run("should not match when the request rules do not match", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, _ := http.NewRequest(http.MethodPost, "/users?notify=true", strings.NewReader(`{"name":"morty"}`))
+		httpReq.Header.Set("X-Role", "admin")
+
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assertNotMatchedResponse(t, httpReq, httpResp)
+	})
+}
+
+func TestServer_LoadStubs_Errors(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		file          string
+		expectedError string
+	}{
+		"should fail when the version is newer": {
+			file:          `{"version": 2, "stubs": []}`,
+			expectedError: "unsupported stub file version 2, the current version is 1",
+		},
+		"should fail when the version is not a number": {
+			file:          `{"version": "1", "stubs": []}`,
+			expectedError: "invalid stub file version: 1",
+		},
+		"should fail when a field is unknown": {
+			file:          `{"stubs": [{"request": {"method": "GET", "path": "/"}, "reponse": {}}]}`,
+			expectedError: `decode stub file failed: json: unknown field "reponse"`,
+		},
+		"should fail when the method is missing": {
+			file:          `{"stubs": [{"request": {"path": "/"}}]}`,
+			expectedError: "stub #0: request method is required",
+		},
+		"should fail when there is no url": {
+			file:          `{"stubs": [{"request": {"method": "GET"}}]}`,
+			expectedError: "stub #0: request must have one of url, path, urlPattern or pathPattern",
+		},
+		"should fail when the path has query params": {
+			file:          `{"stubs": [{"request": {"method": "GET", "path": "/users?id=1"}}]}`,
+			expectedError: "stub #0: pattern must not contain any query string parameters",
+		},
+		"should fail when the delay is not valid": {
+			file:          `{"stubs": [{"request": {"method": "GET", "path": "/"}, "response": {"delay": "1 second"}}]}`,
+			expectedError: `stub #0: invalid response delay: time: unknown unit " second" in duration "1 second"`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := mockaso.NewServer().LoadStubs(strings.NewReader(tc.file))
+			assert.EqualError(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestServer_LoadStubs_Template(t *testing.T) {
+	t.Parallel()
+
+	const file = `{"stubs": [{
+	  "request": {"method": "GET", "path": "/users"},
+	  "response": {"template": "{\"id\":\"{{ fakeUUID }}\",\"q\":\"{{ .Query.q }}\"}", "seed": "users"}
+	}]}`
+
+	get := func(t *testing.T) string {
+		t.Helper()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		require.NoError(t, server.LoadStubs(strings.NewReader(file)))
+
+		httpResp, err := server.Client().Get("/users?q=john")
+		require.NoError(t, err)
+
+		return readString(httpResp.Body)
+	}
+
+	first, second := get(t), get(t)
+
+	assert.JSONEq(t, first, second, "the faker is seeded with the response seed")
+	assert.Contains(t, first, `"q":"john"`)
+	assert.NotContains(t, first, "fakeUUID")
+
+	t.Run("should fail when the template is not valid", func(t *testing.T) {
+		t.Parallel()
+
+		err := mockaso.NewServer().LoadStubs(strings.NewReader(
+			`{"stubs": [{"request": {"method": "GET", "path": "/"}, "response": {"template": "{{ .Query"}}]}`))
+		assert.ErrorContains(t, err, "stub #0: WithBodyTemplate err: invalid template")
+	})
+}
+
+func TestServer_LoadStubsFromFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "bodies", "user.json"), `{"id": 1, "name": "john"}`)
+	writeFile(t, filepath.Join(dir, "users.yaml"), `
+version: 1
+stubs:
+  - request:
+      method: GET
+      path: /users/1
+    response:
+      bodyFile: bodies/user.json
+      headers:
+        Content-Type: application/json
+  - request:
+      method: POST
+      path: /users
+      body:
+        name: rick
+    response:
+      status: 201
+`)
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	require.NoError(t, server.LoadStubsFromFile(filepath.Join(dir, "users.yaml")))
+
+	t.Run("should respond with the body file", func(t *testing.T) {
+		t.Parallel()
+
+		httpResp, err := server.Client().Get("/users/1")
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assert.Equal(t, "application/json", httpResp.Header.Get("Content-Type"))
+		assertBodyString(t, `{"id": 1, "name": "john"}`, httpResp)
+	})
+
+	t.Run("should match the yaml body as json", func(t *testing.T) {
+		t.Parallel()
+
+		httpResp, err := server.Client().Post("/users", "application/json", strings.NewReader(`{"name":"rick"}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, httpResp.StatusCode)
+	})
+}
+
+func TestServer_LoadStubsFromFile_Errors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	testCases := map[string]struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		"should fail when the body file does not exist": {
+			name:    "missing-body-file.json",
+			content: `{"stubs": [{"request": {"method": "GET", "path": "/"}, "response": {"bodyFile": "missing.json"}}]}`,
+			expectedError: filepath.Join(dir, "missing-body-file.json") + ": stub #0: read response body file failed: " +
+				"open " + filepath.Join(dir, "missing.json") + ": no such file or directory",
+		},
+		"should fail when there is more than one body": {
+			name:    "many-bodies.json",
+			content: `{"stubs": [{"request": {"method": "GET", "path": "/"}, "response": {"body": "a", "json": {}}}]}`,
+			expectedError: filepath.Join(dir, "many-bodies.json") +
+				": stub #0: response must have only one of body, bodyFile, json or template",
+		},
+		"should fail when the yaml is not valid": {
+			name:    "invalid.yml",
+			content: "stubs: [",
+			expectedError: filepath.Join(dir, "invalid.yml") +
+				": decode yaml failed: yaml: line 1: did not find expected node content",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(dir, tc.name)
+			writeFile(t, path, tc.content)
+
+			err := mockaso.NewServer().LoadStubsFromFile(path)
+			assert.EqualError(t, err, tc.expectedError)
+		})
+	}
+
+	t.Run("should fail when the file does not exist", func(t *testing.T) {
+		t.Parallel()
+
+		err := mockaso.NewServer().LoadStubsFromFile(filepath.Join(dir, "missing.yaml"))
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+}
+
+func TestServer_LoadStubsFromDir(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should load the stubs of all the stub files", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		writeFile(t, filepath.Join(dir, "users.json"),
+			`{"stubs": [{"request": {"method": "GET", "path": "/users"}, "response": {"body": "users"}}]}`)
+		writeFile(t, filepath.Join(dir, "orders.yml"),
+			"stubs: [{request: {method: GET, path: /orders}, response: {body: orders}}]")
+		writeFile(t, filepath.Join(dir, "README.md"), "not a stub file")
+		writeFile(t, filepath.Join(dir, "nested", "ignored.json"),
+			`{"stubs": [{"request": {"method": "GET", "path": "/ignored"}}]}`)
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		require.NoError(t, server.LoadStubsFromDir(dir))
+
+		for path, expectedBody := range map[string]string{"/users": "users", "/orders": "orders"} {
+			httpResp, err := server.Client().Get(path)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+			assertBodyString(t, expectedBody, httpResp)
+		}
+
+		httpResp, err := server.Client().Get("/ignored")
+		require.NoError(t, err)
+
+		assert.Equal(t, 666, httpResp.StatusCode)
+	})
+
+	t.Run("should not load any stub when a file fails", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		writeFile(t, filepath.Join(dir, "a.json"),
+			`{"stubs": [{"request": {"method": "GET", "path": "/users"}, "response": {"body": "users"}}]}`)
+		writeFile(t, filepath.Join(dir, "b.json"), `{"stubs": [{"request": {"path": "/orders"}}]}`)
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		err := server.LoadStubsFromDir(dir)
+		require.EqualError(t, err, filepath.Join(dir, "b.json")+": stub #0: request method is required")
+
+		httpResp, err := server.Client().Get("/users")
+		require.NoError(t, err)
+
+		assert.Equal(t, 666, httpResp.StatusCode)
+	})
+
+	t.Run("should fail when the dir does not exist", func(t *testing.T) {
+		t.Parallel()
+
+		err := mockaso.NewServer().LoadStubsFromDir(filepath.Join(t.TempDir(), "missing"))
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+}
+
+func TestServer_LoadStubsFromFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"testdata/stubs/users.json": {Data: []byte(
+			`{"stubs": [{"request": {"method": "GET", "path": "/users"}, "response": {"bodyFile": "users.txt"}}]}`)},
+		"testdata/stubs/users.txt": {Data: []byte("users")},
+		"testdata/stubs/orders.yaml": {Data: []byte(
+			"stubs: [{request: {method: GET, path: /orders}, response: {body: orders}}]")},
+		"testdata/stubs/nested/ignored.json": {Data: []byte(
+			`{"stubs": [{"request": {"method": "GET", "path": "/ignored"}}]}`)},
+		"testdata/invalid/a.json": {Data: []byte(
+			`{"stubs": [{"request": {"method": "GET", "path": "/users"}, "response": {"bodyFile": "missing.txt"}}]}`)},
+	}
+
+	t.Run("should load the stubs of all the stub files", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		require.NoError(t, server.LoadStubsFromFS(fsys, "testdata/stubs"))
+
+		for path, expectedBody := range map[string]string{"/users": "users", "/orders": "orders"} {
+			httpResp, err := server.Client().Get(path)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+			assertBodyString(t, expectedBody, httpResp)
+		}
+
+		httpResp, err := server.Client().Get("/ignored")
+		require.NoError(t, err)
+
+		assert.Equal(t, 666, httpResp.StatusCode)
+	})
+
+	t.Run("should fail when a body file does not exist", func(t *testing.T) {
+		t.Parallel()
+
+		err := mockaso.NewServer().LoadStubsFromFS(fsys, "testdata/invalid")
+		require.ErrorIs(t, err, fs.ErrNotExist)
+		assert.ErrorContains(t, err, "testdata/invalid/a.json: stub #0: read response body file failed")
+	})
+
+	t.Run("should fail when the dir does not exist", func(t *testing.T) {
+		t.Parallel()
+
+		err := mockaso.NewServer().LoadStubsFromFS(fsys, "testdata/missing")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestMigrateStubs(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should write the stubs with the current version", func(t *testing.T) {
+		t.Parallel()
+
+		var migrated bytes.Buffer
+
+		err := mockaso.MigrateStubs(strings.NewReader(`{"stubs": [{"request": {"method": "GET", "path": "/"}}]}`), &migrated)
+		require.NoError(t, err)
+
+		assert.JSONEq(t, `{
+			"version": 1,
+			"stubs": [{"request": {"method": "GET", "path": "/"}, "response": {}}]
+		}`, migrated.String())
+	})
+
+	t.Run("should fail when the version is not supported", func(t *testing.T) {
+		t.Parallel()
+
+		err := mockaso.MigrateStubs(strings.NewReader(`{"version": 0}`), new(bytes.Buffer))
+		assert.EqualError(t, err, "unsupported stub file version 0, the current version is 1")
+	})
+}
@@ -0,0 +1,88 @@
+package mockaso
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+)
+
+// TemplateData is the request data available in the templates of WithBodyTemplate.
+type TemplateData struct {
+	Method  string
+	Path    string
+	Params  map[string]string // path pattern params, see URLPattern
+	Query   map[string]string // first value of each query param
+	Headers map[string]string // first value of each header, by canonical name
+	Body    any               // request body decoded as JSON, nil if it is not valid JSON
+	RawBody string
+}
+
+// WithBodyTemplate sets the response body executing the text/template tmpl with the request data (see TemplateData).
+// funcs are added to the template functions, e.g. FakerFuncs. Headers with dashes are accessed with index.
+// The Content-Type header is not set, use WithHeader if needed.
+//
+// Example:
+//
+//	WithBodyTemplate(`{"id":"{{ .Params.user_id }}","q":"{{ .Query.page }}","name":"{{ .Body.name }}"}`)
+//	WithBodyTemplate(`{"request_id":"{{ index .Headers "X-Request-Id" }}"}`)
+func WithBodyTemplate(tmpl string, funcs ...template.FuncMap) StubResponseRule {
+	parsed := template.New("body")
+
+	for _, fm := range funcs {
+		parsed = parsed.Funcs(fm)
+	}
+
+	parsed, err := parsed.Parse(tmpl)
+	if err != nil {
+		panic(fmt.Errorf("WithBodyTemplate err: invalid template: %w", err))
+	}
+
+	bodyFunc := func(st *stub, r *http.Request) []byte {
+		var buff bytes.Buffer
+
+		if execErr := parsed.Execute(&buff, newTemplateData(st, r)); execErr != nil {
+			panic(fmt.Errorf("WithBodyTemplate err: execute template failed: %w", execErr))
+		}
+
+		return buff.Bytes()
+	}
+
+	return func(r *stubResponse) {
+		r.body = nil
+		r.bodyFunc = bodyFunc
+		r.stream = nil
+	}
+}
+
+func newTemplateData(st *stub, r *http.Request) TemplateData {
+	body := mustReadDecodedBody(r)
+
+	data := TemplateData{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Params:  ParamsFromRequest(r),
+		Query:   firstValues(r.URL.Query()),
+		Headers: firstValues(r.Header),
+		RawBody: string(body),
+	}
+
+	if err := json.Unmarshal(body, &data.Body); err != nil {
+		data.Body = nil
+	}
+
+	return data
+}
+
+func firstValues[M ~map[string][]string](values M) map[string]string {
+	first := make(map[string]string, len(values))
+
+	for key, v := range values {
+		if len(v) > 0 {
+			first[key] = v[0]
+		}
+	}
+
+	return first
+}
@@ -0,0 +1,64 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestWithBodyTemplate(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const tmpl = `{"id":"{{ .Params.user_id }}","q":"{{ .Query.page }}","name":"{{ .Body.name }}",` +
+		`"request_id":"{{ index .Headers "X-Request-Id" }}","method":"{{ .Method }}","path":"{{ .Path }}"}`
+
+	server.Stub(http.MethodPost, mockaso.PathPattern("/api/users/{user_id}")).
+		Respond(mockaso.WithBodyTemplate(tmpl), mockaso.WithHeader("Content-Type", "application/json"))
+
+	server.Stub(http.MethodPost, mockaso.Path("/api/echo")).
+		Respond(mockaso.WithBodyTemplate(`{{ .RawBody }}|{{ .Body }}`))
+
+	server.Stub(http.MethodGet, mockaso.Path("/api/fake")).
+		Respond(mockaso.WithBodyTemplate(`{{ fakeInt 1 1 }}`, mockaso.FakerFuncs(1)))
+
+	t.Run("should respond with the template executed with the request data", func(t *testing.T) {
+		httpReq, _ := http.NewRequest(http.MethodPost, "/api/users/10?page=2", strings.NewReader(`{"name":"john"}`))
+		httpReq.Header.Set("X-Request-Id", "abc")
+
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assert.Equal(t, "application/json", httpResp.Header.Get("Content-Type"))
+		assertBodyString(t, `{"id":"10","q":"2","name":"john","request_id":"abc","method":"POST","path":"/api/users/10"}`,
+			httpResp)
+	})
+
+	t.Run("should have a nil body when the request body is not JSON", func(t *testing.T) {
+		httpResp, err := server.Client().Post("/api/echo", "text/plain", strings.NewReader("hello"))
+		require.NoError(t, err)
+
+		assertBodyString(t, "hello|<no value>", httpResp)
+	})
+
+	t.Run("should use the given template funcs", func(t *testing.T) {
+		httpResp, err := server.Client().Get("/api/fake")
+		require.NoError(t, err)
+
+		assertBodyString(t, "1", httpResp)
+	})
+
+	t.Run("should panic when the template is not valid", func(t *testing.T) {
+		fn := func() { mockaso.WithBodyTemplate(`{{ .Params.user_id `) }
+		assert.PanicsWithError(t, "WithBodyTemplate err: invalid template: "+
+			`template: body:1: unclosed action`, fn)
+	})
+}
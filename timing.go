@@ -0,0 +1,80 @@
+package mockaso
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestTiming is the server-side timing of a request, useful to see where the time was spent inside the mock,
+// e.g. to tune the client timeouts.
+type RequestTiming struct {
+	Method    string
+	URL       string
+	Received  time.Time     // when the request was received
+	Match     time.Duration // time to find the matching stub, including reading the body
+	FirstByte time.Duration // time to write the response status since the request was received, 0 if not written
+	Total     time.Duration // time to write the whole response since the request was received
+}
+
+// Timings returns the timings of the requests received by the server, in the order they were received.
+// The timing of a request is available once its response was written.
+func (s *Server) Timings() []RequestTiming {
+	requests := s.journal.all()
+	timings := make([]RequestTiming, 0, len(requests))
+
+	for _, rr := range requests {
+		if rr.timing.Total > 0 {
+			timings = append(timings, rr.timing)
+		}
+	}
+
+	return timings
+}
+
+// timingWriter is a http.ResponseWriter that records when the response status is written, and the status and the
+// body size of the response.
+type timingWriter struct {
+	http.ResponseWriter
+	received   time.Time
+	firstByte  time.Duration
+	statusCode int
+	size       int64
+}
+
+func (w *timingWriter) WriteHeader(statusCode int) {
+	if w.firstByte == 0 && statusCode >= http.StatusOK { // informational responses are not the response
+		w.firstByte = time.Since(w.received)
+	}
+
+	if w.statusCode == 0 && statusCode >= http.StatusOK {
+		w.statusCode = statusCode
+	}
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *timingWriter) Write(data []byte) (int, error) {
+	if w.firstByte == 0 {
+		w.firstByte = time.Since(w.received)
+	}
+
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(data)
+	w.size += int64(n)
+
+	return n, err
+}
+
+func (w *timingWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to access the underlying writer, e.g. to hijack the connection.
+func (w *timingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,58 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestServer_Timings(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const (
+		path  = "/test/timings"
+		delay = 100 * time.Millisecond
+	)
+
+	server.Stub(http.MethodGet, mockaso.Path(path)).
+		Respond(mockaso.WithStatusCode(http.StatusOK), mockaso.WithDelay(delay))
+
+	before := time.Now()
+
+	for _, url := range []string{path, "/test/timings/not-found"} {
+		httpReq, _ := http.NewRequest(http.MethodGet, url, http.NoBody)
+		_, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+	}
+
+	timings := server.Timings()
+	require.Len(t, timings, 2)
+
+	t.Run("should record the timing of the matched request", func(t *testing.T) {
+		timing := timings[0]
+
+		assert.Equal(t, http.MethodGet, timing.Method)
+		assert.Equal(t, path, timing.URL)
+		assert.False(t, timing.Received.Before(before))
+		assert.Less(t, timing.Match, delay)
+		assert.GreaterOrEqual(t, timing.FirstByte, delay)
+		assert.GreaterOrEqual(t, timing.Total, timing.FirstByte)
+	})
+
+	t.Run("should record the timing of the unmatched request", func(t *testing.T) {
+		timing := timings[1]
+
+		assert.Equal(t, "/test/timings/not-found", timing.URL)
+		assert.Positive(t, timing.FirstByte)
+		assert.Less(t, timing.FirstByte, delay)
+		assert.GreaterOrEqual(t, timing.Total, timing.FirstByte)
+	})
+}
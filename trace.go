@@ -0,0 +1,88 @@
+package mockaso
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// WithMatchTracing enables the match tracing of unmatched requests: every stub is logged with the reason why it did
+// not match the request, e.g. "stub #3 GET /api/users: header X-Token mismatch". Named stubs are referred to by
+// their name instead of their position, see Stub.Name.
+// Note the matchers of every stub are evaluated again for unmatched requests.
+func WithMatchTracing() ServerOption {
+	return func(s *Server) {
+		s.matchTracing = true
+	}
+}
+
+// matchTrace holds why a matcher rejected a request, see describedMatcher.
+type matchTrace struct {
+	rejection string
+}
+
+// describedMatcher returns the matcher, which reports why it rejected a request when the match is traced.
+// The outermost described matcher reports the rejection, e.g. Not(MatchHeader(...)) is not described as a header
+// mismatch.
+func describedMatcher(matcher requestMatcherFunc, format string, args ...any) requestMatcherFunc {
+	return func(state *matchState, r *http.Request) bool {
+		if matcher(state, r) {
+			return true
+		}
+
+		if state != nil && state.trace != nil {
+			state.trace.rejection = fmt.Sprintf(format, args...)
+		}
+
+		return false
+	}
+}
+
+// logMatchTrace logs why every stub did not match the request.
+func (s *Server) logMatchTrace(r *http.Request) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var trace strings.Builder
+
+	fmt.Fprintf(&trace, "match trace for %s %s:", r.Method, r.URL.String())
+
+	for i, st := range s.stubs {
+		fmt.Fprintf(&trace, "\n\t%s: %s", st.title(i+1), st.rejection(r))
+	}
+
+	if len(s.stubs) == 0 {
+		trace.WriteString("\n\tno stubs")
+	}
+
+	s.logger.Logf("%s", trace.String())
+}
+
+// rejection returns why the stub did not match the request.
+func (s *stub) rejection(r *http.Request) string {
+	if s.expired() {
+		return "expired"
+	}
+
+	state := &matchState{trace: new(matchTrace)}
+
+	for i, match := range s.matchers {
+		state.trace.rejection = ""
+
+		if match(state, r) {
+			continue
+		}
+
+		if state.trace.rejection != "" {
+			return state.trace.rejection
+		}
+
+		return fmt.Sprintf("matcher #%d mismatch", i+1)
+	}
+
+	if s.maxUses > 0 && s.uses.Load() >= s.maxUses {
+		return "used up"
+	}
+
+	return "matched" // e.g. a more specific MuxPattern was preferred, or the stub was used up meanwhile
+}
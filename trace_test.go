@@ -0,0 +1,68 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestWithMatchTracing(t *testing.T) {
+	t.Parallel()
+
+	const path = "/api/users"
+
+	newServer := func(t *testing.T, opts ...mockaso.ServerOption) (*mockaso.Server, func() string) {
+		logger, buff := newTestLogLogger()
+
+		server := mockaso.MustStartNewServer(append(opts, mockaso.WithLogger(logger))...)
+		t.Cleanup(server.MustShutdown)
+
+		server.Stub(http.MethodGet, mockaso.Path(path)).Match(mockaso.MatchHeader("X-Token", "abc"))
+		server.Stub(http.MethodPost, mockaso.Path(path))
+		server.Stub(http.MethodGet, mockaso.PathPattern("/api/users/{id:int}"))
+		server.Stub(http.MethodGet, mockaso.Path(path)).Match(mockaso.MatchQuery("page", "2"))
+		server.Stub(http.MethodGet, mockaso.Path(path)).
+			Match(mockaso.MatchRequest(func(r *http.Request) bool { return r.ContentLength > 0 }))
+
+		return server, buff.String
+	}
+
+	send := func(t *testing.T, server *mockaso.Server) {
+		httpReq, _ := http.NewRequest(http.MethodGet, path, nil)
+		httpReq.Header.Set("X-Token", "xyz")
+
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assertNotMatchedResponse(t, httpReq, httpResp)
+	}
+
+	t.Run("should log why every stub did not match", func(t *testing.T) {
+		t.Parallel()
+
+		server, logs := newServer(t, mockaso.WithMatchTracing())
+		send(t, server)
+
+		expected := "match trace for GET /api/users:\n" +
+			"\tstub #1 GET /api/users: header X-Token mismatch\n" +
+			"\tstub #2 POST /api/users: method mismatch\n" +
+			"\tstub #3 GET /api/users/{id:int}: url mismatch\n" +
+			"\tstub #4 GET /api/users: query param page mismatch\n" +
+			"\tstub #5 GET /api/users: matcher #3 mismatch\n"
+
+		assert.Contains(t, logs(), expected)
+	})
+
+	t.Run("should not log the match trace when it is not enabled", func(t *testing.T) {
+		t.Parallel()
+
+		server, logs := newServer(t)
+		send(t, server)
+
+		assert.NotContains(t, logs(), "match trace")
+	})
+}
@@ -3,6 +3,7 @@ package mockaso
 import (
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 )
 
@@ -29,3 +30,125 @@ func newTransportWithBaseURL(baseTransport http.RoundTripper, baseURL string) ht
 		return baseTransport.RoundTrip(&copyRequest)
 	})
 }
+
+// MultiTransport returns an http.RoundTripper which routes the requests to the given servers by the request host,
+// so a single client can be injected in a service with several upstream dependencies.
+// Keys are hosts like "users.example.com" or, to route by port too, "users.example.com:8080".
+// The original Host header is preserved, and the original scheme is sent in the X-Forwarded-Proto header (see
+// MatchHost and MatchScheme). Requests to other hosts fail.
+//
+// Example:
+//
+//	client := &http.Client{Transport: mockaso.MultiTransport(map[string]*mockaso.Server{
+//		"users.example.com":  usersServer,
+//		"orders.example.com": ordersServer,
+//	})}
+func MultiTransport(servers map[string]*Server) http.RoundTripper {
+	return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		server, ok := servers[r.URL.Host]
+		if !ok {
+			server, ok = servers[r.URL.Hostname()]
+		}
+
+		if !ok || server.server == nil {
+			return nil, fmt.Errorf("no started server for host %s", r.URL.Host)
+		}
+
+		serverURL, err := url.Parse(server.URL())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse server URL: %w", err)
+		}
+
+		copyRequest := *r
+		copyURL := *r.URL
+		copyURL.Scheme, copyURL.Host = serverURL.Scheme, serverURL.Host
+		copyRequest.URL = &copyURL
+
+		if copyRequest.Host == "" {
+			copyRequest.Host = r.URL.Host
+		}
+
+		if r.Header.Get("X-Forwarded-Proto") == "" && r.URL.Scheme != "" {
+			copyRequest.Header = r.Header.Clone()
+			if copyRequest.Header == nil { // requests sent with RoundTrip may have no header
+				copyRequest.Header = make(http.Header)
+			}
+
+			copyRequest.Header.Set("X-Forwarded-Proto", r.URL.Scheme)
+		}
+
+		return server.server.Client().Transport.RoundTrip(&copyRequest)
+	})
+}
+
+// NewTransport returns an http.RoundTripper which serves the requests with the server stubs in-process,
+// without starting the server nor opening sockets. Useful for fast unit tests and massively parallel suites.
+// Responses are buffered until the stub response is complete, so streaming and hijacking are not supported.
+// A panic while serving the request is returned as an error, as a server would close the connection.
+//
+// Example:
+//
+//	server := mockaso.NewServer()
+//	server.Stub(http.MethodGet, mockaso.Path("/users/1")).Respond(mockaso.WithJSON(john))
+//
+//	client := &http.Client{Transport: mockaso.NewTransport(server)}
+//	resp, err := client.Get("http://users.example.com/users/1")
+func NewTransport(server *Server) http.RoundTripper {
+	handler := server.handler()
+
+	return roundTripFunc(func(r *http.Request) (resp *http.Response, err error) {
+		if r.Body != nil {
+			defer r.Body.Close()
+		}
+
+		serverRequest, err := newServerRequest(r)
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if v := recover(); v != nil {
+				resp, err = nil, fmt.Errorf("serve %s %s failed: %v", r.Method, r.URL.String(), v)
+			}
+		}()
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, serverRequest)
+
+		resp = recorder.Result()
+		resp.Request = r
+
+		return resp, nil
+	})
+}
+
+// newServerRequest returns the request as it is received by a server, e.g. with a relative URL.
+func newServerRequest(r *http.Request) (*http.Request, error) {
+	serverURL, err := url.ParseRequestURI(r.URL.RequestURI())
+	if err != nil {
+		return nil, fmt.Errorf("invalid request URI: %w", err)
+	}
+
+	serverRequest := r.Clone(r.Context())
+	serverRequest.URL = serverURL
+	serverRequest.RequestURI = r.URL.RequestURI()
+	serverRequest.RemoteAddr = "127.0.0.1:0"
+
+	if serverRequest.Header == nil { // requests sent with RoundTrip may have no header
+		serverRequest.Header = make(http.Header)
+	}
+
+	if serverRequest.Host == "" {
+		serverRequest.Host = r.URL.Host
+	}
+
+	if serverRequest.Header.Get("X-Forwarded-Proto") == "" && r.URL.Scheme != "" {
+		serverRequest.Header.Set("X-Forwarded-Proto", r.URL.Scheme)
+	}
+
+	if serverRequest.Body == nil {
+		serverRequest.Body = http.NoBody
+	}
+
+	return serverRequest, nil
+}
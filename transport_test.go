@@ -0,0 +1,220 @@
+package mockaso_test
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestMultiTransport(t *testing.T) {
+	t.Parallel()
+
+	usersServer := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(usersServer.MustShutdown)
+
+	ordersServer := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(ordersServer.MustShutdown)
+
+	usersServer.Stub(http.MethodGet, mockaso.Path("/users/1")).
+		Match(mockaso.MatchRequest(func(r *http.Request) bool { return r.Host == "users.example.com" })).
+		Respond(mockaso.WithBody("john"))
+
+	ordersServer.Stub(http.MethodGet, mockaso.Path("/orders")).
+		Match(mockaso.MatchQuery("user", "1")).
+		Respond(mockaso.WithBody("[]"))
+
+	client := &http.Client{Transport: mockaso.MultiTransport(map[string]*mockaso.Server{
+		"users.example.com":       usersServer,
+		"orders.example.com:8080": ordersServer,
+	})}
+
+	testCases := map[string]struct {
+		url          string
+		expectedBody string
+	}{
+		"should route the request by host and keep the host header": {
+			url:          "http://users.example.com/users/1",
+			expectedBody: "john",
+		},
+		"should route the request by host and port": {
+			url:          "https://orders.example.com:8080/orders?user=1",
+			expectedBody: "[]",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpResp, err := client.Get(tc.url)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+			assertBodyString(t, tc.expectedBody, httpResp)
+		})
+	}
+
+	t.Run("should fail when there is no server for the host", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := client.Get("http://payments.example.com/payments")
+		assert.ErrorContains(t, err, "no started server for host payments.example.com")
+	})
+
+	t.Run("should round trip a request without header", func(t *testing.T) {
+		t.Parallel()
+
+		reqURL, _ := url.Parse("http://users.example.com/users/1")
+
+		httpResp, err := client.Transport.RoundTrip(&http.Request{Method: http.MethodGet, URL: reqURL})
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assertBodyString(t, "john", httpResp)
+	})
+}
+
+func TestNewTransport(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.NewServer(mockaso.WithLogger(t))
+
+	server.Stub(http.MethodPost, mockaso.URL("/users?notify=true")).
+		Match(
+			mockaso.MatchRequest(func(r *http.Request) bool { return r.Host == "users.example.com" }),
+			mockaso.MatchRawJSONBody(`{"name":"john"}`),
+		).
+		Respond(mockaso.WithStatusCode(http.StatusCreated), mockaso.WithJSON(map[string]int{"id": 1}))
+
+	client := &http.Client{Transport: mockaso.NewTransport(server)}
+
+	t.Run("should respond with the stubs without starting the server", func(t *testing.T) {
+		httpResp, err := client.Post("http://users.example.com/users?notify=true", "application/json",
+			strings.NewReader(`{"name":"john"}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, httpResp.StatusCode)
+		assert.Equal(t, "application/json", httpResp.Header.Get("Content-Type"))
+		assertBodyString(t, `{"id":1}`, httpResp)
+		assert.Empty(t, server.URL())
+	})
+
+	t.Run("should respond no match when no stub matches", func(t *testing.T) {
+		httpReq, _ := http.NewRequest(http.MethodGet, "http://users.example.com/orders", http.NoBody)
+
+		httpResp, err := client.Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, 666, httpResp.StatusCode)
+		assertBodyString(t, "no stubs for GET /orders", httpResp)
+	})
+
+	t.Run("should record the requests", func(t *testing.T) {
+		requests := server.RequestsMatching(func(r *http.Request) bool { return r.Method == http.MethodPost })
+
+		require.Len(t, requests, 1)
+		assert.Equal(t, "/users?notify=true", requests[0].URL.String())
+		assert.NotNil(t, requests[0].Stub)
+	})
+
+	t.Run("should close the request body", func(t *testing.T) {
+		body := &closeTrackingReader{Reader: strings.NewReader(`{"name":"john"}`)}
+
+		httpResp, err := client.Post("http://users.example.com/users?notify=true", "application/json", body)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, httpResp.StatusCode)
+		assert.True(t, body.closed)
+	})
+
+	t.Run("should return an error when serving panics", func(t *testing.T) {
+		server.Stub(http.MethodGet, mockaso.Path("/panic")).
+			Respond(mockaso.WithBodyReaderFunc(func() io.Reader { panic("boom") }))
+
+		_, err := client.Get("http://users.example.com/panic")
+		assert.ErrorContains(t, err, "serve GET http://users.example.com/panic failed: boom")
+	})
+
+	t.Run("should round trip a request without header", func(t *testing.T) {
+		reqURL, _ := url.Parse("https://users.example.com/orders")
+
+		httpResp, err := client.Transport.RoundTrip(&http.Request{Method: http.MethodGet, URL: reqURL})
+		require.NoError(t, err)
+
+		assert.Equal(t, 666, httpResp.StatusCode)
+		assertBodyString(t, "no stubs for GET /orders", httpResp)
+	})
+}
+
+func TestMultiTransport_VirtualHosts(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodGet, mockaso.Path("/status")).
+		Match(mockaso.MatchHost("users.example.com"), mockaso.MatchScheme("https")).
+		Respond(mockaso.WithBody("users"))
+
+	server.Stub(http.MethodGet, mockaso.Path("/status")).
+		Match(mockaso.MatchHost("orders.example.com")).
+		Respond(mockaso.WithBody("orders"))
+
+	client := &http.Client{Transport: mockaso.MultiTransport(map[string]*mockaso.Server{
+		"users.example.com":  server,
+		"orders.example.com": server,
+	})}
+
+	testCases := map[string]struct {
+		url            string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"should route the stubs by host and scheme": {
+			url:            "https://users.example.com/status",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "users",
+		},
+		"should route the stubs by host": {
+			url:            "http://orders.example.com/status",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "orders",
+		},
+		"should not match another scheme": {
+			url:            "http://users.example.com/status",
+			expectedStatus: 666,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpResp, err := client.Get(tc.url)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedStatus, httpResp.StatusCode)
+
+			if tc.expectedBody != "" {
+				assertBodyString(t, tc.expectedBody, httpResp)
+			}
+		})
+	}
+}
+
+type closeTrackingReader struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closeTrackingReader) Close() error {
+	r.closed = true
+	return nil
+}
@@ -0,0 +1,63 @@
+package mockaso
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// MatchConnectionUpgrade sets a rule to match the http request asking for a protocol upgrade,
+// i.e. with the Connection: Upgrade header and an Upgrade header.
+func MatchConnectionUpgrade() StubMatcherRule {
+	return describedRule(isConnectionUpgrade, "connection upgrade missing")
+}
+
+// MatchUpgrade sets a rule to match the http request asking to upgrade to the given protocol (case-insensitive).
+// For "websocket" the handshake is also validated: GET method, Sec-WebSocket-Version: 13 and a Sec-WebSocket-Key
+// of 16 bytes encoded as base64 (RFC 6455).
+//
+// Example:
+//
+//	MatchUpgrade("websocket")
+func MatchUpgrade(protocol string) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		if !isConnectionUpgrade(r) || !headerHasToken(r, "Upgrade", protocol) {
+			return false
+		}
+
+		if strings.EqualFold(protocol, "websocket") {
+			return isWebSocketHandshake(r)
+		}
+
+		return true
+	})
+
+	return describedRule(matcher, "upgrade %s missing", protocol)
+}
+
+func isConnectionUpgrade(r *http.Request) bool {
+	return headerHasToken(r, "Connection", "upgrade") && r.Header.Get("Upgrade") != ""
+}
+
+func isWebSocketHandshake(r *http.Request) bool {
+	key, err := base64.StdEncoding.DecodeString(r.Header.Get("Sec-WebSocket-Key"))
+
+	return r.Method == http.MethodGet &&
+		r.Header.Get("Sec-WebSocket-Version") == "13" &&
+		err == nil && len(key) == 16
+}
+
+// headerHasToken reports whether the comma-separated values of the header include the token (case-insensitive).
+// Protocol versions are ignored, e.g. the token "h2c" is included in "h2c/1.0".
+func headerHasToken(r *http.Request, key, token string) bool {
+	for _, value := range r.Header.Values(key) {
+		for _, candidate := range strings.Split(value, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(candidate), "/")
+			if strings.EqualFold(name, token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,120 @@
+package mockaso_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestMatchConnectionUpgrade(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		headers       map[string]string
+		expectedMatch bool
+	}{
+		"should return true when connection is upgraded": {
+			headers:       map[string]string{"Connection": "keep-alive, Upgrade", "Upgrade": "h2c"},
+			expectedMatch: true,
+		},
+		"should return false when upgrade header is missing": {
+			headers:       map[string]string{"Connection": "Upgrade"},
+			expectedMatch: false,
+		},
+		"should return false when connection header is not upgrade": {
+			headers:       map[string]string{"Connection": "keep-alive", "Upgrade": "h2c"},
+			expectedMatch: false,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(http.MethodGet, "/upgrade", http.NoBody)
+			for k, v := range tc.headers {
+				httpReq.Header.Set(k, v)
+			}
+
+			matcher := mockaso.MatchConnectionUpgrade()()
+			assert.Equal(t, tc.expectedMatch, matcher(nil, httpReq))
+		})
+	}
+}
+
+func TestMatchUpgrade(t *testing.T) {
+	t.Parallel()
+
+	webSocketHeaders := func(overrides map[string]string) map[string]string {
+		headers := map[string]string{
+			"Connection":            "Upgrade",
+			"Upgrade":               "websocket",
+			"Sec-WebSocket-Key":     "dGhlIHNhbXBsZSBub25jZQ==",
+			"Sec-WebSocket-Version": "13",
+		}
+
+		for k, v := range overrides {
+			headers[k] = v
+		}
+
+		return headers
+	}
+
+	testCases := map[string]struct {
+		method        string
+		protocol      string
+		headers       map[string]string
+		expectedMatch bool
+	}{
+		"should return true when websocket handshake is valid": {
+			method:        http.MethodGet,
+			protocol:      "websocket",
+			headers:       webSocketHeaders(nil),
+			expectedMatch: true,
+		},
+		"should return false when websocket key is not 16 bytes": {
+			method:   http.MethodGet,
+			protocol: "websocket",
+			headers:  webSocketHeaders(map[string]string{"Sec-WebSocket-Key": "c2hvcnQ="}),
+		},
+		"should return false when websocket version is not 13": {
+			method:   http.MethodGet,
+			protocol: "websocket",
+			headers:  webSocketHeaders(map[string]string{"Sec-WebSocket-Version": "8"}),
+		},
+		"should return false when websocket method is not GET": {
+			method:   http.MethodPost,
+			protocol: "websocket",
+			headers:  webSocketHeaders(nil),
+		},
+		"should return false when upgrade protocol is another": {
+			method:   http.MethodGet,
+			protocol: "h2c",
+			headers:  webSocketHeaders(nil),
+		},
+		"should return true when upgrade protocol has version": {
+			method:        http.MethodGet,
+			protocol:      "h2c",
+			headers:       map[string]string{"Connection": "Upgrade, HTTP2-Settings", "Upgrade": "H2C/1.0"},
+			expectedMatch: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(tc.method, "/upgrade", http.NoBody)
+			for k, v := range tc.headers {
+				httpReq.Header.Set(k, v)
+			}
+
+			matcher := mockaso.MatchUpgrade(tc.protocol)()
+			assert.Equal(t, tc.expectedMatch, matcher(nil, httpReq))
+		})
+	}
+}
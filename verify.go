@@ -0,0 +1,194 @@
+package mockaso
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// TestingT is the interface used to report assertion failures. Intended for use with testing.T.
+type TestingT interface {
+	Errorf(format string, args ...any)
+}
+
+type tHelper interface {
+	Helper()
+}
+
+// AssertNoDuplicateRequests asserts that the server did not receive the same request more than once.
+// Requests are identified by the key returned by keyFunc. If keyFunc is nil, the method, URL and body are used.
+// Useful to catch accidental double submits of the client under test.
+func (s *Server) AssertNoDuplicateRequests(t TestingT, keyFunc func(*http.Request) string) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	if keyFunc == nil {
+		keyFunc = defaultRequestKey
+	}
+
+	counts := make(map[string]int)
+	for _, rr := range s.journal.all() {
+		counts[keyFunc(rr.httpRequest())]++
+	}
+
+	var duplicates []string
+
+	for key, count := range counts {
+		if count > 1 {
+			duplicates = append(duplicates, fmt.Sprintf("%s (%d times)", key, count))
+		}
+	}
+
+	if len(duplicates) == 0 {
+		return true
+	}
+
+	sort.Strings(duplicates)
+	t.Errorf("duplicate requests received:\n\t%s", strings.Join(duplicates, "\n\t"))
+
+	return false
+}
+
+// AssertNoUnmatched asserts that every request received by the server matched a stub.
+func (s *Server) AssertNoUnmatched(t TestingT) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	var unmatched []string
+
+	for _, rr := range s.journal.all() {
+		if rr.stub == nil {
+			unmatched = append(unmatched, rr.request.Method+" "+rr.request.URL.String())
+		}
+	}
+
+	if len(unmatched) == 0 {
+		return true
+	}
+
+	t.Errorf("requests did not match any stub:\n\t%s", strings.Join(unmatched, "\n\t"))
+
+	return false
+}
+
+// AssertAllStubsMatched asserts that every stub registered in the server matched at least one request.
+// Stubs which never matched usually mean the test does not exercise what it is expected to. The stubs removed by
+// Clear are not checked. See WithAssertAllStubsMatched to check it on Shutdown.
+func (s *Server) AssertAllStubsMatched(t TestingT) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var unused []string
+
+	for i, st := range s.stubs {
+		if st.calls.Load() == 0 {
+			unused = append(unused, st.title(i+1))
+		}
+	}
+
+	if len(unused) == 0 {
+		return true
+	}
+
+	t.Errorf("stubs did not match any request:\n\t%s", strings.Join(unused, "\n\t"))
+
+	return false
+}
+
+// WithAssertAllStubsMatched sets the server to assert that every stub matched at least one request on Shutdown.
+// See Server.AssertAllStubsMatched.
+func WithAssertAllStubsMatched(t TestingT) ServerOption {
+	return func(s *Server) {
+		s.unusedStubsT = t
+	}
+}
+
+// AssertNoLateRequests asserts that the server did not receive requests after the test ended, i.e. while it was
+// shutting down, or after Clear matching a cleared stub. Late requests usually come from goroutines of the client
+// under test which keep calling the upstream. Call it after Shutdown or Clear. Only the last 100 cleared stubs are
+// kept to detect late requests.
+func (s *Server) AssertNoLateRequests(t TestingT) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	var late []string
+
+	for _, rr := range s.journal.all() {
+		if rr.late != "" {
+			late = append(late, rr.request.Method+" "+rr.request.URL.String()+" ("+rr.late+")")
+		}
+	}
+
+	if len(late) == 0 {
+		return true
+	}
+
+	t.Errorf("requests received late:\n\t%s", strings.Join(late, "\n\t"))
+
+	return false
+}
+
+// Calls returns the number of requests matched by the stub.
+func (s *stub) Calls() int {
+	return int(s.calls.Load())
+}
+
+// AssertCalled asserts that the stub matched at least one request.
+func (s *stub) AssertCalled(t TestingT) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	if s.calls.Load() == 0 {
+		t.Errorf("%s was not called", s.label(0))
+		return false
+	}
+
+	return true
+}
+
+// AssertCalledTimes asserts that the stub matched exactly n requests.
+func (s *stub) AssertCalledTimes(t TestingT, n int) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	if calls := s.calls.Load(); calls != int64(n) {
+		t.Errorf("%s was called %d times, expected %d times", s.label(0), calls, n)
+		return false
+	}
+
+	return true
+}
+
+// AssertNotCalled asserts that the stub did not match any request.
+func (s *stub) AssertNotCalled(t TestingT) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	if calls := s.calls.Load(); calls != 0 {
+		t.Errorf("%s was called %d times, expected not to be called", s.label(0), calls)
+		return false
+	}
+
+	return true
+}
+
+func defaultRequestKey(r *http.Request) string {
+	key := r.Method + " " + r.URL.String()
+
+	if body := mustReadBody(r); len(body) > 0 {
+		key += " " + string(body)
+	}
+
+	return key
+}
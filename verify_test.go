@@ -0,0 +1,349 @@
+package mockaso_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+// fakeT records the assertion failures instead of failing the test.
+type fakeT struct {
+	errors []string
+}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestServer_AssertNoDuplicateRequests(t *testing.T) {
+	t.Parallel()
+
+	send := func(t *testing.T, server *mockaso.Server, method, url, body string) {
+		httpReq, _ := http.NewRequest(method, url, strings.NewReader(body))
+		_, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+	}
+
+	t.Run("should pass when the requests are different", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		send(t, server, http.MethodPost, "/orders", `{"id":1}`)
+		send(t, server, http.MethodPost, "/orders", `{"id":2}`)
+		send(t, server, http.MethodGet, "/orders", "")
+
+		fake := new(fakeT)
+		assert.True(t, server.AssertNoDuplicateRequests(fake, nil))
+		assert.Empty(t, fake.errors)
+	})
+
+	t.Run("should fail when the same request was received more than once", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		send(t, server, http.MethodPost, "/orders", `{"id":1}`)
+		send(t, server, http.MethodPost, "/orders", `{"id":1}`)
+
+		fake := new(fakeT)
+		assert.False(t, server.AssertNoDuplicateRequests(fake, nil))
+		require.Len(t, fake.errors, 1)
+		assert.Contains(t, fake.errors[0], `POST /orders {"id":1} (2 times)`)
+	})
+
+	t.Run("should identify requests with the given key func", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		idempotencyKey := func(r *http.Request) string { return r.Header.Get("Idempotency-Key") }
+
+		for _, body := range []string{"1", "2", "3"} {
+			httpReq, _ := http.NewRequest(http.MethodPost, "/payments", strings.NewReader(body))
+			httpReq.Header.Set("Idempotency-Key", "key-a")
+			_, err := server.Client().Do(httpReq)
+			require.NoError(t, err)
+		}
+
+		fake := new(fakeT)
+		assert.False(t, server.AssertNoDuplicateRequests(fake, idempotencyKey))
+		require.Len(t, fake.errors, 1)
+		assert.Contains(t, fake.errors[0], "key-a (3 times)")
+	})
+
+	t.Run("should not consume the body read by the stub matchers", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		server.Stub(http.MethodPost, mockaso.Path("/orders")).
+			Match(mockaso.MatchRawJSONBody(`{"id":1}`)).
+			Respond(mockaso.WithStatusCode(http.StatusCreated))
+
+		httpReq, _ := http.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"id":1}`))
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, httpResp.StatusCode)
+		assert.True(t, server.AssertNoDuplicateRequests(t, nil))
+	})
+}
+
+func TestServer_AssertNoUnmatched(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	server.Stub(http.MethodGet, mockaso.Path("/users")).Respond(mockaso.WithStatusCode(http.StatusOK))
+
+	_, err := server.Client().Get("/users")
+	require.NoError(t, err)
+
+	fake := new(fakeT)
+	assert.True(t, server.AssertNoUnmatched(fake))
+	assert.Empty(t, fake.errors)
+
+	_, err = server.Client().Get("/orders?page=1")
+	require.NoError(t, err)
+
+	assert.False(t, server.AssertNoUnmatched(fake))
+	assert.Equal(t, []string{"requests did not match any stub:\n\tGET /orders?page=1"}, fake.errors)
+}
+
+func TestServer_AssertNoLateRequests(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail when a cleared stub is requested", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		server.Stub(http.MethodGet, mockaso.Path("/users")).Respond(mockaso.WithStatusCode(http.StatusOK))
+
+		_, err := server.Client().Get("/users")
+		require.NoError(t, err)
+
+		server.Clear()
+
+		_, err = server.Client().Get("/orders")
+		require.NoError(t, err)
+
+		fake := new(fakeT)
+		assert.True(t, server.AssertNoLateRequests(fake))
+		assert.Empty(t, fake.errors)
+
+		_, err = server.Client().Get("/users?page=2")
+		require.NoError(t, err)
+
+		assert.False(t, server.AssertNoLateRequests(fake))
+		assert.Equal(t, []string{"requests received late:\n\tGET /users?page=2 (after clear)"}, fake.errors)
+	})
+
+	t.Run("should fail when a scope stub is requested after the test finished", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		var client *http.Client
+
+		t.Run("scope", func(t *testing.T) {
+			scope := server.Scope(t)
+			scope.Stub(http.MethodGet, mockaso.Path("/users")).Respond(mockaso.WithStatusCode(http.StatusOK))
+			client = scope.Client()
+		})
+
+		_, err := client.Get("/users")
+		require.NoError(t, err)
+
+		fake := new(fakeT)
+		assert.False(t, server.AssertNoLateRequests(fake))
+		assert.Equal(t, []string{"requests received late:\n\tGET /users (after clear)"}, fake.errors)
+	})
+
+	t.Run("should only keep the last cleared stubs", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		server.Stub(http.MethodGet, mockaso.Path("/users"))
+		server.Clear()
+
+		for i := range 100 {
+			server.Stub(http.MethodGet, mockaso.Path(fmt.Sprintf("/orders/%d", i)))
+		}
+
+		server.Clear()
+
+		_, err := server.Client().Get("/users")
+		require.NoError(t, err)
+
+		_, err = server.Client().Get("/orders/99")
+		require.NoError(t, err)
+
+		fake := new(fakeT)
+		assert.False(t, server.AssertNoLateRequests(fake))
+		assert.Equal(t, []string{"requests received late:\n\tGET /orders/99 (after clear)"}, fake.errors)
+	})
+}
+
+func TestStub_AssertCalled(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	st := server.Stub(http.MethodGet, mockaso.Path("/users"))
+	st.Respond(mockaso.WithStatusCode(http.StatusOK))
+
+	fake := new(fakeT)
+	assert.False(t, st.AssertCalled(fake))
+	assert.Equal(t, []string{"stub was not called"}, fake.errors)
+
+	_, err := server.Client().Get("/users")
+	require.NoError(t, err)
+
+	fake = new(fakeT)
+	assert.True(t, st.AssertCalled(fake))
+	assert.Empty(t, fake.errors)
+}
+
+func TestStub_AssertCalledTimes(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	st := server.Stub(http.MethodGet, mockaso.Path("/users"))
+	st.Respond(mockaso.WithStatusCode(http.StatusOK))
+
+	for range 2 {
+		_, err := server.Client().Get("/users")
+		require.NoError(t, err)
+	}
+
+	fake := new(fakeT)
+	assert.True(t, st.AssertCalledTimes(fake, 2))
+	assert.Empty(t, fake.errors)
+
+	assert.False(t, st.AssertCalledTimes(fake, 3))
+	assert.Equal(t, []string{"stub was called 2 times, expected 3 times"}, fake.errors)
+}
+
+func TestStub_AssertNotCalled(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	st := server.Stub(http.MethodGet, mockaso.Path("/users"))
+	st.Respond(mockaso.WithStatusCode(http.StatusOK))
+
+	fake := new(fakeT)
+	assert.True(t, st.AssertNotCalled(fake))
+	assert.Empty(t, fake.errors)
+
+	_, err := server.Client().Get("/users")
+	require.NoError(t, err)
+
+	assert.False(t, st.AssertNotCalled(fake))
+	assert.Equal(t, []string{"stub was called 1 times, expected not to be called"}, fake.errors)
+}
+
+func TestStub_Calls(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	st := server.Stub(http.MethodGet, mockaso.Path("/users")).Match(mockaso.MatchQuery("page", "1"))
+	st.Respond(mockaso.WithStatusCode(http.StatusOK))
+
+	assert.Zero(t, st.Calls())
+
+	for range 3 {
+		_, err := server.Client().Get("/users?page=1")
+		require.NoError(t, err)
+	}
+
+	_, err := server.Client().Get("/users?page=2")
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, st.Calls())
+}
+
+func TestServer_AssertAllStubsMatched(t *testing.T) {
+	t.Parallel()
+
+	newServer := func(t *testing.T, opts ...mockaso.ServerOption) *mockaso.Server {
+		server := mockaso.MustStartNewServer(append(opts, mockaso.WithLogger(t))...)
+
+		server.Stub(http.MethodGet, mockaso.Path("/users")).Respond(mockaso.WithStatusCode(http.StatusOK))
+		server.Stub(http.MethodPost, mockaso.Path("/users")).Name("create-user").
+			Respond(mockaso.WithStatusCode(http.StatusCreated))
+		server.Stub(http.MethodDelete, mockaso.PathPattern("/users/{id}")).
+			Respond(mockaso.WithStatusCode(http.StatusNoContent))
+
+		_, err := server.Client().Get("/users")
+		require.NoError(t, err)
+
+		return server
+	}
+
+	t.Run("should fail listing the stubs that did not match any request", func(t *testing.T) {
+		t.Parallel()
+
+		server := newServer(t)
+		t.Cleanup(server.MustShutdown)
+
+		fake := new(fakeT)
+		assert.False(t, server.AssertAllStubsMatched(fake))
+		assert.Equal(t, []string{"stubs did not match any request:\n" +
+			"\tstub \"create-user\" POST /users\n" +
+			"\tstub #3 DELETE /users/{id}"}, fake.errors)
+	})
+
+	t.Run("should pass when every stub matched", func(t *testing.T) {
+		t.Parallel()
+
+		server := newServer(t)
+		t.Cleanup(server.MustShutdown)
+
+		_, err := server.Client().Post("/users", "application/json", nil)
+		require.NoError(t, err)
+
+		httpReq, _ := http.NewRequest(http.MethodDelete, "/users/1", nil)
+		_, err = server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		fake := new(fakeT)
+		assert.True(t, server.AssertAllStubsMatched(fake))
+		assert.Empty(t, fake.errors)
+	})
+
+	t.Run("should assert on shutdown", func(t *testing.T) {
+		t.Parallel()
+
+		fake := new(fakeT)
+
+		server := newServer(t, mockaso.WithAssertAllStubsMatched(fake))
+		assert.Empty(t, fake.errors)
+
+		server.MustShutdown()
+		assert.Len(t, fake.errors, 1)
+	})
+}
@@ -0,0 +1,346 @@
+package mockaso
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"time"
+)
+
+// WebSocketStub is a stub which accepts WebSocket connections and runs a script on them, see Server.StubWebSocket.
+type WebSocketStub interface {
+	Match(...StubMatcherRule) WebSocketStub
+	Script(...WebSocketStep) WebSocketStub
+	Name(string) WebSocketStub
+	Calls() int
+	AssertCalled(TestingT) bool
+	AssertCalledTimes(TestingT, int) bool
+	AssertNotCalled(TestingT) bool
+}
+
+// WebSocketStep is a step of the script run on the connections of a WebSocketStub.
+type WebSocketStep func(*webSocketConn) error
+
+type webSocketStub struct {
+	*stub
+	logger Logger
+}
+
+// StubWebSocket adds a stub which accepts the WebSocket handshakes (RFC 6455) of the given URL and then runs its
+// script, see WebSocketStub.Script. The handshake is validated as in MatchUpgrade("websocket").
+//
+// Example:
+//
+//	server.StubWebSocket(Path("/ws")).Script(
+//		ExpectMessage(`{"subscribe":"prices"}`),
+//		SendMessage(`{"price":10}`),
+//		CloseWebSocket(1000),
+//	)
+func (s *Server) StubWebSocket(url URLMatcher) WebSocketStub {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ws := &webSocketStub{
+		stub:   s.newStub(http.MethodGet, url, MatchUpgrade("websocket")()),
+		logger: s.logger,
+	}
+
+	ws.Script()
+	s.appendStubs(ws.stub)
+
+	return ws
+}
+
+// Match sets additional rules to match the handshake request.
+func (s *webSocketStub) Match(rules ...StubMatcherRule) WebSocketStub {
+	s.stub.Match(rules...)
+	return s
+}
+
+// Script sets the steps run on every accepted connection, in order. If a step fails, e.g. an unexpected message is
+// received, the failure is logged and the connection is closed with 1008 (policy violation). When the script ends,
+// the connection is closed with 1000 (normal closure), unless it was already closed. Ping frames are answered while
+// waiting for messages.
+func (s *webSocketStub) Script(steps ...WebSocketStep) WebSocketStub {
+	s.stub.Respond(func(r *stubResponse) {
+		r.webSocket = &webSocketScript{steps: steps, logger: s.logger}
+	})
+
+	return s
+}
+
+// Name sets a human-readable name of the stub, see Stub.Name.
+func (s *webSocketStub) Name(name string) WebSocketStub {
+	s.stub.Name(name)
+	return s
+}
+
+// ExpectMessage sets a step which waits for the next message (text or binary) and fails if it is not the expected.
+func ExpectMessage(expected string) WebSocketStep {
+	return func(c *webSocketConn) error {
+		message, err := c.readMessage()
+		if err != nil {
+			return err
+		}
+
+		if string(message) != expected {
+			return fmt.Errorf("expected message %q, got %q", expected, message)
+		}
+
+		return nil
+	}
+}
+
+// ExpectMessageFunc sets a step which waits for the next message (text or binary) and fails if fn reports false.
+func ExpectMessageFunc(fn func(message []byte) bool) WebSocketStep {
+	return func(c *webSocketConn) error {
+		message, err := c.readMessage()
+		if err != nil {
+			return err
+		}
+
+		if !fn(message) {
+			return fmt.Errorf("unexpected message %q", message)
+		}
+
+		return nil
+	}
+}
+
+// SendMessage sets a step which sends the given text message.
+func SendMessage(text string) WebSocketStep {
+	return func(c *webSocketConn) error {
+		return c.writeFrame(wsOpText, []byte(text))
+	}
+}
+
+// SendBinaryMessage sets a step which sends the given binary message.
+func SendBinaryMessage(data []byte) WebSocketStep {
+	return func(c *webSocketConn) error {
+		return c.writeFrame(wsOpBinary, data)
+	}
+}
+
+// Pause sets a step which waits the given duration, measured with the server clock (see WithClock),
+// e.g. to push updates periodically.
+func Pause(d time.Duration) WebSocketStep {
+	return func(c *webSocketConn) error {
+		<-c.stub.clock.After(d)
+		return nil
+	}
+}
+
+// CloseWebSocket sets a step which closes the connection with the given status code, e.g. 1001 (going away).
+// The steps after it are not run.
+func CloseWebSocket(code int) WebSocketStep {
+	return func(c *webSocketConn) error {
+		c.close(code, "")
+		return errWebSocketClosed
+	}
+}
+
+// webSocketScript accepts the WebSocket handshake and runs the steps on the connection.
+type webSocketScript struct {
+	steps  []WebSocketStep
+	logger Logger
+}
+
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xa
+
+	wsCloseNormal          = 1000
+	wsClosePolicyViolation = 1008
+
+	wsAcceptGUID       = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsMaxPayload       = 16 << 20 // larger frames are rejected
+	wsMaxCloseReason   = 123
+	wsCloseWaitTimeout = time.Second
+)
+
+var errWebSocketClosed = errors.New("connection closed")
+
+func (ws *webSocketScript) serve(st *stub, w http.ResponseWriter, r *http.Request) {
+	hijackConn(w, func(conn net.Conn, rw *bufio.ReadWriter) {
+		c := &webSocketConn{conn: conn, rw: rw, stub: st}
+
+		if err := c.accept(r); err != nil {
+			ws.logger.Logf("%s websocket handshake failed: %v", st.title(0), err)
+			return
+		}
+
+		for i, step := range ws.steps {
+			err := step(c)
+
+			switch {
+			case err == nil:
+				continue
+			case c.closedByClient:
+				ws.logger.Logf("%s websocket closed by the client at step #%d", st.title(0), i+1)
+			case errors.Is(err, errWebSocketClosed):
+			default:
+				ws.logger.Logf("%s websocket script failed at step #%d: %v", st.title(0), i+1, err)
+				c.close(wsClosePolicyViolation, err.Error())
+			}
+
+			return
+		}
+
+		c.close(wsCloseNormal, "")
+	})
+}
+
+// webSocketConn is a server side WebSocket connection.
+type webSocketConn struct {
+	conn           net.Conn
+	rw             *bufio.ReadWriter
+	stub           *stub
+	closedByClient bool
+}
+
+// accept writes the handshake response of the request.
+func (c *webSocketConn) accept(r *http.Request) error {
+	hash := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + wsAcceptGUID))
+
+	_, _ = c.rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	_, _ = c.rw.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(hash[:]) + "\r\n\r\n")
+
+	return c.rw.Flush()
+}
+
+// readMessage returns the next data message, joining its fragments and answering the control frames received.
+func (c *webSocketConn) readMessage() ([]byte, error) {
+	var message []byte
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err = c.writeFrame(wsOpPong, payload); err != nil {
+				return nil, err
+			}
+		case wsOpPong:
+		case wsOpClose:
+			c.closedByClient = true
+			_ = c.writeFrame(wsOpClose, payload[:min(2, len(payload))]) // echoes the status code
+
+			return nil, errWebSocketClosed
+		case wsOpText, wsOpBinary, wsOpContinuation:
+			message = append(message, payload...)
+			if fin {
+				return message, nil
+			}
+		default:
+			return nil, fmt.Errorf("unsupported opcode %#x", opcode)
+		}
+	}
+}
+
+// readFrame reads a client frame, which must be masked.
+func (c *webSocketConn) readFrame() (bool, byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.rw, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin, opcode, masked := head[0]&0x80 != 0, head[0]&0x0f, head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7f)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if !masked {
+		return false, 0, nil, errors.New("client frame is not masked")
+	}
+
+	if length > wsMaxPayload {
+		return false, 0, nil, fmt.Errorf("frame of %d bytes exceeds the limit", length)
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.rw, payload); err != nil {
+		return false, 0, nil, err
+	}
+
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// writeFrame writes an unfragmented and unmasked server frame.
+func (c *webSocketConn) writeFrame(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= math.MaxUint16:
+		header = binary.BigEndian.AppendUint16(append(header, 126), uint16(n))
+	default:
+		header = binary.BigEndian.AppendUint64(append(header, 127), uint64(n))
+	}
+
+	_, _ = c.rw.Write(header)
+	_, _ = c.rw.Write(payload)
+
+	return c.rw.Flush()
+}
+
+// close sends a close frame and waits for the client to close too, unless it already did.
+func (c *webSocketConn) close(code int, reason string) {
+	if c.closedByClient {
+		return
+	}
+
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason[:min(len(reason), wsMaxCloseReason)]...)
+
+	if err := c.writeFrame(wsOpClose, payload); err != nil {
+		return
+	}
+
+	_ = c.conn.SetReadDeadline(time.Now().Add(wsCloseWaitTimeout))
+
+	for {
+		_, opcode, _, err := c.readFrame()
+		if err != nil || opcode == wsOpClose {
+			return
+		}
+	}
+}
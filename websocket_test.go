@@ -0,0 +1,198 @@
+package mockaso_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestServer_StubWebSocket(t *testing.T) {
+	t.Parallel()
+
+	t.Run("scripted exchange", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		ws := server.StubWebSocket(mockaso.Path("/ws")).Script(
+			mockaso.ExpectMessage(`{"subscribe":"prices"}`),
+			mockaso.SendMessage(`{"price":10}`),
+			mockaso.SendBinaryMessage([]byte{1, 2, 3}),
+			mockaso.CloseWebSocket(1001),
+		)
+
+		conn, reader := dialWebSocket(t, server, "/ws")
+
+		writeWebSocketFrame(t, conn, 0x1, []byte(`{"subscribe":"prices"}`))
+
+		opcode, payload := readWebSocketFrame(t, reader)
+		assert.Equal(t, byte(0x1), opcode)
+		assert.JSONEq(t, `{"price":10}`, string(payload))
+
+		opcode, payload = readWebSocketFrame(t, reader)
+		assert.Equal(t, byte(0x2), opcode)
+		assert.Equal(t, []byte{1, 2, 3}, payload)
+
+		opcode, payload = readWebSocketFrame(t, reader)
+		assert.Equal(t, byte(0x8), opcode)
+		assert.Equal(t, uint16(1001), binary.BigEndian.Uint16(payload))
+
+		writeWebSocketFrame(t, conn, 0x8, payload[:2])
+
+		assert.Empty(t, readString(reader), "the connection is closed")
+		ws.AssertCalledTimes(t, 1)
+	})
+
+	t.Run("fragmented messages are joined and pings answered", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		server.StubWebSocket(mockaso.Path("/ws")).Script(
+			mockaso.ExpectMessageFunc(func(message []byte) bool { return string(message) == "hello mockaso" }),
+			mockaso.SendMessage("hi"),
+		)
+
+		conn, reader := dialWebSocket(t, server, "/ws")
+
+		writeFragment(t, conn, false, 0x1, []byte("hello "))
+		writeWebSocketFrame(t, conn, 0x9, []byte("ping"))
+		writeFragment(t, conn, true, 0x0, []byte("mockaso"))
+
+		opcode, payload := readWebSocketFrame(t, reader)
+		assert.Equal(t, byte(0xa), opcode)
+		assert.Equal(t, "ping", string(payload))
+
+		opcode, payload = readWebSocketFrame(t, reader)
+		assert.Equal(t, byte(0x1), opcode)
+		assert.Equal(t, "hi", string(payload))
+
+		opcode, payload = readWebSocketFrame(t, reader)
+		assert.Equal(t, byte(0x8), opcode)
+		assert.Equal(t, uint16(1000), binary.BigEndian.Uint16(payload), "the script ended")
+	})
+
+	t.Run("unexpected message closes with policy violation", func(t *testing.T) {
+		t.Parallel()
+
+		logger, buff := newTestLogLogger()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(logger))
+		t.Cleanup(server.MustShutdown)
+
+		server.StubWebSocket(mockaso.Path("/ws")).Name("prices").Script(
+			mockaso.ExpectMessage("subscribe"),
+			mockaso.SendMessage("never sent"),
+		)
+
+		conn, reader := dialWebSocket(t, server, "/ws")
+
+		writeWebSocketFrame(t, conn, 0x1, []byte("unsubscribe"))
+
+		opcode, payload := readWebSocketFrame(t, reader)
+		assert.Equal(t, byte(0x8), opcode)
+		assert.Equal(t, uint16(1008), binary.BigEndian.Uint16(payload))
+		assert.Equal(t, `expected message "subscribe", got "unsubscribe"`, string(payload[2:]))
+
+		writeWebSocketFrame(t, conn, 0x8, payload[:2])
+		_ = readString(reader)
+
+		assert.Contains(t, buff.String(),
+			`stub "prices" GET /ws websocket script failed at step #1: expected message "subscribe", got "unsubscribe"`)
+	})
+
+	t.Run("should not match requests which are not websocket handshakes", func(t *testing.T) {
+		t.Parallel()
+
+		server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+		t.Cleanup(server.MustShutdown)
+
+		ws := server.StubWebSocket(mockaso.Path("/ws"))
+
+		httpReq, _ := http.NewRequest(http.MethodGet, "/ws", nil)
+
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assertNotMatchedResponse(t, httpReq, httpResp)
+		ws.AssertNotCalled(t)
+	})
+}
+
+// dialWebSocket opens a WebSocket connection to the server path and validates the handshake response.
+func dialWebSocket(t *testing.T, server *mockaso.Server, path string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL(), "http://"))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close() })
+	require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+
+	_, err = fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: mockaso\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n"+
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n", path)
+	require.NoError(t, err)
+
+	reader := bufio.NewReader(conn)
+
+	httpResp, err := http.ReadResponse(reader, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusSwitchingProtocols, httpResp.StatusCode)
+	require.Equal(t, "websocket", httpResp.Header.Get("Upgrade"))
+	require.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", httpResp.Header.Get("Sec-WebSocket-Accept")) // RFC 6455 sample
+
+	return conn, reader
+}
+
+func writeWebSocketFrame(t *testing.T, conn net.Conn, opcode byte, payload []byte) {
+	t.Helper()
+	writeFragment(t, conn, true, opcode, payload)
+}
+
+// writeFragment writes a masked client frame with a payload shorter than 126 bytes.
+func writeFragment(t *testing.T, conn net.Conn, fin bool, opcode byte, payload []byte) {
+	t.Helper()
+
+	if fin {
+		opcode |= 0x80
+	}
+
+	mask := []byte{0x12, 0x34, 0x56, 0x78}
+	frame := append([]byte{opcode, 0x80 | byte(len(payload))}, mask...)
+
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	_, err := conn.Write(frame)
+	require.NoError(t, err)
+}
+
+// readWebSocketFrame reads an unmasked server frame with a payload shorter than 126 bytes.
+func readWebSocketFrame(t *testing.T, reader *bufio.Reader) (byte, []byte) {
+	t.Helper()
+
+	var head [2]byte
+	_, err := io.ReadFull(reader, head[:])
+	require.NoError(t, err)
+
+	payload := make([]byte, head[1])
+	_, err = io.ReadFull(reader, payload)
+	require.NoError(t, err)
+
+	return head[0] & 0x0f, payload
+}
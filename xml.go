@@ -0,0 +1,168 @@
+package mockaso
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// MatchRawXMLBody sets a rule to match the http request with the given raw XML body.
+// Bodies are compared semantically: whitespace between elements, comments, the attribute order and the namespace
+// prefixes are ignored. It panics if the given XML is not valid.
+func MatchRawXMLBody[T string | []byte](raw T) StubMatcherRule {
+	expected, err := parseXML([]byte(raw))
+	if err != nil {
+		panic(fmt.Errorf("MatchRawXMLBody err: invalid xml: %w", err))
+	}
+
+	return matchXMLNode(expected)
+}
+
+// MatchXMLBody sets a rule to match the http request with the given XML body.
+// The specified body will be marshaled with encoding/xml and compared with the real body as in MatchRawXMLBody.
+func MatchXMLBody(body any) StubMatcherRule {
+	data, err := xml.Marshal(body)
+	if err != nil {
+		panic(fmt.Errorf("MatchXMLBody err: marshal body failed: %w", err))
+	}
+
+	expected, err := parseXML(data)
+	if err != nil {
+		panic(fmt.Errorf("MatchXMLBody err: invalid xml: %w", err))
+	}
+
+	return matchXMLNode(expected)
+}
+
+// WithRawXML sets the response content with the given XML.
+// The response will include the Content-Type:application/xml header. It panics if the given XML is not valid.
+func WithRawXML[T string | []byte](raw T) StubResponseRule {
+	data := []byte(raw)
+
+	if _, err := parseXML(data); err != nil {
+		panic(fmt.Errorf("WithRawXML err: invalid xml: %w", err))
+	}
+
+	return func(r *stubResponse) {
+		r.setXML(data)
+	}
+}
+
+// WithXML sets the response content with the encoding/xml marshal output of the given body.
+// The response will include the Content-Type:application/xml header.
+func WithXML(body any) StubResponseRule {
+	data, err := xml.Marshal(body)
+	if err != nil {
+		panic(fmt.Errorf("WithXML err: body marshal failed: %w", err))
+	}
+
+	return func(r *stubResponse) {
+		r.setXML(data)
+	}
+}
+
+func (r *stubResponse) setXML(content []byte) {
+	r.headers["Content-Type"] = "application/xml"
+	r.setBody(content)
+}
+
+func matchXMLNode(expected *xmlNode) StubMatcherRule {
+	matcher := RequestMatcherFunc(func(r *http.Request) bool {
+		actual, err := parseXML(mustReadDecodedBody(r))
+		if err != nil { // the request body is not valid XML
+			return false
+		}
+
+		return reflect.DeepEqual(expected, actual)
+	})
+
+	return describedRule(matcher, "body mismatch")
+}
+
+// xmlNode is a normalized XML element, to compare documents semantically.
+type xmlNode struct {
+	name     xml.Name   // the space is the namespace URL, not the prefix
+	attrs    []xml.Attr // sorted, without the namespace declarations
+	text     string     // trimmed
+	children []*xmlNode
+}
+
+// parseXML parses a document with a single root element into its normalized form.
+func parseXML(data []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var (
+		root  *xmlNode
+		stack []*xmlNode
+		text  []*strings.Builder
+	)
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if root != nil && len(stack) == 0 {
+				return nil, errors.New("more than one root element")
+			}
+
+			node := &xmlNode{name: t.Name, attrs: normalizeXMLAttrs(t.Attr)}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else {
+				root = node
+			}
+
+			stack = append(stack, node)
+			text = append(text, new(strings.Builder))
+		case xml.EndElement:
+			stack[len(stack)-1].text = strings.TrimSpace(text[len(text)-1].String())
+			stack, text = stack[:len(stack)-1], text[:len(text)-1]
+		case xml.CharData:
+			if len(text) > 0 {
+				text[len(text)-1].Write(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("no root element")
+	}
+
+	return root, nil
+}
+
+func normalizeXMLAttrs(attrs []xml.Attr) []xml.Attr {
+	normalized := slices.DeleteFunc(slices.Clone(attrs), func(attr xml.Attr) bool {
+		return attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" && attr.Name.Space == ""
+	})
+
+	slices.SortFunc(normalized, func(a, b xml.Attr) int {
+		if c := strings.Compare(a.Name.Space, b.Name.Space); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Name.Local, b.Name.Local)
+	})
+
+	if len(normalized) == 0 {
+		return nil
+	}
+
+	return normalized
+}
@@ -0,0 +1,184 @@
+package mockaso_test
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/royhq/mockaso"
+)
+
+func TestMatchRawXMLBody(t *testing.T) {
+	t.Parallel()
+
+	const expected = `<?xml version="1.0"?>
+		<user xmlns="urn:users" id="1" active="true">
+			<!-- the user name -->
+			<name>john</name>
+			<roles><role>admin</role><role>dev</role></roles>
+		</user>`
+
+	testCases := map[string]struct {
+		body     string
+		expected bool
+	}{
+		"should match the same xml": {
+			body:     expected,
+			expected: true,
+		},
+		"should match ignoring whitespace, comments and attributes order": {
+			body: `<user active="true" id="1" xmlns="urn:users"><name> john </name>` +
+				`<roles><role>admin</role><role>dev</role></roles></user>`,
+			expected: true,
+		},
+		"should match ignoring the namespace prefix": {
+			body: `<u:user xmlns:u="urn:users" active="true" id="1"><u:name>john</u:name>` +
+				`<u:roles><u:role>admin</u:role><u:role>dev</u:role></u:roles></u:user>`,
+			expected: true,
+		},
+		"should not match a different text": {
+			body: `<user xmlns="urn:users" id="1" active="true"><name>rick</name>` +
+				`<roles><role>admin</role><role>dev</role></roles></user>`,
+		},
+		"should not match a different attribute": {
+			body: `<user xmlns="urn:users" id="2" active="true"><name>john</name>` +
+				`<roles><role>admin</role><role>dev</role></roles></user>`,
+		},
+		"should not match a different children order": {
+			body: `<user xmlns="urn:users" id="1" active="true"><name>john</name>` +
+				`<roles><role>dev</role><role>admin</role></roles></user>`,
+		},
+		"should not match a different namespace": {
+			body: `<user xmlns="urn:orders" id="1" active="true"><name>john</name>` +
+				`<roles><role>admin</role><role>dev</role></roles></user>`,
+		},
+		"should not match an invalid xml": {
+			body: `<user xmlns="urn:users" id="1" active="true"><name>john</name>`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpReq := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tc.body))
+			assert.Equal(t, tc.expected, stubMatches(httpReq, mockaso.MatchRawXMLBody(expected)))
+		})
+	}
+
+	t.Run("should panic when the xml is not valid", func(t *testing.T) {
+		t.Parallel()
+
+		fn := func() { mockaso.MatchRawXMLBody(`<user><name>john</user>`) }
+		assert.PanicsWithError(t, "MatchRawXMLBody err: invalid xml: "+
+			"XML syntax error on line 1: element <name> closed by </user>", fn)
+	})
+}
+
+func TestMatchXMLBody(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		XMLName xml.Name `xml:"user"`
+		ID      int      `xml:"id,attr"`
+		Name    string   `xml:"name"`
+	}
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	const path = "/test/match-xml-body"
+
+	server.Stub(http.MethodPost, mockaso.Path(path)).
+		Match(mockaso.MatchXMLBody(user{ID: 1, Name: "john"})).
+		Respond(matchedRequestRules()...)
+
+	t.Run("should return the specified stub when request match", func(t *testing.T) {
+		t.Parallel()
+
+		body := strings.NewReader("<user id=\"1\">\n\t<name>john</name>\n</user>")
+		httpResp, err := server.Client().Post(path, "application/xml", body)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assertBodyString(t, "matched request", httpResp)
+	})
+
+	t.Run("should return no match response when request does not match", func(t *testing.T) {
+		t.Parallel()
+
+		httpReq, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(`<user id="1"><name>rick</name></user>`))
+		httpResp, err := server.Client().Do(httpReq)
+		require.NoError(t, err)
+
+		assertNotMatchedResponse(t, httpReq, httpResp)
+	})
+}
+
+func TestWithRawXML(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	t.Run("should return the specified xml", func(t *testing.T) {
+		const body = `<?xml version="1.0"?><user id="1"><name>john</name></user>`
+
+		server.Stub(http.MethodGet, mockaso.Path("/test/with-raw-xml")).Respond(mockaso.WithRawXML(body))
+
+		httpResp, err := server.Client().Get("/test/with-raw-xml")
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assert.Equal(t, "application/xml", httpResp.Header.Get("Content-Type"))
+		assertBodyString(t, body, httpResp)
+	})
+
+	t.Run("should panic", func(t *testing.T) {
+		testCases := map[string]struct {
+			body string
+		}{
+			"when the xml is not well-formed": {
+				body: `<user><name>john</user>`,
+			},
+			"when there is no root element": {
+				body: `john`,
+			},
+		}
+
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				assert.Panics(t, func() { mockaso.WithRawXML(tc.body) })
+			})
+		}
+	})
+}
+
+func TestWithXML(t *testing.T) {
+	t.Parallel()
+
+	server := mockaso.MustStartNewServer(mockaso.WithLogger(t))
+	t.Cleanup(server.MustShutdown)
+
+	type user struct {
+		XMLName xml.Name `xml:"user"`
+		ID      int      `xml:"id,attr"`
+		Name    string   `xml:"name"`
+	}
+
+	server.Stub(http.MethodGet, mockaso.Path("/test/with-xml")).Respond(mockaso.WithXML(user{ID: 1, Name: "john"}))
+
+	httpResp, err := server.Client().Get("/test/with-xml")
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+	assert.Equal(t, "application/xml", httpResp.Header.Get("Content-Type"))
+	assertBodyString(t, `<user id="1"><name>john</name></user>`, httpResp)
+}